@@ -0,0 +1,73 @@
+package sshconfig
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrNoAgent is returned by DialAgent if no agent is configured for the
+// host, because IdentityAgent is none or SSH_AUTH_SOCK is not set.
+var ErrNoAgent = errors.New("no ssh agent configured")
+
+// AgentSocket returns the socket of the agent ssh uses for the host. Like
+// ssh, an IdentityAgent of SSH_AUTH_SOCK or no IdentityAgent uses the
+// SSH_AUTH_SOCK environment variable, a value starting with $ names another
+// environment variable and other values are expanded by ExpandPath. An empty
+// socket is returned if IdentityAgent is none or the variable is not set.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func (h *SSHHost) AgentSocket() (string, error) {
+	value := h.IdentityAgent
+	switch {
+	case value == "" || value == "SSH_AUTH_SOCK":
+		return os.Getenv("SSH_AUTH_SOCK"), nil
+	case strings.EqualFold(value, "none"):
+		return "", nil
+	case strings.HasPrefix(value, "$") && !strings.HasPrefix(value, "${"):
+		return os.Getenv(value[1:]), nil
+	}
+	return h.ExpandPath(value)
+}
+
+// AgentConn is a connection to an ssh agent.
+type AgentConn struct {
+	agent.ExtendedAgent
+	conn io.ReadWriteCloser
+}
+
+// Close closes the connection to the agent.
+func (c *AgentConn) Close() error {
+	return c.conn.Close()
+}
+
+// DialAgent connects to the agent of host, see AgentSocket, so its keys can
+// be used with ssh.PublicKeysCallback. Named pipes like
+// \\.\pipe\openssh-ssh-agent are opened as file, other sockets are dialed as
+// unix socket. ErrNoAgent is returned if the host has no agent.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func DialAgent(host *SSHHost) (*AgentConn, error) {
+	socket, err := host.AgentSocket()
+	if err != nil {
+		return nil, err
+	}
+	if socket == "" {
+		return nil, ErrNoAgent
+	}
+
+	var conn io.ReadWriteCloser
+	if strings.HasPrefix(socket, `\\.\pipe\`) {
+		conn, err = os.OpenFile(socket, os.O_RDWR, 0)
+	} else {
+		conn, err = net.Dial("unix", socket)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &AgentConn{ExtendedAgent: agent.NewClient(conn), conn: conn}, nil
+}
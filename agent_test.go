@@ -0,0 +1,125 @@
+package sshconfig
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAgentSocket(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	homedir.DisableCache = true
+	homeEnv := "HOME"
+	if runtime.GOOS == "plan9" {
+		homeEnv = "home"
+	}
+	t.Setenv(homeEnv, tmpdir)
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/auth.sock")
+	t.Setenv("OTHER_SOCK", "/tmp/other.sock")
+
+	config := `Host quoted
+  IdentityAgent "~/agent sock"
+
+Host none
+  IdentityAgent none
+
+Host env
+  IdentityAgent SSH_AUTH_SOCK
+
+Host var
+  IdentityAgent $OTHER_SOCK
+
+Host braces
+  IdentityAgent ${OTHER_SOCK}/%h
+
+Host missing
+  IdentityAgent ${MISSING_SOCK}
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, tc := range []struct {
+		alias    string
+		expected string
+	}{
+		{"quoted", filepath.Join(tmpdir, "agent sock")},
+		{"none", ""},
+		{"env", "/tmp/auth.sock"},
+		{"var", "/tmp/other.sock"},
+		{"braces", "/tmp/other.sock/braces"},
+		{"default", "/tmp/auth.sock"},
+	} {
+		socket, err := Lookup(hosts, tc.alias).AgentSocket()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.alias, err)
+			continue
+		}
+		if socket != tc.expected {
+			t.Errorf("%s: expected socket %q, got %q", tc.alias, tc.expected, socket)
+		}
+	}
+
+	if _, err := Lookup(hosts, "missing").AgentSocket(); err == nil {
+		t.Errorf("missing: expected error for unset variable")
+	}
+	if _, err := DialAgent(Lookup(hosts, "none")); !errors.Is(err, ErrNoAgent) {
+		t.Errorf("none: expected ErrNoAgent, got %v", err)
+	}
+}
+
+func TestDialAgent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not available")
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("unable to listen: %s", err.Error())
+	}
+	defer l.Close()
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatalf("unable to add key: %s", err.Error())
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+
+	a, err := DialAgent(&SSHHost{IdentityAgent: socket})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer a.Close()
+
+	keys, err := a.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("expected 1 key, got %d", len(keys))
+	}
+}
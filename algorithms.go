@@ -0,0 +1,82 @@
+package sshconfig
+
+import "slices"
+
+// AlgorithmModifier tells how an algorithm list like Ciphers or MACs relates
+// to the default list of ssh.
+type AlgorithmModifier int
+
+const (
+	// AlgorithmsReplace replaces the default list.
+	AlgorithmsReplace AlgorithmModifier = iota
+	// AlgorithmsAppend appends to the default list, written as +list.
+	AlgorithmsAppend
+	// AlgorithmsRemove removes from the default list, written as -list.
+	AlgorithmsRemove
+	// AlgorithmsPrepend puts the algorithms first in the default list,
+	// written as ^list.
+	AlgorithmsPrepend
+)
+
+// ParseAlgorithms returns the modifier of list and its algorithms without the
+// modifier. Lists of the Ciphers, MACs, KexAlgorithms, HostKeyAlgorithms,
+// PubkeyAcceptedAlgorithms and HostbasedAcceptedAlgorithms keywords are kept
+// as written, with the modifier as prefix of their first element.
+func ParseAlgorithms(list []string) (AlgorithmModifier, []string) {
+	if len(list) == 0 || list[0] == "" {
+		return AlgorithmsReplace, list
+	}
+
+	var modifier AlgorithmModifier
+	switch list[0][0] {
+	case '+':
+		modifier = AlgorithmsAppend
+	case '-':
+		modifier = AlgorithmsRemove
+	case '^':
+		modifier = AlgorithmsPrepend
+	default:
+		return AlgorithmsReplace, list
+	}
+
+	algorithms := append([]string{list[0][1:]}, list[1:]...)
+	return modifier, algorithms
+}
+
+// EffectiveAlgorithms returns the algorithms used by applying list to the
+// default list of an implementation. Algorithms to remove may be given as
+// patterns like `*-cbc`. Without a list the defaults are returned.
+func EffectiveAlgorithms(list []string, defaults []string) []string {
+	if len(list) == 0 {
+		return defaults
+	}
+
+	modifier, algorithms := ParseAlgorithms(list)
+	var result []string
+	switch modifier {
+	case AlgorithmsAppend:
+		result = append(result, defaults...)
+		for _, a := range algorithms {
+			if !slices.Contains(result, a) {
+				result = append(result, a)
+			}
+		}
+	case AlgorithmsRemove:
+		for _, d := range defaults {
+			if !matchHost(algorithms, d) {
+				result = append(result, d)
+			}
+		}
+	case AlgorithmsPrepend:
+		result = append(result, algorithms...)
+		for _, d := range defaults {
+			if !slices.Contains(algorithms, d) {
+				result = append(result, d)
+			}
+		}
+	default:
+		result = append(result, algorithms...)
+	}
+
+	return result
+}
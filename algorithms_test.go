@@ -0,0 +1,41 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEffectiveAlgorithms(t *testing.T) {
+	defaults := []string{"aes128-ctr", "aes256-ctr", "aes128-cbc", "aes256-cbc"}
+
+	for _, tc := range []struct {
+		list     []string
+		modifier AlgorithmModifier
+		expected []string
+	}{
+		{nil, AlgorithmsReplace, defaults},
+		{[]string{"aes256-ctr"}, AlgorithmsReplace, []string{"aes256-ctr"}},
+		{[]string{"+3des-cbc", "aes128-ctr"}, AlgorithmsAppend, []string{"aes128-ctr", "aes256-ctr", "aes128-cbc", "aes256-cbc", "3des-cbc"}},
+		{[]string{"-*-cbc"}, AlgorithmsRemove, []string{"aes128-ctr", "aes256-ctr"}},
+		{[]string{"^aes256-cbc"}, AlgorithmsPrepend, []string{"aes256-cbc", "aes128-ctr", "aes256-ctr", "aes128-cbc"}},
+	} {
+		if modifier, _ := ParseAlgorithms(tc.list); modifier != tc.modifier {
+			t.Errorf("%v: expected modifier %d, got %d", tc.list, tc.modifier, modifier)
+		}
+		if actual := EffectiveAlgorithms(tc.list, defaults); !reflect.DeepEqual(tc.expected, actual) {
+			t.Errorf("%v: expected %v, got %v", tc.list, tc.expected, actual)
+		}
+	}
+}
+
+func TestParseAlgorithmsKeyword(t *testing.T) {
+	hosts, err := parse("Host *\n  Ciphers +aes128-cbc,3des-cbc", "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	modifier, algorithms := ParseAlgorithms(hosts[0].Ciphers)
+	if modifier != AlgorithmsAppend || !reflect.DeepEqual([]string{"aes128-cbc", "3des-cbc"}, algorithms) {
+		t.Errorf("unexpected algorithms: %d %v", modifier, algorithms)
+	}
+}
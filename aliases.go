@@ -0,0 +1,94 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListAliases returns the aliases named in the Host lines of the config
+// given by path, without patterns and duplicates, in file order, like
+// Config.Aliases. It is much faster than Parse for large configs as it only
+// looks at Host and Include lines, for callers like shell completions which
+// need the aliases often and nothing else. Other lines are not validated.
+//
+// Included files are read like Parse does with the IncludeResolver,
+// IncludeMode and include depth of opts, unless WithoutIncludes is given.
+// Other options are ignored.
+func ListAliases(path string, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+	l := aliasLister{o: o, seen: map[string]bool{}}
+	content, err := o.resolver.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.scan(content, path, 0); err != nil {
+		return nil, err
+	}
+	return l.aliases, nil
+}
+
+type aliasLister struct {
+	o       *options
+	seen    map[string]bool
+	aliases []string
+}
+
+// scan adds the aliases of the config file path with content, included at
+// depth.
+func (l *aliasLister) scan(content []byte, path string, depth int) error {
+	for i, line := range strings.Split(string(content), "\n") {
+		n := i + 1
+		keyword, value := splitLine(line)
+		switch {
+		case strings.EqualFold(keyword, "Host"):
+			aliases, err := splitArgs(value)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", path, n, err)
+			}
+			for _, alias := range aliases {
+				if isPattern(alias) || l.seen[alias] {
+					continue
+				}
+				l.seen[alias] = true
+				l.aliases = append(l.aliases, alias)
+			}
+		case strings.EqualFold(keyword, "Include") && !l.o.skipIncludes:
+			if depth >= l.o.includeDepth {
+				return fmt.Errorf("%s:%d: maximum include depth of %d exceeded", path, n, l.o.includeDepth)
+			}
+			for _, pattern := range strings.Fields(value) {
+				files, err := includeFiles(path, pattern, l.o)
+				if err != nil {
+					return fmt.Errorf("%s:%d: %w", path, n, err)
+				}
+				for _, f := range files {
+					content, err := l.o.resolver.ReadFile(f)
+					if err != nil {
+						return fmt.Errorf("%s:%d: %w", path, n, err)
+					}
+					if err := l.scan(content, f, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// splitLine splits a config line into its keyword and value, separated by
+// whitespace or an equal sign like the lexer does. Comments and blank lines
+// have no keyword.
+func splitLine(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", ""
+	}
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+	value := strings.TrimLeft(line[i:], " \t")
+	value = strings.TrimPrefix(value, "=")
+	return line[:i], strings.TrimLeft(value, " \t")
+}
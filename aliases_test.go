@@ -0,0 +1,54 @@
+package sshconfig
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestListAliases(t *testing.T) {
+	resolver := mapResolver{
+		"/ssh/config": `# Host commented
+Host web "my server" *.example.com !db
+  HostName web.example.com
+
+host=db
+  Include conf.d/*.conf
+
+Host web
+`,
+		"/ssh/conf.d/a.conf": "Host a1 a2\n",
+		"/ssh/conf.d/b.conf": "  HOST   b1\n",
+	}
+
+	aliases, err := ListAliases("/ssh/config", WithIncludeResolver(resolver))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{"web", "my server", "db", "a1", "a2", "b1"}
+	if !slices.Equal(aliases, expected) {
+		t.Errorf("expected %v, got %v", expected, aliases)
+	}
+
+	aliases, err = ListAliases("/ssh/config", WithIncludeResolver(resolver), WithoutIncludes())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected = []string{"web", "my server", "db"}
+	if !slices.Equal(aliases, expected) {
+		t.Errorf("expected %v, got %v", expected, aliases)
+	}
+
+	hosts, err := ParseString("Include conf.d/*.conf\nHost web\n", "/ssh/config", WithIncludeResolver(resolver), WithoutIncludes())
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if len(hosts) != 1 {
+		t.Errorf("expected Include to be skipped, got %d hosts", len(hosts))
+	}
+
+	resolver["/ssh/config"] = "Host \"web\n"
+	_, err = ListAliases("/ssh/config", WithIncludeResolver(resolver))
+	if err == nil {
+		t.Errorf("expected error for unterminated quote")
+	}
+}
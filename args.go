@@ -0,0 +1,59 @@
+package sshconfig
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Args returns the ssh command line flags equivalent to the host, to be
+// passed to ssh before the destination. User, Port, IdentityFile, ProxyJump
+// and forwards are given by their own flags, every other keyword including
+// unknown ones by -o Keyword=Value.
+func (h *SSHHost) Args() []string {
+	var args []string
+
+	if h.User != "" {
+		args = append(args, "-l", h.User)
+	}
+	if h.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(h.Port))
+	}
+	for _, f := range h.IdentityFiles {
+		args = append(args, "-i", f)
+	}
+	if h.ProxyJump != "" {
+		args = append(args, "-J", h.ProxyJump)
+	}
+	for _, f := range h.LocalForwards {
+		args = append(args, "-L", f.Arg())
+	}
+	for _, f := range h.RemoteForwards {
+		args = append(args, "-R", f.Arg())
+	}
+	for _, f := range h.DynamicForwards {
+		args = append(args, "-D", f.String())
+	}
+
+	for _, keyword := range keywords {
+		switch keyword {
+		case "User", "Port", "IdentityFile", "ProxyJump", "LocalForward", "RemoteForward", "DynamicForward":
+			continue
+		}
+		for _, value := range h.GetAll(keyword) {
+			args = append(args, "-o", keyword+"="+value)
+		}
+	}
+
+	unknowns := make([]string, 0, len(h.Unknowns))
+	for keyword := range h.Unknowns {
+		unknowns = append(unknowns, keyword)
+	}
+	sort.Strings(unknowns)
+	for _, keyword := range unknowns {
+		for _, value := range h.Unknowns[keyword] {
+			args = append(args, "-o", keyword+"="+value)
+		}
+	}
+
+	return args
+}
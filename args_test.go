@@ -0,0 +1,45 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  User deploy
+  Port 2222
+  IdentityFile ~/.ssh/web
+  IdentityFile ~/.ssh/id_ed25519
+  ProxyJump bastion
+  LocalForward 8080 localhost:80
+  RemoteForward 127.0.0.1:9090 localhost:90
+  DynamicForward 1080
+  ProxyCommand none
+  Ciphers aes256-ctr,aes128-ctr
+  VisualHostKey yes`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []string{
+		"-l", "deploy",
+		"-p", "2222",
+		"-i", "~/.ssh/web",
+		"-i", "~/.ssh/id_ed25519",
+		"-J", "bastion",
+		"-L", "8080:localhost:80",
+		"-R", "127.0.0.1:9090:localhost:90",
+		"-D", "1080",
+		"-o", "HostName=web.example.com",
+		"-o", "ProxyCommand=none",
+		"-o", "Ciphers=aes256-ctr,aes128-ctr",
+		"-o", "VisualHostKey=yes",
+	}
+	if args := hosts[0].Args(); !reflect.DeepEqual(expected, args) {
+		t.Errorf("unexpected args:\n%q\nexpected:\n%q", args, expected)
+	}
+}
@@ -0,0 +1,147 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Audit rule IDs.
+const (
+	// RuleWeakAlgorithm flags an algorithm listed by DefaultAuditPolicy or
+	// the policy given to Audit.
+	RuleWeakAlgorithm = "weak-algorithm"
+	// RuleProtocol1 flags `Protocol 1`, which OpenSSH no longer supports.
+	RuleProtocol1 = "protocol-1"
+)
+
+// AuditRule flags algorithms of a keyword matching a pattern.
+type AuditRule struct {
+	// Keyword is one of Ciphers, MACs, KexAlgorithms, HostKeyAlgorithms,
+	// PubkeyAcceptedAlgorithms and HostbasedAcceptedAlgorithms.
+	Keyword string
+	// Pattern is matched against each algorithm, like `*-cbc`.
+	Pattern  string
+	Severity Severity
+	Reason   string
+}
+
+// DefaultAuditPolicy flags algorithms which are broken, like 3des-cbc or
+// ssh-dss, as errors and algorithms which are weak or deprecated by
+// OpenSSH, like SHA-1 based ones, as warnings.
+var DefaultAuditPolicy = []AuditRule{
+	{"Ciphers", "3des-cbc", SeverityError, "64-bit block cipher"},
+	{"Ciphers", "blowfish-cbc", SeverityError, "64-bit block cipher"},
+	{"Ciphers", "cast128-cbc", SeverityError, "64-bit block cipher"},
+	{"Ciphers", "arcfour*", SeverityError, "RC4 is broken"},
+	{"Ciphers", "des*", SeverityError, "DES is broken"},
+	{"Ciphers", "rijndael-cbc@lysator.liu.se", SeverityWarning, "CBC mode is vulnerable to plaintext recovery"},
+	{"Ciphers", "aes*-cbc", SeverityWarning, "CBC mode is vulnerable to plaintext recovery"},
+	{"MACs", "hmac-md5*", SeverityError, "MD5 is broken"},
+	{"MACs", "hmac-ripemd160*", SeverityWarning, "removed from OpenSSH"},
+	{"MACs", "hmac-sha1-96*", SeverityWarning, "truncated SHA-1"},
+	{"MACs", "hmac-sha1*", SeverityWarning, "SHA-1 is deprecated"},
+	{"MACs", "umac-64*", SeverityWarning, "64-bit tag"},
+	{"KexAlgorithms", "diffie-hellman-group1-sha1", SeverityError, "1024-bit group with SHA-1"},
+	{"KexAlgorithms", "diffie-hellman-group14-sha1", SeverityWarning, "SHA-1 is deprecated"},
+	{"KexAlgorithms", "diffie-hellman-group-exchange-sha1", SeverityWarning, "SHA-1 is deprecated"},
+	{"HostKeyAlgorithms", "ssh-dss*", SeverityError, "DSA is removed from OpenSSH"},
+	{"HostKeyAlgorithms", "ssh-rsa", SeverityWarning, "RSA signatures with SHA-1 are deprecated"},
+	{"HostKeyAlgorithms", "ssh-rsa-cert-v01@openssh.com", SeverityWarning, "RSA signatures with SHA-1 are deprecated"},
+	{"PubkeyAcceptedAlgorithms", "ssh-dss*", SeverityError, "DSA is removed from OpenSSH"},
+	{"PubkeyAcceptedAlgorithms", "ssh-rsa", SeverityWarning, "RSA signatures with SHA-1 are deprecated"},
+	{"PubkeyAcceptedAlgorithms", "ssh-rsa-cert-v01@openssh.com", SeverityWarning, "RSA signatures with SHA-1 are deprecated"},
+	{"HostbasedAcceptedAlgorithms", "ssh-dss*", SeverityError, "DSA is removed from OpenSSH"},
+	{"HostbasedAcceptedAlgorithms", "ssh-rsa", SeverityWarning, "RSA signatures with SHA-1 are deprecated"},
+	{"HostbasedAcceptedAlgorithms", "ssh-rsa-cert-v01@openssh.com", SeverityWarning, "RSA signatures with SHA-1 are deprecated"},
+}
+
+// Audit checks the algorithms enabled by each host against policy and
+// reports `Protocol 1`. Without a policy DefaultAuditPolicy is used. The
+// first rule matching an algorithm is reported. Algorithms removed from the
+// defaults with a -list are not flagged.
+func Audit(hosts []*SSHHost, policy []AuditRule) []Finding {
+	if policy == nil {
+		policy = DefaultAuditPolicy
+	}
+
+	var findings []Finding
+	for _, h := range hosts {
+		for _, keyword := range []string{"Ciphers", "MACs", "KexAlgorithms", "HostKeyAlgorithms", "PubkeyAcceptedAlgorithms", "HostbasedAcceptedAlgorithms"} {
+			modifier, algorithms := ParseAlgorithms(algorithmList(h, keyword))
+			if modifier == AlgorithmsRemove {
+				continue
+			}
+			line := h.directiveLine(keyword)
+			for _, algorithm := range algorithms {
+				for _, rule := range policy {
+					if !strings.EqualFold(rule.Keyword, keyword) || !matchPattern(rule.Pattern, algorithm) {
+						continue
+					}
+					findings = append(findings, Finding{
+						Rule:     RuleWeakAlgorithm,
+						Severity: rule.Severity,
+						File:     h.SourceFile,
+						Line:     line,
+						Msg:      fmt.Sprintf("%s enables %s: %s", keyword, algorithm, rule.Reason),
+					})
+					break
+				}
+			}
+		}
+
+		for keyword, values := range h.Unknowns {
+			if !strings.EqualFold(keyword, "Protocol") {
+				continue
+			}
+			for _, value := range values {
+				for _, version := range strings.Split(value, ",") {
+					if strings.TrimSpace(version) == "1" {
+						findings = append(findings, Finding{
+							Rule:     RuleProtocol1,
+							Severity: SeverityError,
+							File:     h.SourceFile,
+							Line:     h.directiveLine(keyword),
+							Msg:      "Protocol 1 is insecure and no longer supported",
+						})
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// Audit checks the hosts of the config against policy, see Audit.
+func (c *Config) Audit(policy []AuditRule) []Finding {
+	return Audit(c.hosts, policy)
+}
+
+// algorithmList returns the list of an algorithm keyword of h.
+func algorithmList(h *SSHHost, keyword string) []string {
+	switch keyword {
+	case "Ciphers":
+		return h.Ciphers
+	case "MACs":
+		return h.MACs
+	case "KexAlgorithms":
+		return h.KexAlgorithms
+	case "HostKeyAlgorithms":
+		return h.HostKeyAlgorithms
+	case "PubkeyAcceptedAlgorithms":
+		return h.PubkeyAcceptedAlgorithms
+	case "HostbasedAcceptedAlgorithms":
+		return h.HostbasedAcceptedAlgorithms
+	}
+	return nil
+}
+
+// directiveLine returns the line of the first directive of h setting
+// keyword, or the line of the block.
+func (h *SSHHost) directiveLine(keyword string) int {
+	for _, d := range h.Directives {
+		if strings.EqualFold(d.Keyword, keyword) {
+			return d.Line
+		}
+	}
+	return h.SourceLine
+}
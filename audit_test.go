@@ -0,0 +1,49 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	config := `Host legacy
+  Protocol 2,1
+  Ciphers aes128-ctr,aes256-cbc,3des-cbc
+  MACs +hmac-sha1
+  HostKeyAlgorithms +ssh-rsa,ssh-dss
+
+Host modern
+  Ciphers -*-cbc
+  KexAlgorithms ^diffie-hellman-group1-sha1
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []Finding{
+		{Rule: RuleWeakAlgorithm, Severity: SeverityWarning, File: "~/.ssh/config", Line: 3, Msg: "Ciphers enables aes256-cbc: CBC mode is vulnerable to plaintext recovery"},
+		{Rule: RuleWeakAlgorithm, Severity: SeverityError, File: "~/.ssh/config", Line: 3, Msg: "Ciphers enables 3des-cbc: 64-bit block cipher"},
+		{Rule: RuleWeakAlgorithm, Severity: SeverityWarning, File: "~/.ssh/config", Line: 4, Msg: "MACs enables hmac-sha1: SHA-1 is deprecated"},
+		{Rule: RuleWeakAlgorithm, Severity: SeverityWarning, File: "~/.ssh/config", Line: 5, Msg: "HostKeyAlgorithms enables ssh-rsa: RSA signatures with SHA-1 are deprecated"},
+		{Rule: RuleWeakAlgorithm, Severity: SeverityError, File: "~/.ssh/config", Line: 5, Msg: "HostKeyAlgorithms enables ssh-dss: DSA is removed from OpenSSH"},
+		{Rule: RuleProtocol1, Severity: SeverityError, File: "~/.ssh/config", Line: 2, Msg: "Protocol 1 is insecure and no longer supported"},
+		{Rule: RuleWeakAlgorithm, Severity: SeverityError, File: "~/.ssh/config", Line: 9, Msg: "KexAlgorithms enables diffie-hellman-group1-sha1: 1024-bit group with SHA-1"},
+	}
+
+	findings := NewConfig(hosts).Audit(nil)
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %v", len(expected), len(findings), findings)
+	}
+	for i, f := range findings {
+		if f != expected[i] {
+			t.Errorf("unexpected finding %d: %v, expected %v", i, f, expected[i])
+		}
+	}
+
+	policy := []AuditRule{{Keyword: "Ciphers", Pattern: "aes128-*", Severity: SeverityInfo, Reason: "prefer aes256"}}
+	findings = Audit(hosts, policy)
+	if len(findings) != 2 || findings[0].Msg != "Ciphers enables aes128-ctr: prefer aes256" || findings[1].Rule != RuleProtocol1 {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
@@ -0,0 +1,167 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HostBuilder constructs a Host block in code, validating every value the
+// same way the parser does. Errors are collected and returned by Build, so
+// calls can be chained:
+//
+//	host, err := NewHostBuilder("web").
+//		HostName("web.example.com").
+//		Port(2222).
+//		IdentityFile("~/.ssh/id_ed25519").
+//		Build()
+type HostBuilder struct {
+	host *SSHHost
+	errs []error
+}
+
+// NewHostBuilder returns a builder for a Host block matching the given
+// aliases or patterns.
+func NewHostBuilder(patterns ...string) *HostBuilder {
+	b := &HostBuilder{host: &SSHHost{Host: patterns, Port: 22}}
+	if len(patterns) == 0 {
+		b.errs = append(b.errs, errors.New("Host requires at least one pattern"))
+	}
+	for _, p := range patterns {
+		if err := validatePattern(p); err != nil {
+			b.errs = append(b.errs, err)
+		}
+	}
+	return b
+}
+
+// HostName sets the HostName.
+func (b *HostBuilder) HostName(name string) *HostBuilder {
+	return b.set("HostName", name)
+}
+
+// User sets the User.
+func (b *HostBuilder) User(user string) *HostBuilder {
+	return b.set("User", user)
+}
+
+// Port sets the Port, which must be between 1 and 65535.
+func (b *HostBuilder) Port(port int) *HostBuilder {
+	if port < 1 || port > 65535 {
+		b.errs = append(b.errs, fmt.Errorf("Port out of range: %d", port))
+		return b
+	}
+	return b.set("Port", strconv.Itoa(port))
+}
+
+// IdentityFile adds an IdentityFile.
+func (b *HostBuilder) IdentityFile(path string) *HostBuilder {
+	return b.set("IdentityFile", path)
+}
+
+// ProxyJump sets the jump hosts to connect through.
+func (b *HostBuilder) ProxyJump(hosts ...string) *HostBuilder {
+	return b.set("ProxyJump", strings.Join(hosts, ","))
+}
+
+// LocalForward adds a LocalForward.
+func (b *HostBuilder) LocalForward(f Forward) *HostBuilder {
+	if err := validateForward(f, false); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid LocalForward: %w", err))
+		return b
+	}
+	return b.add("LocalForward", f.String())
+}
+
+// RemoteForward adds a RemoteForward. A forward without destination is a
+// reverse dynamic forward.
+func (b *HostBuilder) RemoteForward(f Forward) *HostBuilder {
+	if err := validateForward(f, true); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid RemoteForward: %w", err))
+		return b
+	}
+	return b.add("RemoteForward", f.String())
+}
+
+// DynamicForward adds a DynamicForward.
+func (b *HostBuilder) DynamicForward(f DynamicForward) *HostBuilder {
+	if f.Port < 1 || f.Port > 65535 {
+		b.errs = append(b.errs, fmt.Errorf("invalid DynamicForward: port out of range: %d", f.Port))
+		return b
+	}
+	return b.add("DynamicForward", f.String())
+}
+
+// Set sets any other keyword, including unknown ones. The value is given as
+// written in a config file, see SSHHost.Set.
+func (b *HostBuilder) Set(keyword, value string) *HostBuilder {
+	if err := b.host.Set(keyword, value); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid %s: %w", keyword, err))
+	}
+	return b
+}
+
+// Build returns the host, or all errors found while building it.
+func (b *HostBuilder) Build() (*SSHHost, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+	return b.host, nil
+}
+
+// set sets a single argument value, replacing the previous one.
+func (b *HostBuilder) set(keyword, value string) *HostBuilder {
+	if value == "" {
+		b.errs = append(b.errs, fmt.Errorf("empty %s", keyword))
+		return b
+	}
+	if keyword == "IdentityFile" {
+		return b.add(keyword, quoteArg(value))
+	}
+	return b.Set(keyword, quoteArg(value))
+}
+
+// add adds a value of a keyword which may be given multiple times.
+func (b *HostBuilder) add(keyword, value string) *HostBuilder {
+	typ := variables[strings.ToLower(keyword)]
+	if err := b.host.setValue(typ, keyword, value); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid %s: %w", keyword, err))
+		return b
+	}
+	b.host.Directives = append(b.host.Directives, Directive{Keyword: keyword, Value: value})
+	return b
+}
+
+// validatePattern checks the syntax of a single Host pattern.
+func validatePattern(p string) error {
+	switch {
+	case p == "" || p == "!":
+		return fmt.Errorf("invalid Host pattern: %#v", p)
+	case strings.Contains(p[1:], "!"):
+		return fmt.Errorf("invalid Host pattern %#v: ! is only allowed at the start", p)
+	case strings.ContainsAny(p, ",\n"):
+		return fmt.Errorf("invalid Host pattern: %#v", p)
+	}
+	return nil
+}
+
+// validateForward checks that a forward has a listen address and a
+// destination, which remote forwards may leave out.
+func validateForward(f Forward, remote bool) error {
+	if f.InSocket == "" {
+		if f.InPort < 0 || f.InPort > 65535 || (!remote && f.InPort == 0) {
+			return fmt.Errorf("listen port out of range: %d", f.InPort)
+		}
+	}
+	if f.IsDynamic() {
+		if !remote {
+			return errors.New("missing destination")
+		}
+		return nil
+	}
+	if f.OutSocket == "" && (f.OutPort < 1 || f.OutPort > 65535) {
+		return fmt.Errorf("destination port out of range: %d", f.OutPort)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sshconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHostBuilder(t *testing.T) {
+	host, err := NewHostBuilder("web", "*.web.internal").
+		HostName("web.example.com").
+		User("deploy").
+		Port(2222).
+		IdentityFile("~/keys/my key").
+		IdentityFile("~/.ssh/id_ed25519").
+		ProxyJump("bastion", "jump@gateway:2222").
+		LocalForward(Forward{InPort: 8080, OutHost: "localhost", OutPort: 80}).
+		RemoteForward(Forward{InPort: 9090}).
+		DynamicForward(DynamicForward{Port: 1080}).
+		Set("ServerAliveInterval", "30").
+		Set("UseKeychain", "yes").
+		Build()
+	if err != nil {
+		t.Fatalf("unable to build host: %s", err.Error())
+	}
+
+	if host.HostName != "web.example.com" || host.User != "deploy" || host.Port != 2222 {
+		t.Errorf("unexpected host: %+v", host)
+	}
+	if !reflect.DeepEqual(host.IdentityFiles, []string{"~/keys/my key", "~/.ssh/id_ed25519"}) {
+		t.Errorf("unexpected IdentityFiles: %v", host.IdentityFiles)
+	}
+	if host.ProxyJump != "bastion,jump@gateway:2222" || host.ServerAliveInterval != 30*time.Second {
+		t.Errorf("unexpected host: %+v", host)
+	}
+
+	// the built host renders into a config which parses back to it
+	text, err := host.MarshalText()
+	if err != nil {
+		t.Fatalf("unable to marshal host: %s", err.Error())
+	}
+	parsed, err := parse(string(text), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse built host: %s", err.Error())
+	}
+	compare(t, []*SSHHost{host}, parsed)
+}
+
+func TestHostBuilderValidation(t *testing.T) {
+	_, err := NewHostBuilder("web", "a!b", "").
+		Port(70000).
+		User("").
+		LocalForward(Forward{InPort: 8080}).
+		RemoteForward(Forward{InPort: 9090, OutHost: "localhost", OutPort: 0}).
+		DynamicForward(DynamicForward{Port: 0}).
+		Set("ServerAliveInterval", "often").
+		Set("Host", "other").
+		Build()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	expected := []string{
+		`invalid Host pattern "a!b": ! is only allowed at the start`,
+		`invalid Host pattern: ""`,
+		"Port out of range: 70000",
+		"empty User",
+		"invalid LocalForward: missing destination",
+		"invalid RemoteForward: destination port out of range: 0",
+		"invalid DynamicForward: port out of range: 0",
+		`invalid ServerAliveInterval: invalid time: "often"`,
+		"invalid Host: Host can not be set on a host",
+	}
+	if msgs := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(expected, msgs) {
+		t.Errorf("unexpected errors:\n%s", strings.Join(msgs, "\n"))
+	}
+
+	if _, err := NewHostBuilder().Build(); err == nil {
+		t.Errorf("expected error for host without patterns")
+	}
+}
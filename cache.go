@@ -0,0 +1,93 @@
+package sshconfig
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// IncludeCache keeps the lexed content of included files between parses, so
+// programs parsing the config over and over only read and lex included files
+// which changed since. Files are considered unchanged as long as their
+// modification time and size stay the same.
+//
+// Caching requires an include resolver with a Stat(name) (fs.FileInfo, error)
+// method like the default one and the one returned by FSResolver, files of
+// other resolvers are read on every parse. A cache must only be used with a
+// single resolver, it is safe for concurrent use.
+type IncludeCache struct {
+	mu    sync.Mutex
+	files map[string]cachedFile
+}
+
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	input   string
+	items   []item
+}
+
+// NewIncludeCache returns an empty IncludeCache.
+func NewIncludeCache() *IncludeCache {
+	return &IncludeCache{files: map[string]cachedFile{}}
+}
+
+// WithIncludeCache makes the parser take included files from c.
+func WithIncludeCache(c *IncludeCache) Option {
+	return func(o *options) {
+		o.cache = c
+	}
+}
+
+// statResolver is implemented by resolvers supporting the include cache.
+type statResolver interface {
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// readInclude returns the content of the included file name and a lexer for
+// it, taken from the include cache if possible.
+func readInclude(name string, o *options) (string, *lexer, error) {
+	if r, ok := o.resolver.(statResolver); ok && o.cache != nil {
+		return o.cache.lexer(name, o.resolver, r)
+	}
+
+	content, err := o.resolver.ReadFile(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	input := string(content)
+	return input, lexWithComments(input), nil
+}
+
+func (c *IncludeCache) lexer(name string, resolver IncludeResolver, r statResolver) (string, *lexer, error) {
+	info, err := r.Stat(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.mu.Lock()
+	f, ok := c.files[name]
+	c.mu.Unlock()
+	if ok && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+		return f.input, replay(f.items), nil
+	}
+
+	content, err := resolver.ReadFile(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f = cachedFile{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		input:   string(content),
+	}
+	f.items = lexAll(f.input)
+
+	c.mu.Lock()
+	c.files[name] = f
+	c.mu.Unlock()
+
+	return f.input, replay(f.items), nil
+}
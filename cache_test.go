@@ -0,0 +1,46 @@
+package sshconfig
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestIncludeCache(t *testing.T) {
+	memfs := fstest.MapFS{
+		"ssh/hosts.conf": &fstest.MapFile{
+			Data:    []byte("Host web\n  User deploy\n"),
+			ModTime: time.Unix(1000, 0),
+		},
+	}
+	cache := NewIncludeCache()
+
+	parseUser := func() string {
+		hosts, err := ParseString("Include hosts.conf\n", "ssh/config",
+			WithIncludeResolver(FSResolver(memfs)),
+			WithIncludeCache(cache),
+		)
+		if err != nil {
+			t.Fatalf("unable to parse config: %s", err.Error())
+		}
+		if len(hosts) != 1 {
+			t.Fatalf("expected 1 host, got %d", len(hosts))
+		}
+		return hosts[0].User
+	}
+
+	if user := parseUser(); user != "deploy" {
+		t.Errorf("expected user deploy, got %s", user)
+	}
+
+	// same size and modification time, the cached content is used
+	memfs["ssh/hosts.conf"].Data = []byte("Host web\n  User backup\n")
+	if user := parseUser(); user != "deploy" {
+		t.Errorf("expected cached user deploy, got %s", user)
+	}
+
+	memfs["ssh/hosts.conf"].ModTime = time.Unix(2000, 0)
+	if user := parseUser(); user != "backup" {
+		t.Errorf("expected user backup after change, got %s", user)
+	}
+}
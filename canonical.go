@@ -0,0 +1,107 @@
+package sshconfig
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// HostResolver resolves host names for canonicalization, it is implemented
+// by *net.Resolver.
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// WithCanonicalization makes Lookup canonicalize the host name using r as
+// configured by CanonicalizeHostname and related keywords. After the host
+// name was canonicalized, the blocks not applied yet are applied again
+// matching the canonical name, including `Match canonical` blocks, the same
+// way ssh parses its config a second time.
+//
+// Lookup can not report resolution errors, if CanonicalizeFallbackLocal
+// forbids falling back to the unresolved name the host is returned
+// uncanonicalized. Use Canonicalize to get the error.
+func WithCanonicalization(r HostResolver) LookupOption {
+	return func(o *lookupOptions) {
+		o.resolver = r
+	}
+}
+
+// Canonicalize returns the canonical name of name as ssh determines it with
+// the canonicalization settings of the host, and whether name was
+// canonicalized. Names with more dots than CanonicalizeMaxDots, which
+// defaults to 1 unless CanonicalizeMaxDotsSet, are left alone, so with an
+// explicit 0 only names without dots are canonicalized. An error is returned
+// if name could not be resolved in any of the CanonicalDomains and
+// CanonicalizeFallbackLocal is no.
+func (h *SSHHost) Canonicalize(ctx context.Context, name string, r HostResolver) (string, bool, error) {
+	switch strings.ToLower(h.CanonicalizeHostname) {
+	case "always":
+	case "yes":
+		// ssh leaves proxied connections to the proxy
+		if (h.ProxyCommand != "" && !strings.EqualFold(h.ProxyCommand, "none")) ||
+			(h.ProxyJump != "" && !strings.EqualFold(h.ProxyJump, "none")) {
+			return name, false, nil
+		}
+	default:
+		return name, false, nil
+	}
+
+	if strings.HasSuffix(name, ".") {
+		return strings.TrimSuffix(name, "."), true, nil
+	}
+
+	if net.ParseIP(name) != nil {
+		return name, false, nil
+	}
+
+	maxDots := h.CanonicalizeMaxDots
+	if maxDots == 0 && !h.CanonicalizeMaxDotsSet {
+		maxDots = 1
+	}
+	if strings.Count(name, ".") > maxDots {
+		return name, false, nil
+	}
+
+	for _, domain := range h.CanonicalDomains {
+		fqdn := name + "." + domain
+		if _, err := r.LookupHost(ctx, fqdn); err != nil {
+			continue
+		}
+
+		return h.permittedCNAME(ctx, fqdn, r), true, nil
+	}
+
+	if h.CanonicalizeFallbackLocal == TriBoolNo {
+		return name, false, fmt.Errorf("could not resolve host %s", name)
+	}
+
+	return name, false, nil
+}
+
+// permittedCNAME returns the CNAME target of fqdn if following it is
+// permitted by CanonicalizePermittedCNAMEs, fqdn otherwise.
+func (h *SSHHost) permittedCNAME(ctx context.Context, fqdn string, r HostResolver) string {
+	if len(h.CanonicalizePermittedCNAMEs) == 0 {
+		return fqdn
+	}
+
+	cname, err := r.LookupCNAME(ctx, fqdn)
+	if err != nil {
+		return fqdn
+	}
+	cname = strings.TrimSuffix(cname, ".")
+	if cname == "" || cname == fqdn {
+		return fqdn
+	}
+
+	for _, rule := range h.CanonicalizePermittedCNAMEs {
+		source, target, ok := strings.Cut(rule, ":")
+		if ok && matchPatternList(source, fqdn) && matchPatternList(target, cname) {
+			return cname
+		}
+	}
+	return fqdn
+}
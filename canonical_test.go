@@ -0,0 +1,138 @@
+package sshconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// mapHostResolver resolves the names it holds to their CNAME
+type mapHostResolver map[string]string
+
+func (r mapHostResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if _, ok := r[host]; !ok {
+		return nil, errors.New("no such host")
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func (r mapHostResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	cname, ok := r[host]
+	if !ok {
+		return "", errors.New("no such host")
+	}
+	return cname, nil
+}
+
+func TestLookupCanonicalization(t *testing.T) {
+	config := `Host web
+  User deploy
+
+Host *.example.com
+  Port 2222
+
+Match canonical host *.example.com
+  IdentityFile ~/.ssh/example
+
+Host *
+  CanonicalizeHostname yes
+  CanonicalDomains dev.example.com example.com
+  CanonicalizePermittedCNAMEs *.example.com:*.cdn.example.net`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	resolver := mapHostResolver{
+		"web.example.com": "web.example.com.",
+		"db.example.com":  "db.cdn.example.net.",
+	}
+
+	h := Lookup(hosts, "web", WithCanonicalization(resolver))
+	if h.HostName != "web.example.com" || h.Port != 2222 || h.User != "deploy" {
+		t.Errorf("unexpected host: %+v", h)
+	}
+	if len(h.IdentityFiles) != 1 {
+		t.Errorf("expected Match canonical to apply, got %v", h.IdentityFiles)
+	}
+
+	h = Lookup(hosts, "db", WithCanonicalization(resolver))
+	if h.HostName != "db.cdn.example.net" {
+		t.Errorf("expected permitted CNAME to be followed, got %s", h.HostName)
+	}
+
+	h = Lookup(hosts, "web")
+	if h.HostName != "web" || h.Port != 22 || len(h.IdentityFiles) != 0 {
+		t.Errorf("unexpected host without canonicalization: %+v", h)
+	}
+
+	h = Lookup(hosts, "other", WithCanonicalization(resolver))
+	if h.HostName != "other" {
+		t.Errorf("expected fallback to unresolved name, got %s", h.HostName)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	resolver := mapHostResolver{"web.example.com": "web.example.com"}
+	h := &SSHHost{
+		CanonicalizeHostname:      "yes",
+		CanonicalDomains:          []string{"example.com"},
+		CanonicalizeFallbackLocal: "no",
+	}
+
+	for _, tc := range []struct {
+		name      string
+		canonical string
+		ok        bool
+		err       bool
+	}{
+		{"web", "web.example.com", true, false},
+		{"web.example.com.", "web.example.com", true, false},
+		{"a.b.c", "a.b.c", false, false},
+		{"10.0.0.1", "10.0.0.1", false, false},
+		{"other", "other", false, true},
+	} {
+		name, ok, err := h.Canonicalize(context.Background(), tc.name, resolver)
+		if name != tc.canonical || ok != tc.ok || (err != nil) != tc.err {
+			t.Errorf("Canonicalize(%s): expected (%s, %t, error %t), got (%s, %t, %v)", tc.name, tc.canonical, tc.ok, tc.err, name, ok, err)
+		}
+	}
+
+	h.ProxyJump = "bastion"
+	if _, ok, _ := h.Canonicalize(context.Background(), "web", resolver); ok {
+		t.Error("expected proxied host not to be canonicalized")
+	}
+}
+
+func TestCanonicalizeMaxDots(t *testing.T) {
+	config := `Host *
+  CanonicalizeHostname yes
+  CanonicalDomains example.com
+  CanonicalizeMaxDots 0`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	resolver := mapHostResolver{
+		"web.example.com":     "web.example.com",
+		"web.dev.example.com": "web.dev.example.com",
+	}
+
+	h := Lookup(hosts, "web")
+	if v, ok := h.Get("CanonicalizeMaxDots"); !ok || v != "0" {
+		t.Errorf("expected CanonicalizeMaxDots 0 to be set, got %q", v)
+	}
+	if name, ok, _ := h.Canonicalize(context.Background(), "web", resolver); !ok || name != "web.example.com" {
+		t.Errorf("expected web to be canonicalized, got (%s, %t)", name, ok)
+	}
+	if _, ok, _ := h.Canonicalize(context.Background(), "web.dev", resolver); ok {
+		t.Error("expected web.dev not to be canonicalized with CanonicalizeMaxDots 0")
+	}
+
+	h.CanonicalizeMaxDotsSet = false
+	if _, ok, _ := h.Canonicalize(context.Background(), "web.dev", resolver); !ok {
+		t.Error("expected web.dev to be canonicalized with the default CanonicalizeMaxDots")
+	}
+}
@@ -0,0 +1,115 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ClientConfig returns the x/crypto/ssh client config for host together with
+// the address to dial. Host is expected to be a resolved host as returned by
+// Lookup.
+//
+// User, Ciphers, MACs, KexAlgorithms and HostKeyAlgorithms are applied, the
+// latter only if it doesn't modify the default list. Every identity file is
+// loaded for public key authentication unless it is disabled by
+// PubkeyAuthentication or left out of PreferredAuthentications. Like ssh,
+// identity files which don't exist are skipped, as are keys protected by a
+// passphrase. Without a User the local user name is used.
+func ClientConfig(host *SSHHost, hostKeyCallback ssh.HostKeyCallback) (*ssh.ClientConfig, string, error) {
+	config := &ssh.ClientConfig{
+		User:            host.User,
+		HostKeyCallback: hostKeyCallback,
+	}
+
+	if config.User == "" {
+		config.User = localUser()
+	}
+
+	// lists relative to the defaults are applied to the ones of x/crypto,
+	// which doesn't export its default host key algorithms
+	var defaults ssh.Config
+	defaults.SetDefaults()
+	if len(host.Ciphers) > 0 {
+		config.Ciphers = EffectiveAlgorithms(host.Ciphers, defaults.Ciphers)
+	}
+	if len(host.MACs) > 0 {
+		config.MACs = EffectiveAlgorithms(host.MACs, defaults.MACs)
+	}
+	if len(host.KexAlgorithms) > 0 {
+		config.KeyExchanges = EffectiveAlgorithms(host.KexAlgorithms, defaults.KeyExchanges)
+	}
+	if modifier, _ := ParseAlgorithms(host.HostKeyAlgorithms); modifier == AlgorithmsReplace {
+		config.HostKeyAlgorithms = host.HostKeyAlgorithms
+	}
+
+	if pubkeyAllowed(host) {
+		signers, err := identitySigners(host)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(signers) > 0 {
+			config.Auth = []ssh.AuthMethod{ssh.PublicKeys(signers...)}
+		}
+	}
+
+	port := host.Port
+	if port == 0 {
+		port = 22
+	}
+
+	return config, net.JoinHostPort(remoteHost(host), strconv.Itoa(port)), nil
+}
+
+// pubkeyAllowed reports whether public key authentication may be used.
+func pubkeyAllowed(host *SSHHost) bool {
+	if strings.EqualFold(host.PubkeyAuthentication, "no") {
+		return false
+	}
+	if len(host.PreferredAuthentications) == 0 {
+		return true
+	}
+	for _, method := range host.PreferredAuthentications {
+		if method == "publickey" {
+			return true
+		}
+	}
+	return false
+}
+
+// identitySigners loads the identity files of host.
+func identitySigners(host *SSHHost) ([]ssh.Signer, error) {
+	var signers []ssh.Signer
+	for _, f := range host.IdentityFiles {
+		path, err := host.ExpandPath(f)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		signer, err := ssh.ParsePrivateKey(key)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity file %s: %w", path, err)
+		}
+
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
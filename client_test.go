@@ -0,0 +1,88 @@
+package sshconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestClientConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("unable to marshal key: %s", err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "id_ed25519"), pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	config := `Host web
+  HostName web.example.com
+  User deploy
+  Port 2222
+  IdentityFile ` + dir + `/missing
+  IdentityFile ` + dir + `/id_ed25519
+  Ciphers aes256-ctr,aes128-ctr
+  MACs hmac-sha2-256
+  HostKeyAlgorithms ssh-ed25519,rsa-sha2-512`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "web")
+	c, addr, err := ClientConfig(h, ssh.InsecureIgnoreHostKey())
+	if err != nil {
+		t.Fatalf("unable to build client config: %s", err)
+	}
+
+	if addr != "web.example.com:2222" {
+		t.Errorf("unexpected address %s", addr)
+	}
+	if c.User != "deploy" {
+		t.Errorf("unexpected user %s", c.User)
+	}
+	if !reflect.DeepEqual([]string{"aes256-ctr", "aes128-ctr"}, c.Ciphers) || !reflect.DeepEqual([]string{"hmac-sha2-256"}, c.MACs) {
+		t.Errorf("unexpected algorithms: %v, %v", c.Ciphers, c.MACs)
+	}
+	if !reflect.DeepEqual([]string{"ssh-ed25519", "rsa-sha2-512"}, c.HostKeyAlgorithms) {
+		t.Errorf("unexpected host key algorithms: %v", c.HostKeyAlgorithms)
+	}
+	if len(c.Auth) != 1 {
+		t.Errorf("expected a public key auth method, got %d methods", len(c.Auth))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "id_ed25519"), []byte("garbage"), 0600); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if _, _, err := ClientConfig(h, ssh.InsecureIgnoreHostKey()); err == nil {
+		t.Error("expected error for invalid identity file")
+	}
+}
+
+func TestPubkeyAllowed(t *testing.T) {
+	for _, h := range []*SSHHost{
+		{PubkeyAuthentication: "no"},
+		{PreferredAuthentications: []string{"password"}},
+	} {
+		if pubkeyAllowed(h) {
+			t.Errorf("expected public key authentication to be disabled for %+v", h)
+		}
+	}
+
+	if !pubkeyAllowed(&SSHHost{PreferredAuthentications: []string{"password", "publickey"}}) {
+		t.Error("expected public key authentication to be allowed")
+	}
+}
@@ -0,0 +1,117 @@
+package sshconfig
+
+import (
+	"reflect"
+	"strings"
+)
+
+// locationFields are the fields of SSHHost telling where a block was read
+// from and how it is described rather than what it configures.
+var locationFields = map[string]bool{
+	"Comments":   true,
+	"Directives": true,
+	"PortSet":    true,
+	"Section":    true,
+	"SourceFile": true,
+	"SourceLine": true,
+	"Tags":       true,
+}
+
+// Clone returns a deep copy of the host, sharing no slices, maps or
+// pointers with it.
+func (h *SSHHost) Clone() *SSHHost {
+	if h == nil {
+		return nil
+	}
+
+	c := *h
+	cv := reflect.ValueOf(&c).Elem()
+	for i := 0; i < cv.NumField(); i++ {
+		if !cv.Type().Field(i).IsExported() {
+			continue
+		}
+		cv.Field(i).Set(deepCopy(cv.Field(i)))
+	}
+	return &c
+}
+
+// deepCopy returns a copy of v not sharing memory with it. Elements of
+// slices and maps are copied the same way.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	}
+	return v
+}
+
+// Equal reports whether h and other configure the same values. Where the
+// blocks were read from, their Directives, PortSet, Section, SourceFile
+// and SourceLine, and their Comments and Tags are ignored, as are the case
+// of unknown keywords and the difference between empty and unset lists.
+func (h *SSHHost) Equal(other *SSHHost) bool {
+	if h == nil || other == nil {
+		return h == other
+	}
+
+	hv := reflect.ValueOf(h).Elem()
+	ov := reflect.ValueOf(other).Elem()
+	for i := 0; i < hv.NumField(); i++ {
+		field := hv.Type().Field(i)
+		if !field.IsExported() || locationFields[field.Name] {
+			continue
+		}
+
+		if field.Name == "Unknowns" {
+			if !reflect.DeepEqual(lowerKeys(h.Unknowns), lowerKeys(other.Unknowns)) {
+				return false
+			}
+			continue
+		}
+
+		a, b := hv.Field(i), ov.Field(i)
+		if (a.Kind() == reflect.Slice || a.Kind() == reflect.Map) && a.Len() == 0 && b.Len() == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// lowerKeys returns m with lowercased keys, or nil if it is empty.
+func lowerKeys(m map[string][]string) map[string][]string {
+	if len(m) == 0 {
+		return nil
+	}
+	lower := make(map[string][]string, len(m))
+	for k, v := range m {
+		lower[strings.ToLower(k)] = append(lower[strings.ToLower(k)], v...)
+	}
+	return lower
+}
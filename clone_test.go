@@ -0,0 +1,69 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  IdentityFile ~/.ssh/id_ed25519
+  LocalForward 8080 localhost:80
+  SetEnv FOO=bar
+  RekeyLimit 1G 1h
+  UseKeychain yes
+
+Match host *.internal
+  User admin
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	for _, h := range hosts {
+		c := h.Clone()
+		if !c.Equal(h) {
+			t.Errorf("clone differs: %+v", c)
+		}
+		compare(t, []*SSHHost{h}, []*SSHHost{c})
+	}
+
+	c := hosts[0].Clone()
+	c.Host[0] = "db"
+	c.IdentityFiles[0] = "~/.ssh/other"
+	c.LocalForwards[0].InPort = 9090
+	c.SetEnv["FOO"] = "baz"
+	c.RekeyLimit.Bytes = 0
+	c.Unknowns["UseKeychain"][0] = "no"
+	c.Directives[0].Value = "changed"
+
+	h := hosts[0]
+	if h.Host[0] != "web" || h.IdentityFiles[0] != "~/.ssh/id_ed25519" || h.LocalForwards[0].InPort != 8080 ||
+		h.SetEnv["FOO"] != "bar" || h.RekeyLimit.Bytes == 0 || h.Unknowns["UseKeychain"][0] != "yes" || h.Directives[0].Value == "changed" {
+		t.Errorf("clone shares memory with the original: %+v", h)
+	}
+
+	if (*SSHHost)(nil).Clone() != nil {
+		t.Errorf("expected nil clone of nil host")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := &SSHHost{Host: []string{"web"}, Port: 22, Unknowns: map[string][]string{"UseKeychain": {"yes"}}, SourceLine: 1}
+	b := &SSHHost{Host: []string{"web"}, Port: 22, Unknowns: map[string][]string{"usekeychain": {"yes"}}, SourceLine: 7, Ciphers: []string{}}
+
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Errorf("expected hosts to be equal")
+	}
+
+	b.LocalForwards = []Forward{{InPort: 8080, OutHost: "localhost", OutPort: 80}}
+	if a.Equal(b) {
+		t.Errorf("expected hosts with different forwards to differ")
+	}
+
+	if a.Equal(nil) || !(*SSHHost)(nil).Equal(nil) {
+		t.Errorf("unexpected result comparing with nil")
+	}
+}
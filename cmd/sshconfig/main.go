@@ -0,0 +1,227 @@
+// Command sshconfig queries, lints and formats OpenSSH client configs.
+//
+// Usage:
+//
+//	sshconfig get [-F file] [-json] <alias>
+//	sshconfig lint [-F file]
+//	sshconfig fmt [-w] [file ...]
+//
+// Without -F the user and system configs are read like ssh does. get prints
+// the effective configuration of alias, lint prints problems found in the
+// config and exits with status 1 if any is a warning or error and fmt
+// formats the given files, or stdin, to stdout or with -w in place.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikkeloscar/sshconfig"
+)
+
+const usage = `usage:
+  sshconfig get [-F file] [-json] <alias>
+  sshconfig lint [-F file]
+  sshconfig fmt [-w] [file ...]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "get":
+		err = get(args[1:], stdout, stderr)
+	case "lint":
+		var failed bool
+		failed, err = lint(args[1:], stdout, stderr)
+		if err == nil && failed {
+			return 1
+		}
+	case "fmt":
+		err = format(args[1:], stdin, stdout, stderr)
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var usageErr usageError
+	switch {
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "sshconfig %s: %s\n%s", args[0], err, usage)
+		return 2
+	case errors.Is(err, flag.ErrHelp):
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "sshconfig %s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// usageError is returned for invalid arguments.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// parseFlags parses the flags of fs in args, which may also follow the
+// positional arguments like in `get web -json`, and returns the latter.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// load parses the config given by -F or the default configs.
+func load(file string) ([]*sshconfig.SSHHost, error) {
+	if file != "" {
+		return sshconfig.Parse(file)
+	}
+	return sshconfig.ParseDefault()
+}
+
+func get(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("F", "", "config `file` to read instead of the default configs")
+	asJSON := fs.Bool("json", false, "print the configuration as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return usageError("expected a single alias")
+	}
+
+	hosts, err := load(*file)
+	if err != nil {
+		return err
+	}
+	host := sshconfig.Lookup(hosts, args[0], sshconfig.WithOpenSSHPrecedence())
+
+	var out []byte
+	if *asJSON {
+		out, err = json.MarshalIndent(host, "", "  ")
+		out = append(out, '\n')
+	} else {
+		out, err = host.MarshalText()
+	}
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(out)
+	return err
+}
+
+// lint prints the findings of Lint and reports whether any of them is a
+// warning or an error.
+func lint(args []string, stdout, stderr io.Writer) (bool, error) {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("F", "", "config `file` to read instead of the default configs")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return false, err
+	}
+	if len(args) != 0 {
+		return false, usageError("unexpected arguments")
+	}
+
+	hosts, err := load(*file)
+	if err != nil {
+		return false, err
+	}
+
+	failed := false
+	for _, f := range sshconfig.Lint(hosts) {
+		fmt.Fprintln(stdout, f)
+		if f.Severity >= sshconfig.SeverityWarning {
+			failed = true
+		}
+	}
+	return failed, nil
+}
+
+func format(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	write := fs.Bool("w", false, "write the result to the files instead of stdout")
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		if *write {
+			return usageError("-w requires files")
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		out, err := formatSource("<stdin>", src)
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(out)
+		return err
+	}
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out, err := formatSource(file, src)
+		if err != nil {
+			return err
+		}
+		if !*write {
+			if _, err := stdout.Write(out); err != nil {
+				return err
+			}
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, out, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatSource formats src read from name, which is given in errors.
+func formatSource(name string, src []byte) ([]byte, error) {
+	out, err := sshconfig.Format(src)
+	var parseErr *sshconfig.ParseError
+	if errors.As(err, &parseErr) {
+		parseErr.File = name
+	}
+	return out, err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGet(t *testing.T) {
+	config := writeConfig(t, `Host web
+  HostName web.example.com
+
+Host *
+  User deploy
+`)
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"get", "-F", config, "web"}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("expected status 0, got %d: %s", status, stderr.String())
+	}
+	expected := "Host web\n  HostName web.example.com\n  User deploy\n"
+	if stdout.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	if status := run([]string{"get", "web", "-F", config, "--json"}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("expected status 0, got %d: %s", status, stderr.String())
+	}
+	var host struct {
+		HostName string `json:"hostName"`
+		User     string `json:"user"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &host); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+	if host.HostName != "web.example.com" || host.User != "deploy" {
+		t.Errorf("unexpected host: %+v", host)
+	}
+
+	if status := run([]string{"get", "-F", config}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("expected status 2 without alias, got %d", status)
+	}
+}
+
+func TestLint(t *testing.T) {
+	config := writeConfig(t, `Host web
+  HostName web.example.com
+  HostName other.example.com
+`)
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"lint", "-F", config}, nil, &stdout, &stderr); status != 1 {
+		t.Errorf("expected status 1, got %d: %s", status, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), config+":3: warning: HostName has no effect") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestFmt(t *testing.T) {
+	config := writeConfig(t, "host web\nhostname   web.example.com\n")
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"fmt"}, strings.NewReader("host db\nuser postgres\n"), &stdout, &stderr); status != 0 {
+		t.Fatalf("expected status 0, got %d: %s", status, stderr.String())
+	}
+	if stdout.String() != "Host db\n  User postgres\n" {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+
+	if status := run([]string{"fmt", "-w", config}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("expected status 0, got %d: %s", status, stderr.String())
+	}
+	formatted, err := os.ReadFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != "Host web\n  HostName web.example.com\n" {
+		t.Errorf("unexpected file content: %q", formatted)
+	}
+
+	stderr.Reset()
+	if status := run([]string{"fmt"}, strings.NewReader("Host\n"), &stdout, &stderr); status != 1 {
+		t.Errorf("expected status 1, got %d", status)
+	}
+	if !strings.HasPrefix(stderr.String(), "sshconfig fmt: <stdin>:1:") {
+		t.Errorf("unexpected error: %s", stderr.String())
+	}
+}
@@ -0,0 +1,106 @@
+package sshconfig
+
+import (
+	"iter"
+	"slices"
+)
+
+// Config is a parsed SSH config
+type Config struct {
+	hosts []*SSHHost
+	// files are the files read by Load
+	files []string
+}
+
+// NewConfig returns a Config for the parsed hosts.
+func NewConfig(hosts []*SSHHost) *Config {
+	return &Config{hosts: hosts}
+}
+
+// Load parses the SSH config given by path into a Config. With WithLenient
+// the Config of every host that could be parsed is returned together with
+// the error, like Parse does.
+func Load(path string, opts ...Option) (*Config, error) {
+	record := &parseRecord{}
+	hosts, err := Parse(path, append(opts[:len(opts):len(opts)], recording(record))...)
+	if err != nil && !newOptions(opts).lenient {
+		return nil, err
+	}
+	c := NewConfig(hosts)
+	c.files = compactFiles(record.files)
+	return c, err
+}
+
+// Files returns every file of the config: the config itself followed by the
+// files it includes, in the order they are read, without duplicates. Files
+// included within an included file follow that file. For a Config returned
+// by NewConfig the files the hosts were read from are returned, which misses
+// files without Host or Match blocks.
+func (c *Config) Files() []string {
+	if c.files != nil {
+		return slices.Clone(c.files)
+	}
+	files := make([]string, 0, len(c.hosts))
+	for _, h := range c.hosts {
+		if h.SourceFile != "" {
+			files = append(files, h.SourceFile)
+		}
+	}
+	return compactFiles(files)
+}
+
+// compactFiles returns files without duplicates, keeping the first one.
+func compactFiles(files []string) []string {
+	seen := map[string]bool{}
+	compact := make([]string, 0, len(files))
+	for _, f := range files {
+		if !seen[f] {
+			seen[f] = true
+			compact = append(compact, f)
+		}
+	}
+	return compact
+}
+
+// Hosts returns all Host and Match blocks of the config in file order.
+func (c *Config) Hosts() []*SSHHost {
+	return c.hosts
+}
+
+// All returns an iterator over all Host and Match blocks in file order.
+func (c *Config) All() iter.Seq[*SSHHost] {
+	return slices.Values(c.hosts)
+}
+
+// Lookup returns the effective configuration for alias, see Lookup.
+func (c *Config) Lookup(alias string, opts ...LookupOption) *SSHHost {
+	return Lookup(c.hosts, alias, opts...)
+}
+
+// GlobalOptions returns the directives found before the first Host or Match,
+// see GlobalOptions.
+func (c *Config) GlobalOptions() *SSHHost {
+	return GlobalOptions(c.hosts)
+}
+
+// Filter returns the blocks for which pred returns true, see Filter.
+func (c *Config) Filter(pred func(*SSHHost) bool) []*SSHHost {
+	return Filter(c.hosts, pred)
+}
+
+// Aliases returns every alias named in a Host line, without patterns and
+// duplicates, in file order.
+func (c *Config) Aliases() []string {
+	var aliases []string
+	seen := map[string]bool{}
+	for _, h := range c.hosts {
+		for _, alias := range h.Host {
+			if isPattern(alias) || seen[alias] {
+				continue
+			}
+			seen[alias] = true
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
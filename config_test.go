@@ -0,0 +1,167 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+	config := `Host google google2
+  HostName google.se
+  User goog
+
+Host face google
+  HostName facebook.com
+  Port 2222
+
+Host *.internal !db.internal
+  User admin`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	c := NewConfig(hosts)
+
+	if len(c.Hosts()) != 3 {
+		t.Errorf("expected 3 hosts, got %d", len(c.Hosts()))
+	}
+
+	expected := []string{"google", "google2", "face"}
+	if aliases := c.Aliases(); !reflect.DeepEqual(expected, aliases) {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+
+	h := c.Lookup("google")
+	if h.HostName != "google.se" || h.User != "goog" || h.Port != 2222 {
+		t.Errorf("unexpected host: %+v", h)
+	}
+
+	filtered := c.Filter(func(h *SSHHost) bool { return h.Port == 2222 })
+	if len(filtered) != 1 || filtered[0].HostName != "facebook.com" {
+		t.Errorf("unexpected filtered hosts: %+v", filtered)
+	}
+}
+
+func TestConfigAll(t *testing.T) {
+	hosts, err := parse("Host a\nHost b\nHost c\n", "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	var aliases []string
+	for h := range NewConfig(hosts).All() {
+		aliases = append(aliases, h.Host...)
+	}
+	if !reflect.DeepEqual([]string{"a", "b", "c"}, aliases) {
+		t.Errorf("unexpected hosts: %v", aliases)
+	}
+}
+
+func TestConfigGlobalOptions(t *testing.T) {
+	resolver := mapResolver{
+		"/team/ssh/defaults.conf": "ServerAliveInterval 10\nVisualHostKey yes\n",
+	}
+
+	config := `Include defaults.conf
+ServerAliveInterval 30
+ServerAliveCountMax 3
+
+Host web
+  User deploy`
+
+	hosts, err := ParseString(config, "/team/ssh/config", WithIncludeResolver(resolver))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	c := NewConfig(hosts)
+
+	global := c.GlobalOptions()
+	if global == nil {
+		t.Fatal("expected global options")
+	}
+	// the included file comes first, so its value wins
+	if global.ServerAliveInterval != 10*time.Second || global.ServerAliveCountMax != 3 || global.User != "" {
+		t.Errorf("unexpected global options: %+v", global)
+	}
+	if v, _ := global.Get("VisualHostKey"); v != "yes" {
+		t.Errorf("unexpected VisualHostKey: %s", v)
+	}
+	if !global.IsGlobal() || global.SourceFile != "/team/ssh/defaults.conf" {
+		t.Errorf("unexpected global block from %s", global.SourceFile)
+	}
+
+	if NewConfig(hosts[len(hosts)-1:]).GlobalOptions() != nil {
+		t.Errorf("expected no global options without top level directives")
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config":         "Include conf.d/*\nHost web\n  Include extra\n",
+		"conf.d/a":       "Include nested\n",
+		"conf.d/b":       "Host b\n",
+		"conf.d/nested":  "Host nested\n",
+		"extra":          "User deploy\n",
+		"conf.d/ignored": "",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unable to create dir: %s", err.Error())
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write file: %s", err.Error())
+		}
+	}
+
+	expected := []string{
+		filepath.Join(dir, "config"),
+		filepath.Join(dir, "conf.d/a"),
+		filepath.Join(dir, "conf.d/nested"),
+		filepath.Join(dir, "conf.d/b"),
+		filepath.Join(dir, "conf.d/ignored"),
+		filepath.Join(dir, "extra"),
+	}
+	for _, opts := range [][]Option{nil, {WithIncludeConcurrency(4)}} {
+		c, err := Load(filepath.Join(dir, "config"), opts...)
+		if err != nil {
+			t.Fatalf("unable to load config: %s", err)
+		}
+		if files := c.Files(); !slices.Equal(files, expected) {
+			t.Errorf("expected %v, got %v", expected, files)
+		}
+	}
+
+	hosts, err := Parse(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err)
+	}
+	expected = []string{filepath.Join(dir, "conf.d/nested"), filepath.Join(dir, "conf.d/b"), filepath.Join(dir, "config")}
+	if files := NewConfig(hosts).Files(); !slices.Equal(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
+
+func TestLoadLenient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("Host web\n  User deploy\n\nHost db\n  Port abc\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	if c, err := Load(path); err == nil || c != nil {
+		t.Errorf("expected only an error, got %v, %v", c, err)
+	}
+
+	c, err := Load(path, WithLenient())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if c == nil || c.Lookup("web").User != "deploy" {
+		t.Errorf("expected the parsed hosts together with the error, got %v", c)
+	}
+}
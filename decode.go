@@ -0,0 +1,114 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decode stores the values of host in the struct pointed to by v. Fields
+// are mapped to keywords by a `sshconfig:"Keyword"` tag, untagged fields are
+// left alone. Any keyword known to the parser as well as unknown keywords
+// recorded in Unknowns may be used, fields of unset keywords are not
+// changed.
+//
+// A field of the same type as the corresponding SSHHost field, like
+// []Forward for LocalForward, gets its value. Otherwise the value is decoded
+// from the form written in config files into fields of string, integer or
+// bool kind, a yes/no value for the latter, or into a string slice getting
+// every value of the keyword.
+func Decode(host *SSHHost, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("sshconfig: Decode expects a non-nil pointer to a struct")
+	}
+
+	rv = rv.Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		keyword, ok := field.Tag.Lookup("sshconfig")
+		if !ok || keyword == "" || keyword == "-" || !field.IsExported() {
+			continue
+		}
+
+		if err := decodeField(host, keyword, rv.Field(i)); err != nil {
+			return fmt.Errorf("sshconfig: %s: %w", keyword, err)
+		}
+	}
+	return nil
+}
+
+// decodeField stores the value of keyword in dst.
+func decodeField(host *SSHHost, keyword string, dst reflect.Value) error {
+	if src, ok := hostField(host, keyword, dst.Type()); ok {
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+		return nil
+	}
+
+	values := host.GetAll(keyword)
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(values[0])
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(values[0], 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(values[0], 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(n)
+	case reflect.Bool:
+		switch strings.ToLower(values[0]) {
+		case "yes", "true":
+			dst.SetBool(true)
+		case "no", "false":
+			dst.SetBool(false)
+		default:
+			return fmt.Errorf("invalid yes/no value: %#v", values[0])
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can not decode into %s", dst.Type())
+		}
+		s := reflect.MakeSlice(dst.Type(), len(values), len(values))
+		for i, value := range values {
+			s.Index(i).SetString(value)
+		}
+		dst.Set(s)
+	default:
+		return fmt.Errorf("can not decode into %s", dst.Type())
+	}
+	return nil
+}
+
+// hostField returns the field of host holding the value of keyword if its
+// type is typ. Fields of keywords which may be given multiple times are
+// named in plural, like IdentityFiles.
+func hostField(host *SSHHost, keyword string, typ reflect.Type) (reflect.Value, bool) {
+	if _, ok := variables[strings.ToLower(keyword)]; !ok {
+		return reflect.Value{}, false
+	}
+
+	hv := reflect.ValueOf(host).Elem()
+	for _, name := range []string{keyword + "s", keyword} {
+		f, ok := hv.Type().FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		})
+		if ok && f.Type == typ {
+			return hv.FieldByIndex(f.Index), true
+		}
+	}
+	return reflect.Value{}, false
+}
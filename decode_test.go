@@ -0,0 +1,83 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  Port 2222
+  IdentityFile ~/.ssh/a
+  IdentityFile ~/.ssh/b
+  LocalForward 8080 localhost:80
+  ForwardAgent yes
+  RequestTTY force
+  Ciphers aes256-gcm@openssh.com,chacha20-poly1305@openssh.com
+  UseKeychain yes
+  XAuthLocation /opt/X11/bin/xauth`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	var target struct {
+		Address       string     `sshconfig:"HostName"`
+		Port          uint16     `sshconfig:"Port"`
+		Keys          []string   `sshconfig:"IdentityFile"`
+		Forwards      []Forward  `sshconfig:"LocalForward"`
+		Agent         bool       `sshconfig:"ForwardAgent"`
+		TTY           RequestTTY `sshconfig:"RequestTTY"`
+		TTYString     string     `sshconfig:"RequestTTY"`
+		Ciphers       []string   `sshconfig:"Ciphers"`
+		Keychain      bool       `sshconfig:"UseKeychain"`
+		XAuth         string     `sshconfig:"xauthlocation"`
+		User          string     `sshconfig:"User"`
+		Untagged      string
+		DefaultedUser string `sshconfig:"User"`
+	}
+	target.Untagged = "untouched"
+	target.DefaultedUser = "nobody"
+
+	if err := Decode(hosts[0], &target); err != nil {
+		t.Fatalf("unable to decode host: %s", err.Error())
+	}
+
+	if target.Address != "web.example.com" || target.Port != 2222 {
+		t.Errorf("unexpected address: %s:%d", target.Address, target.Port)
+	}
+	if !reflect.DeepEqual(target.Keys, []string{"~/.ssh/a", "~/.ssh/b"}) {
+		t.Errorf("unexpected keys: %v", target.Keys)
+	}
+	if !reflect.DeepEqual(target.Forwards, hosts[0].LocalForwards) {
+		t.Errorf("unexpected forwards: %v", target.Forwards)
+	}
+	if !target.Agent || !target.Keychain {
+		t.Errorf("expected yes values to decode as true")
+	}
+	if target.TTY != RequestTTYForce || target.TTYString != "force" {
+		t.Errorf("unexpected RequestTTY: %s %s", target.TTY, target.TTYString)
+	}
+	if !reflect.DeepEqual(target.Ciphers, hosts[0].Ciphers) {
+		t.Errorf("unexpected ciphers: %v", target.Ciphers)
+	}
+	if target.XAuth != "/opt/X11/bin/xauth" {
+		t.Errorf("unexpected XAuthLocation: %s", target.XAuth)
+	}
+	if target.User != "" || target.Untagged != "untouched" || target.DefaultedUser != "nobody" {
+		t.Errorf("expected unset and untagged fields to be left alone")
+	}
+
+	var invalid struct {
+		Port bool `sshconfig:"Port"`
+	}
+	if err := Decode(hosts[0], &invalid); err == nil || err.Error() != `sshconfig: Port: invalid yes/no value: "2222"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := Decode(hosts[0], target); err == nil {
+		t.Errorf("expected error for non-pointer")
+	}
+}
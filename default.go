@@ -0,0 +1,115 @@
+package sshconfig
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+var (
+	userConfig   = "~/.ssh/config"
+	systemDir    = systemConfigDir(runtime.GOOS, os.Getenv)
+	systemConfig = systemDir + pathSeparator(runtime.GOOS) + "ssh_config"
+)
+
+// systemConfigDir returns the directory of the system config on goos:
+// /etc/ssh, or %ProgramData%\ssh for the Windows port of OpenSSH.
+func systemConfigDir(goos string, getenv func(string) string) string {
+	if goos != "windows" {
+		return "/etc/ssh"
+	}
+	dir := getenv("ProgramData")
+	if dir == "" {
+		dir = `C:\ProgramData`
+	}
+	return dir + `\ssh`
+}
+
+// homeDir returns the home directory of the user, falling back to
+// os.UserHomeDir, which uses %USERPROFILE% on Windows, if HOME is not set.
+func homeDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil || home == "" {
+		return os.UserHomeDir()
+	}
+	return home, nil
+}
+
+// expandHome expands a leading ~/ or ~\ of path to the home directory.
+func expandHome(path string) (string, error) {
+	if path == "" || path[0] != '~' {
+		return path, nil
+	}
+	if len(path) > 1 && path[1] != '/' && path[1] != '\\' {
+		return "", errors.New("cannot expand user-specific home dir")
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// pathSeparator returns the path separator of goos.
+func pathSeparator(goos string) string {
+	if goos == "windows" {
+		return `\`
+	}
+	return "/"
+}
+
+// UserConfigPath returns the location of the user config read by ssh,
+// ~/.ssh/config in the home directory of the user. The home directory is
+// $HOME, %USERPROFILE% on Windows and $home on plan9.
+func UserConfigPath() (string, error) {
+	return expandHome(userConfig)
+}
+
+// SystemConfigPath returns the location of the system config read by ssh,
+// /etc/ssh/ssh_config, or %ProgramData%\ssh\ssh_config on Windows.
+func SystemConfigPath() string {
+	return systemConfig
+}
+
+// ParseDefault parses the user config (~/.ssh/config) followed by the system
+// config (/etc/ssh/ssh_config) the same way ssh does, a missing file is
+// skipped. Relative Include paths are resolved against ~/.ssh and /etc/ssh
+// respectively and drop-in files in /etc/ssh/ssh_config.d are read through
+// the Include directive of the system config. On Windows the user config
+// is read from %USERPROFILE%\.ssh\config and the system config from
+// %ProgramData%\ssh\ssh_config.
+//
+// The user hosts come first in the result, so Lookup with
+// WithOpenSSHPrecedence resolves every keyword with the same precedence as
+// ssh.
+func ParseDefault(opts ...Option) ([]*SSHHost, error) {
+	userPath, err := UserConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	sshConfigs := []*SSHHost{}
+	for _, config := range []struct {
+		path string
+		mode IncludeMode
+	}{
+		{userPath, IncludeUser},
+		{SystemConfigPath(), IncludeSystem},
+	} {
+		hosts, err := Parse(config.path, append(opts[:len(opts):len(opts)], WithIncludeMode(config.mode))...)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		sshConfigs = append(sshConfigs, hosts...)
+	}
+
+	return sshConfigs, nil
+}
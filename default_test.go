@@ -0,0 +1,145 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestParseDefault(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	homedir.DisableCache = true
+	homeEnv := "HOME"
+	if runtime.GOOS == "plan9" {
+		// On plan9, env vars are lowercase.
+		homeEnv = "home"
+	}
+	t.Setenv(homeEnv, tmpdir)
+
+	if err := os.Mkdir(tmpdir+"/.ssh", 0700); err != nil {
+		t.Fatalf("unable to create dir: %s", err.Error())
+	}
+
+	userConfigData := `Host web
+  User deploy`
+	systemConfigData := `Host *
+  User nobody
+  Port 2222`
+
+	if err := os.WriteFile(tmpdir+"/.ssh/config", []byte(userConfigData), 0600); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if err := os.WriteFile(tmpdir+"/ssh_config", []byte(systemConfigData), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	defer func(path string) { systemConfig = path }(systemConfig)
+	systemConfig = tmpdir + "/ssh_config"
+
+	hosts, err := ParseDefault()
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "web", WithOpenSSHPrecedence())
+	if h.User != "deploy" || h.Port != 2222 {
+		t.Errorf("unexpected host: %+v", h)
+	}
+
+	systemConfig = tmpdir + "/missing"
+
+	hosts, err = ParseDefault()
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if len(hosts) != 1 {
+		t.Errorf("expected 1 host, got %d", len(hosts))
+	}
+}
+
+func TestSystemConfigDir(t *testing.T) {
+	for _, tc := range []struct {
+		goos        string
+		programData string
+		expected    string
+	}{
+		{"linux", `D:\Data`, "/etc/ssh"},
+		{"darwin", "", "/etc/ssh"},
+		{"windows", `D:\Data`, `D:\Data\ssh`},
+		{"windows", "", `C:\ProgramData\ssh`},
+	} {
+		getenv := func(key string) string {
+			if key == "ProgramData" {
+				return tc.programData
+			}
+			return ""
+		}
+		if dir := systemConfigDir(tc.goos, getenv); dir != tc.expected {
+			t.Errorf("systemConfigDir(%s, %q): expected %q, got %q", tc.goos, tc.programData, tc.expected, dir)
+		}
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	homedir.DisableCache = true
+	homeEnv := "HOME"
+	if runtime.GOOS == "plan9" {
+		homeEnv = "home"
+	}
+	t.Setenv(homeEnv, tmpdir)
+
+	for _, tc := range []struct {
+		path     string
+		expected string
+	}{
+		{"~", tmpdir},
+		{"~/.ssh/config", filepath.Join(tmpdir, ".ssh", "config")},
+		{"/etc/ssh", "/etc/ssh"},
+	} {
+		expanded, err := expandHome(tc.path)
+		if err != nil {
+			t.Errorf("expandHome(%q): unexpected error: %s", tc.path, err)
+			continue
+		}
+		if expanded != tc.expected {
+			t.Errorf("expandHome(%q): expected %q, got %q", tc.path, tc.expected, expanded)
+		}
+	}
+
+	if _, err := expandHome("~other/.ssh"); err == nil {
+		t.Errorf("expandHome(~other/.ssh): expected error")
+	}
+}
+
+func TestConfigPaths(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	homedir.DisableCache = true
+	homeEnv := "HOME"
+	if runtime.GOOS == "plan9" {
+		homeEnv = "home"
+	}
+	t.Setenv(homeEnv, tmpdir)
+
+	path, err := UserConfigPath()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := filepath.Join(tmpdir, ".ssh", "config"); path != expected {
+		t.Errorf("expected user config %q, got %q", expected, path)
+	}
+
+	expected := "/etc/ssh/ssh_config"
+	if runtime.GOOS == "windows" {
+		expected = systemConfigDir(runtime.GOOS, os.Getenv) + `\ssh_config`
+	}
+	if path := SystemConfigPath(); path != expected {
+		t.Errorf("expected system config %q, got %q", expected, path)
+	}
+}
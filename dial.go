@@ -0,0 +1,219 @@
+package sshconfig
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Dial connects to alias as configured in hosts and returns the
+// authenticated client. The connection is made through the ProxyJump chain
+// of alias if any, see ResolveJumpChain, or else through its ProxyCommand.
+// ProxyCommand is run by the user's shell after expanding its tokens. Closing
+// the client closes the connections to all jump hosts as well.
+func Dial(hosts []*SSHHost, alias string, hostKeyCallback ssh.HostKeyCallback, opts ...LookupOption) (*ssh.Client, error) {
+	h := Lookup(hosts, alias, opts...)
+	config, addr, err := ClientConfig(h, hostKeyCallback)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialTransport(hosts, alias, h, addr, hostKeyCallback, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(conn, addr, config)
+}
+
+// DialConn returns the connection to the SSH server of alias, established
+// like Dial does but without starting the SSH protocol on it.
+func DialConn(hosts []*SSHHost, alias string, hostKeyCallback ssh.HostKeyCallback, opts ...LookupOption) (net.Conn, error) {
+	h := Lookup(hosts, alias, opts...)
+	_, addr, err := ClientConfig(h, hostKeyCallback)
+	if err != nil {
+		return nil, err
+	}
+
+	return dialTransport(hosts, alias, h, addr, hostKeyCallback, opts)
+}
+
+// Dial connects to alias, see Dial.
+func (c *Config) Dial(alias string, hostKeyCallback ssh.HostKeyCallback, opts ...LookupOption) (*ssh.Client, error) {
+	return Dial(c.hosts, alias, hostKeyCallback, opts...)
+}
+
+// dialTransport connects to addr, the address of the resolved host h, through
+// its jump chain or proxy command.
+func dialTransport(hosts []*SSHHost, alias string, h *SSHHost, addr string, hostKeyCallback ssh.HostKeyCallback, opts []LookupOption) (net.Conn, error) {
+	if h.ProxyJump == "" || strings.EqualFold(h.ProxyJump, "none") {
+		return dialDirect(h, addr)
+	}
+
+	chain, err := ResolveJumpChain(hosts, alias, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var jumps []*ssh.Client
+	closeJumps := func() {
+		for i := len(jumps) - 1; i >= 0; i-- {
+			jumps[i].Close()
+		}
+	}
+
+	for _, hop := range chain {
+		config, hopAddr, err := ClientConfig(hop, hostKeyCallback)
+		if err != nil {
+			closeJumps()
+			return nil, err
+		}
+
+		var conn net.Conn
+		if len(jumps) == 0 {
+			conn, err = dialDirect(hop, hopAddr)
+		} else {
+			conn, err = jumps[len(jumps)-1].Dial("tcp", hopAddr)
+		}
+		if err != nil {
+			closeJumps()
+			return nil, err
+		}
+
+		client, err := newClient(conn, hopAddr, config)
+		if err != nil {
+			closeJumps()
+			return nil, err
+		}
+		jumps = append(jumps, client)
+	}
+
+	conn, err := jumps[len(jumps)-1].Dial("tcp", addr)
+	if err != nil {
+		closeJumps()
+		return nil, err
+	}
+
+	return &jumpConn{Conn: conn, close: closeJumps}, nil
+}
+
+// dialDirect connects to addr using the proxy command of h if set.
+func dialDirect(h *SSHHost, addr string) (net.Conn, error) {
+	if h.ProxyCommand == "" || strings.EqualFold(h.ProxyCommand, "none") {
+		return net.Dial("tcp", addr)
+	}
+	return dialCommand(expandTokens(h.ProxyCommand, h))
+}
+
+// newClient starts an SSH client on conn. Conn is closed if it fails.
+func newClient(conn net.Conn, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// jumpConn is a connection made through jump hosts, which are closed
+// together with the connection.
+type jumpConn struct {
+	net.Conn
+	close func()
+}
+
+func (c *jumpConn) Close() error {
+	err := c.Conn.Close()
+	c.close()
+	return err
+}
+
+// dialCommand runs command in the user's shell and returns a connection to
+// its standard input and output, like ssh does for ProxyCommand.
+func dialCommand(command string) (net.Conn, error) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	cmd := exec.Command(shell, "-c", "exec "+command)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+// errDeadline is returned when setting deadlines on a commandConn
+var errDeadline = errors.New("deadlines are not supported for ProxyCommand connections")
+
+// commandConn is a connection to the standard input and output of a proxy
+// command.
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func (c *commandConn) Read(b []byte) (int, error) {
+	return c.stdout.Read(b)
+}
+
+func (c *commandConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+func (c *commandConn) Close() error {
+	err := c.stdin.Close()
+	c.cmd.Process.Kill()
+	c.cmd.Wait()
+	return err
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr(c.cmd.String())
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr(c.cmd.String())
+}
+
+func (c *commandConn) SetDeadline(t time.Time) error {
+	return errDeadline
+}
+
+func (c *commandConn) SetReadDeadline(t time.Time) error {
+	return errDeadline
+}
+
+func (c *commandConn) SetWriteDeadline(t time.Time) error {
+	return errDeadline
+}
+
+// commandAddr is the address of a commandConn
+type commandAddr string
+
+func (commandAddr) Network() string {
+	return "proxycommand"
+}
+
+func (a commandAddr) String() string {
+	return string(a)
+}
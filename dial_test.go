@@ -0,0 +1,175 @@
+package sshconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer starts an SSH server without authentication which only
+// supports direct-tcpip channels and returns its port and the number of
+// connections it accepted.
+func testSSHServer(t *testing.T) (int, *int32) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("unable to create signer: %s", err.Error())
+	}
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %s", err.Error())
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var accepted int32
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepted, 1)
+			go serveSSH(c, config)
+		}
+	}()
+
+	return l.Addr().(*net.TCPAddr).Port, &accepted
+}
+
+func serveSSH(c net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(c, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "direct-tcpip" {
+			nc.Reject(ssh.UnknownChannelType, "")
+			continue
+		}
+
+		var target struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(nc.ExtraData(), &target); err != nil {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		conn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(chReqs)
+		go func() {
+			io.Copy(ch, conn)
+			ch.Close()
+		}()
+		go func() {
+			io.Copy(conn, ch)
+			conn.Close()
+		}()
+	}
+}
+
+func TestDialProxyJump(t *testing.T) {
+	port, accepted := testSSHServer(t)
+
+	config := fmt.Sprintf(`Host target
+  HostName 127.0.0.1
+  Port %d
+  ProxyJump bastion
+
+Host bastion
+  HostName 127.0.0.1
+  Port %d`, port, port)
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	client, err := Dial(hosts, "target", ssh.InsecureIgnoreHostKey())
+	if err != nil {
+		t.Fatalf("unable to dial: %s", err)
+	}
+	defer client.Close()
+
+	// one connection for the bastion and one forwarded through it
+	if n := atomic.LoadInt32(accepted); n != 2 {
+		t.Errorf("expected 2 connections, got %d", n)
+	}
+}
+
+func TestDialProxyJumpNotInConfig(t *testing.T) {
+	port, accepted := testSSHServer(t)
+
+	config := fmt.Sprintf(`Host target
+  HostName 127.0.0.1
+  Port %d
+  ProxyJump jump@127.0.0.1:%d`, port, port)
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	client, err := Dial(hosts, "target", ssh.InsecureIgnoreHostKey())
+	if err != nil {
+		t.Fatalf("unable to dial: %s", err)
+	}
+	defer client.Close()
+
+	if n := atomic.LoadInt32(accepted); n != 2 {
+		t.Errorf("expected 2 connections, got %d", n)
+	}
+}
+
+func TestDialConnProxyCommand(t *testing.T) {
+	config := `Host web
+  ProxyCommand printf %h:%p`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	conn, err := DialConn(hosts, "web", ssh.InsecureIgnoreHostKey())
+	if err != nil {
+		t.Fatalf("unable to dial: %s", err)
+	}
+	defer conn.Close()
+
+	out, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("unable to read from proxy command: %s", err)
+	}
+	if string(out) != "web:22" {
+		t.Errorf("unexpected output %q", out)
+	}
+}
@@ -0,0 +1,202 @@
+package sshconfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ParseDump parses the configuration of a single host printed by other
+// programs, like the effective configuration printed by `ssh -G host` or
+// the output of `vagrant ssh-config`. Path is used in errors.
+//
+// The output of ssh -G starts with a `host` line naming the host, without it
+// the host is returned as `Host *`. Values ssh prints in a form it doesn't
+// accept in config files, true and false for yes and no and a RekeyLimit of
+// 0 for the defaults, are converted first. An error is returned if the
+// output has more than one Host block.
+func ParseDump(r io.Reader, path string) (*SSHHost, error) {
+	var b strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		b.WriteString(normalizeDumpLine(scanner.Text()))
+		b.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	hosts, err := parse(b.String(), path)
+	if err != nil {
+		return nil, err
+	}
+	switch len(hosts) {
+	case 0:
+		return nil, errors.New("no host found")
+	case 1:
+		return hosts[0], nil
+	}
+	return nil, fmt.Errorf("expected a single host, found %d", len(hosts))
+}
+
+// normalizeDumpLine converts the value of a line printed by ssh -G to the
+// form accepted in config files.
+func normalizeDumpLine(line string) string {
+	keyword, value, ok := strings.Cut(strings.TrimSpace(line), " ")
+	if !ok {
+		return line
+	}
+	typ, ok := variables[strings.ToLower(keyword)]
+	if !ok {
+		return line
+	}
+
+	switch {
+	case typ == itemRekeyLimit:
+		// ssh prints 0 for the default amount of data and no time limit
+		data, time, _ := strings.Cut(value, " ")
+		if data == "0" {
+			data = "default"
+		}
+		if time == "0" {
+			time = "none"
+		}
+		return strings.TrimSpace(keyword + " " + data + " " + time)
+	case value == "true":
+		return keyword + " yes"
+	case value == "false":
+		return keyword + " no"
+	}
+	return line
+}
+
+// KeyValue is a keyword with a value in the form it is written in a config
+// file.
+type KeyValue struct {
+	Keyword string `json:"keyword" yaml:"keyword"`
+	Value   string `json:"value" yaml:"value"`
+}
+
+func (kv KeyValue) String() string {
+	return kv.Keyword + " " + kv.Value
+}
+
+// openSSHDefaults are the values ssh uses for keywords which are not set,
+// as printed by ssh -G. Keywords whose default depends on the build, like
+// the algorithm lists, are left out.
+var openSSHDefaults = map[string][]string{
+	"Port":                             {"22"},
+	"ForwardAgent":                     {"no"},
+	"ControlMaster":                    {"no"},
+	"ControlPersist":                   {"no"},
+	"ServerAliveCountMax":              {"3"},
+	"StrictHostKeyChecking":            {"ask"},
+	"UserKnownHostsFile":               {"~/.ssh/known_hosts ~/.ssh/known_hosts2"},
+	"PubkeyAuthentication":             {"yes"},
+	"PasswordAuthentication":           {"yes"},
+	"KbdInteractiveAuthentication":     {"yes"},
+	"PermitLocalCommand":               {"no"},
+	"RequestTTY":                       {"auto"},
+	"SessionType":                      {"default"},
+	"ForwardX11":                       {"no"},
+	"ForwardX11Trusted":                {"no"},
+	"ForwardX11Timeout":                {"20m"},
+	"CanonicalizeHostname":             {"no"},
+	"CanonicalizeMaxDots":              {"1"},
+	"CanonicalizeFallbackLocal":        {"yes"},
+	"HashKnownHosts":                   {"no"},
+	"FingerprintHash":                  {"sha256"},
+	"CheckHostIP":                      {"no"},
+	"VerifyHostKeyDNS":                 {"no"},
+	"TCPKeepAlive":                     {"yes"},
+	"Tunnel":                           {"no"},
+	"TunnelDevice":                     {"any:any"},
+	"GatewayPorts":                     {"no"},
+	"ExitOnForwardFailure":             {"no"},
+	"ClearAllForwardings":              {"no"},
+	"LogLevel":                         {"INFO"},
+	"SyslogFacility":                   {"USER"},
+	"BatchMode":                        {"no"},
+	"NumberOfPasswordPrompts":          {"3"},
+	"EscapeChar":                       {"~"},
+	"EnableEscapeCommandline":          {"no"},
+	"SecurityKeyProvider":              {"internal"},
+	"GSSAPIAuthentication":             {"no"},
+	"GSSAPIDelegateCredentials":        {"no"},
+	"GSSAPIKeyExchange":                {"no"},
+	"GSSAPITrustDns":                   {"no"},
+	"RekeyLimit":                       {"default none"},
+	"AddKeysToAgent":                   {"no"},
+	"RequiredRSASize":                  {"1024"},
+	"ChannelTimeout":                   {"none"},
+	"ObscureKeystrokeTiming":           {"interval:20"},
+	"StdinNull":                        {"no"},
+	"ForkAfterAuthentication":          {"no"},
+	"ProxyUseFdpass":                   {"no"},
+	"StreamLocalBindMask":              {"0177"},
+	"StreamLocalBindUnlink":            {"no"},
+	"HostbasedAuthentication":          {"no"},
+	"NoHostAuthenticationForLocalhost": {"no"},
+	"IdentityFile": {
+		"~/.ssh/id_rsa",
+		"~/.ssh/id_ecdsa",
+		"~/.ssh/id_ecdsa_sk",
+		"~/.ssh/id_ed25519",
+		"~/.ssh/id_ed25519_sk",
+	},
+}
+
+// Dump returns the effective configuration of the host as pairs of keyword
+// and value, like ssh -G prints it, to display or compare configurations.
+// It starts with Host and the first alias of the host, followed by the
+// keywords known to the parser and then the unknown keywords sorted by name.
+// Keywords given multiple times, like IdentityFile, have a pair for each
+// value. Keywords which are not set have the default of ssh, if it doesn't
+// depend on how ssh was built. HostName and User default to the alias and
+// the local user.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func (h *SSHHost) Dump() []KeyValue {
+	var pairs []KeyValue
+	add := func(keyword string, values ...string) {
+		for _, v := range values {
+			pairs = append(pairs, KeyValue{Keyword: keyword, Value: v})
+		}
+	}
+
+	add("Host", originalHost(h))
+	for _, keyword := range keywords {
+		values := h.GetAll(keyword)
+		switch {
+		case len(values) > 0:
+		case keyword == "HostName":
+			values = nonEmpty(originalHost(h))
+		case keyword == "User":
+			values = nonEmpty(localUser())
+		case keyword == "GlobalKnownHostsFile":
+			values = spacedList([]string{
+				filepath.Join(systemDir, "ssh_known_hosts"),
+				filepath.Join(systemDir, "ssh_known_hosts2"),
+			})
+		default:
+			values = openSSHDefaults[keyword]
+		}
+		add(keyword, values...)
+	}
+
+	for _, keyword := range slices.Sorted(maps.Keys(h.Unknowns)) {
+		add(keyword, h.Unknowns[keyword]...)
+	}
+	return pairs
+}
+
+// Dump returns the effective configuration of alias, see SSHHost.Dump.
+func (c *Config) Dump(alias string, opts ...LookupOption) []KeyValue {
+	return c.Lookup(alias, opts...).Dump()
+}
@@ -0,0 +1,126 @@
+package sshconfig
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDump(t *testing.T) {
+	output := `host web
+user deploy
+hostname web.example.com
+port 2222
+canonicalizehostname false
+pubkeyauthentication true
+updatehostkeys true
+stricthostkeychecking ask
+identityfile ~/.ssh/id_rsa
+identityfile ~/.ssh/id_ed25519
+userknownhostsfile /home/deploy/.ssh/known_hosts /home/deploy/.ssh/known_hosts2
+proxycommand ssh -W %h:%p bastion
+rekeylimit 0 0
+ipqos lowdelay throughput
+`
+	h, err := ParseDump(strings.NewReader(output), "ssh -G web")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Join(h.Host, " ") != "web" || h.HostName != "web.example.com" || h.User != "deploy" || h.Port != 2222 {
+		t.Errorf("unexpected host: %+v", h)
+	}
+	if h.CanonicalizeHostname != "no" || h.PubkeyAuthentication != "yes" || h.UpdateHostKeys != UpdateHostKeysYes {
+		t.Errorf("expected true and false to be converted, got %q, %q, %q",
+			h.CanonicalizeHostname, h.PubkeyAuthentication, h.UpdateHostKeys)
+	}
+	if len(h.IdentityFiles) != 2 || len(h.UserKnownHostsFiles) != 2 {
+		t.Errorf("unexpected lists: %v, %v", h.IdentityFiles, h.UserKnownHostsFiles)
+	}
+	if h.ProxyCommand != "ssh -W %h:%p bastion" {
+		t.Errorf("unexpected ProxyCommand: %s", h.ProxyCommand)
+	}
+	if h.RekeyLimit == nil || h.RekeyLimit.Bytes != 0 || h.RekeyLimit.Interval != 0 {
+		t.Errorf("unexpected RekeyLimit: %v", h.RekeyLimit)
+	}
+	if got := h.Unknowns["ipqos"]; len(got) != 1 || got[0] != "lowdelay throughput" {
+		t.Errorf("unexpected unknown keywords: %v", h.Unknowns)
+	}
+
+	vagrant := `Host default
+  HostName 127.0.0.1
+  User vagrant
+  Port 2222
+  UserKnownHostsFile /dev/null
+  StrictHostKeyChecking no
+  PasswordAuthentication no
+  IdentityFile /home/me/project/.vagrant/machines/default/virtualbox/private_key
+  IdentitiesOnly yes
+  LogLevel FATAL
+`
+	h, err = ParseDump(strings.NewReader(vagrant), "vagrant ssh-config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Join(h.Host, " ") != "default" || h.Port != 2222 || h.StrictHostKeyChecking != "no" {
+		t.Errorf("unexpected host: %+v", h)
+	}
+
+	if _, err := ParseDump(strings.NewReader("Host a\n  User a\nHost b\n  User b\n"), "-"); err == nil {
+		t.Errorf("expected error for multiple hosts")
+	}
+}
+
+func TestDump(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  User deploy
+  IdentityFile ~/.ssh/web
+  IdentityFile ~/.ssh/backup
+  XVendor on
+
+Host *
+  ForwardAgent yes
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	pairs := (&Config{hosts: hosts}).Dump("web")
+
+	var lines []string
+	for _, kv := range pairs {
+		lines = append(lines, kv.String())
+	}
+	if lines[0] != "Host web" || lines[1] != "HostName web.example.com" || lines[2] != "User deploy" || lines[3] != "Port 22" {
+		t.Errorf("unexpected first pairs: %v", lines[:4])
+	}
+	for _, expected := range []string{
+		"IdentityFile ~/.ssh/web",
+		"IdentityFile ~/.ssh/backup",
+		"ForwardAgent yes",
+		"StrictHostKeyChecking ask",
+		"LogLevel INFO",
+	} {
+		if !slices.Contains(lines, expected) {
+			t.Errorf("expected %q in dump", expected)
+		}
+	}
+	if slices.Contains(lines, "IdentityFile ~/.ssh/id_rsa") {
+		t.Errorf("expected no default IdentityFile")
+	}
+	if lines[len(lines)-1] != "XVendor on" {
+		t.Errorf("expected unknown keyword last, got %s", lines[len(lines)-1])
+	}
+
+	h, err := ParseDump(strings.NewReader(strings.Join(lines, "\n")), "dump")
+	if err != nil {
+		t.Fatalf("unable to parse dump: %s", err)
+	}
+	if h.HostName != "web.example.com" || h.ForwardAgent != "yes" || len(h.IdentityFiles) != 2 {
+		t.Errorf("unexpected host from dump: %+v", h)
+	}
+
+	if lines := (&SSHHost{Host: []string{"db"}}).Dump(); lines[1].Value != "db" {
+		t.Errorf("expected HostName to default to the alias, got %v", lines[1])
+	}
+}
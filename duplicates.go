@@ -0,0 +1,142 @@
+package sshconfig
+
+import (
+	"slices"
+	"strings"
+)
+
+// Duplicate is an alias named by more than one Host block, possibly in
+// different files.
+type Duplicate struct {
+	Alias string
+	// Hosts are the blocks naming the alias in file order. Their SourceFile
+	// and SourceLine give the locations.
+	Hosts []*SSHHost
+	// Shadowed are the directives of later blocks which have no effect on
+	// the alias, because an earlier block already sets the keyword and ssh
+	// uses the first value obtained.
+	Shadowed []Directive
+}
+
+// FindDuplicates returns the aliases named literally by more than one Host
+// block, in the order they first appear. Patterns are not considered, as
+// they are meant to match many hosts.
+func FindDuplicates(hosts []*SSHHost) []Duplicate {
+	var aliases []string
+	blocks := map[string][]*SSHHost{}
+	for _, h := range hosts {
+		if h.Match != nil {
+			continue
+		}
+		for _, alias := range h.Host {
+			if isPattern(alias) {
+				continue
+			}
+			found := blocks[alias]
+			if len(found) > 0 && found[len(found)-1] == h {
+				continue
+			}
+			if len(found) == 0 {
+				aliases = append(aliases, alias)
+			}
+			blocks[alias] = append(found, h)
+		}
+	}
+
+	var duplicates []Duplicate
+	for _, alias := range aliases {
+		if len(blocks[alias]) < 2 {
+			continue
+		}
+		duplicates = append(duplicates, Duplicate{
+			Alias:    alias,
+			Hosts:    blocks[alias],
+			Shadowed: shadowed(blocks[alias]),
+		})
+	}
+	return duplicates
+}
+
+// Duplicates returns the aliases named by more than one Host block, see
+// FindDuplicates.
+func (c *Config) Duplicates() []Duplicate {
+	return FindDuplicates(c.hosts)
+}
+
+// MergeDuplicates returns hosts with the Host blocks naming the same
+// aliases combined into the first of them, so an alias defined both by an
+// included team config and by the personal config is a single entry. Values
+// of earlier blocks win like in ssh, values of keywords which may be given
+// multiple times, like IdentityFile, are collected in file order. Pattern
+// and Match blocks are kept as they are, as are blocks naming a different
+// set of aliases or included within different blocks. A block is only
+// combined with an earlier one if no block in between names one of its
+// aliases, so the result resolves the same with Lookup, which applies the
+// blocks naming an alias before any other. Blocks not combined are shared
+// with hosts.
+func MergeDuplicates(hosts []*SSHHost) []*SSHHost {
+	merged := make([]*SSHHost, 0, len(hosts))
+	first := map[string]int{}
+	// last is the index of the last block naming an alias
+	last := map[string]int{}
+	for _, h := range hosts {
+		if h.Match != nil || h.IsPattern() {
+			merged = append(merged, h)
+			continue
+		}
+		key := aliasesKey(h)
+		i, ok := first[key]
+		for _, alias := range h.Host {
+			ok = ok && last[alias] == i
+		}
+		if !ok {
+			i = len(merged)
+			first[key] = i
+			merged = append(merged, h)
+		} else {
+			merged[i] = combine(merged[i], h)
+		}
+		for _, alias := range h.Host {
+			last[alias] = i
+		}
+	}
+	return merged
+}
+
+// aliasesKey identifies the Host blocks naming the same aliases, in any
+// order, within the same enclosing blocks.
+func aliasesKey(h *SSHHost) string {
+	aliases := slices.Clone(h.Host)
+	slices.Sort(aliases)
+	key := strings.Join(aliases, " ")
+	for p := h.parent; p != nil; p = p.parent {
+		key = blockKey(p) + "\n" + key
+	}
+	return key
+}
+
+// shadowed returns the directives of hosts setting a single valued keyword
+// already set by an earlier block.
+func shadowed(hosts []*SSHHost) []Directive {
+	var directives []Directive
+	set := map[string]bool{}
+	for _, h := range hosts {
+		var current []string
+		for _, d := range h.Directives {
+			keyword := strings.ToLower(d.Keyword)
+			typ, ok := variables[keyword]
+			if !ok || multiValued[typ] || typ == itemInclude {
+				continue
+			}
+			if set[keyword] {
+				directives = append(directives, d)
+				continue
+			}
+			current = append(current, keyword)
+		}
+		for _, keyword := range current {
+			set[keyword] = true
+		}
+	}
+	return directives
+}
@@ -0,0 +1,110 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	config := `Host web db
+  HostName web.example.com
+  User deploy
+  IdentityFile ~/.ssh/web
+
+Host *.example.com !web
+  User nobody
+
+Host web
+  User admin
+  Port 2222
+  IdentityFile ~/.ssh/admin
+
+Host db
+  Port 5432
+
+Host cache
+  User redis
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	duplicates := NewConfig(hosts).Duplicates()
+	if len(duplicates) != 2 {
+		t.Fatalf("expected 2 duplicates, got %d: %+v", len(duplicates), duplicates)
+	}
+
+	web := duplicates[0]
+	if web.Alias != "web" || len(web.Hosts) != 2 || web.Hosts[0].SourceLine != 1 || web.Hosts[1].SourceLine != 9 {
+		t.Errorf("unexpected duplicate: %+v", web)
+	}
+	if len(web.Shadowed) != 1 || web.Shadowed[0].Keyword != "User" || web.Shadowed[0].Line != 10 {
+		t.Errorf("unexpected shadowed directives: %+v", web.Shadowed)
+	}
+
+	db := duplicates[1]
+	if db.Alias != "db" || len(db.Hosts) != 2 || db.Hosts[1].SourceLine != 14 || len(db.Shadowed) != 0 {
+		t.Errorf("unexpected duplicate: %+v", db)
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	config := `Host web
+  User alice
+  IdentityFile ~/.ssh/alice
+
+Host *.example.com
+  User nobody
+
+Host web
+  HostName web.example.com
+  User deploy
+  Port 2222
+  IdentityFile ~/.ssh/team
+
+Host db web
+  HostName db.example.com
+
+Host web
+  User admin
+
+Host db
+  Port 5432
+`
+
+	raw, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	hosts, err := parse(config, "~/.ssh/config", WithMergedDuplicates())
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if len(hosts) != 5 {
+		t.Fatalf("expected 5 hosts, got %d: %v", len(hosts), hosts)
+	}
+	web := hosts[0]
+	if web.User != "alice" || web.HostName != "web.example.com" || web.Port != 2222 || web.SourceLine != 1 {
+		t.Errorf("unexpected merged host: %+v", web)
+	}
+	if len(web.IdentityFiles) != 2 || web.IdentityFiles[0] != "~/.ssh/alice" || web.IdentityFiles[1] != "~/.ssh/team" {
+		t.Errorf("unexpected identity files: %v", web.IdentityFiles)
+	}
+	if raw[0].HostName != "" {
+		t.Errorf("merge changed its input")
+	}
+	// the last web block follows a block naming web as well
+	for i, line := range []int{5, 14, 17, 20} {
+		if hosts[i+1].SourceLine != line {
+			t.Errorf("expected block at line %d to be kept, got %+v", line, hosts[i+1])
+		}
+	}
+
+	for _, alias := range []string{"web", "db"} {
+		if !Lookup(hosts, alias).Equal(Lookup(raw, alias)) {
+			t.Errorf("%s: merged hosts resolve differently: %+v, %+v", alias, Lookup(hosts, alias), Lookup(raw, alias))
+		}
+	}
+}
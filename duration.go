@@ -0,0 +1,123 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nonZeroDuration returns the value of a time keyword for GetAll, a plain
+// number of seconds if it is below a minute.
+func nonZeroDuration(d time.Duration) []string {
+	if d == 0 {
+		return nil
+	}
+	if d < time.Minute {
+		return []string{strconv.Itoa(int(d / time.Second))}
+	}
+	return []string{formatTime(d)}
+}
+
+// parseControlPersist checks the value of ControlPersist, yes, no or a time.
+func parseControlPersist(value string) error {
+	if v := strings.ToLower(value); v == "yes" || v == "no" {
+		return nil
+	}
+	_, err := parseTime(value)
+	return err
+}
+
+// ControlPersistDuration returns how long the master connection stays open
+// in the background after the last client exited, as set by
+// ControlPersist, and whether it stays open at all. A duration of 0 with
+// true keeps it open until it is told to stop.
+func (h *SSHHost) ControlPersistDuration() (time.Duration, bool) {
+	switch strings.ToLower(h.ControlPersist) {
+	case "", "no":
+		return 0, false
+	case "yes":
+		return 0, true
+	}
+	d, err := parseTime(h.ControlPersist)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// ChannelTimeout is an entry of the ChannelTimeout keyword, the time after
+// which ssh closes channels of a type without traffic.
+type ChannelTimeout struct {
+	// Type is the channel type, like session or direct-tcpip, or a
+	// pattern matching channel types.
+	Type string
+	// Timeout is the inactivity timeout, zero means no timeout.
+	Timeout time.Duration
+}
+
+// String returns the entry as written in a config file, type=interval.
+func (c ChannelTimeout) String() string {
+	timeout := "0"
+	if c.Timeout > 0 {
+		timeout = formatTime(c.Timeout)
+	}
+	return c.Type + "=" + timeout
+}
+
+// parseChannelTimeouts parses the type=interval arguments of
+// ChannelTimeout. A single none gives an empty list.
+func parseChannelTimeouts(args []string) ([]ChannelTimeout, error) {
+	if len(args) == 1 && strings.EqualFold(args[0], "none") {
+		return []ChannelTimeout{}, nil
+	}
+	timeouts := make([]ChannelTimeout, 0, len(args))
+	for _, arg := range args {
+		typ, interval, ok := strings.Cut(arg, "=")
+		if !ok || typ == "" {
+			return nil, fmt.Errorf("invalid ChannelTimeout: %#v", arg)
+		}
+		var d time.Duration
+		if interval != "0" {
+			var err error
+			if d, err = parseTime(interval); err != nil {
+				return nil, fmt.Errorf("invalid ChannelTimeout: %#v", arg)
+			}
+		}
+		timeouts = append(timeouts, ChannelTimeout{Type: typ, Timeout: d})
+	}
+	return timeouts, nil
+}
+
+// defaultKeystrokeInterval is the interval of ObscureKeystrokeTiming yes.
+const defaultKeystrokeInterval = 20 * time.Millisecond
+
+// parseObscureKeystrokeTiming checks the value of ObscureKeystrokeTiming,
+// yes, no or interval:milliseconds.
+func parseObscureKeystrokeTiming(value string) error {
+	if v := strings.ToLower(value); v == "yes" || v == "no" {
+		return nil
+	}
+	ms, ok := strings.CutPrefix(value, "interval:")
+	if n, err := strconv.Atoi(ms); !ok || err != nil || n < 1 || n > 1000 {
+		return fmt.Errorf("invalid ObscureKeystrokeTiming: %#v", value)
+	}
+	return nil
+}
+
+// ObscureKeystrokeInterval returns the interval at which ssh sends
+// keystrokes to obscure their timing, as set by ObscureKeystrokeTiming. It
+// is 20ms unless set otherwise, zero if obscuring is turned off.
+func (h *SSHHost) ObscureKeystrokeInterval() time.Duration {
+	switch strings.ToLower(h.ObscureKeystrokeTiming) {
+	case "", "yes":
+		return defaultKeystrokeInterval
+	case "no":
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(h.ObscureKeystrokeTiming, "interval:"))
+	if err != nil {
+		return defaultKeystrokeInterval
+	}
+	return time.Duration(n) * time.Millisecond
+}
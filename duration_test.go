@@ -0,0 +1,126 @@
+package sshconfig
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationKeywords(t *testing.T) {
+	config := `Host web
+  ConnectTimeout 10
+  ServerAliveInterval 1m30s
+  ForwardX11Timeout 2H
+  ControlPersist 10m
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.ConnectTimeout != 10*time.Second || h.ServerAliveInterval != 90*time.Second || h.ForwardX11Timeout != 2*time.Hour {
+		t.Errorf("unexpected durations: %s, %s, %s", h.ConnectTimeout, h.ServerAliveInterval, h.ForwardX11Timeout)
+	}
+	if d, ok := h.ControlPersistDuration(); d != 10*time.Minute || !ok {
+		t.Errorf("unexpected ControlPersist: %s, %t", d, ok)
+	}
+
+	for keyword, expected := range map[string]string{
+		"ConnectTimeout":      "10",
+		"ServerAliveInterval": "1m30s",
+		"ForwardX11Timeout":   "2h",
+		"ControlPersist":      "10m",
+	} {
+		if v, _ := h.Get(keyword); v != expected {
+			t.Errorf("%s: expected %s, got %s", keyword, expected, v)
+		}
+	}
+
+	for value, expected := range map[string]bool{"yes": true, "no": false, "": false} {
+		h := &SSHHost{ControlPersist: value}
+		if d, ok := h.ControlPersistDuration(); d != 0 || ok != expected {
+			t.Errorf("ControlPersist %q: unexpected %s, %t", value, d, ok)
+		}
+	}
+
+	hosts, err = parse("Host web\n  ConnectTimeout none\n", "~/.ssh/config")
+	if err != nil || hosts[0].ConnectTimeout != 0 {
+		t.Errorf("unexpected ConnectTimeout none: %v", err)
+	}
+	_, err = parse("Host web\n  ConnectTimeout 5x\n", "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:2:18: invalid time: "5x"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = parse("Host web\n  ControlPersist sometimes\n", "~/.ssh/config")
+	if err == nil {
+		t.Error("expected error for invalid ControlPersist")
+	}
+}
+
+func TestChannelTimeout(t *testing.T) {
+	config := `Host web
+  ChannelTimeout session=5m direct-*=1h30m x11-connection=0
+  ChannelTimeout global=10m
+  RequiredRSASize 2048
+  ObscureKeystrokeTiming interval:80
+
+Host *
+  ChannelTimeout none
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	web := Lookup(hosts, "web")
+	expected := []ChannelTimeout{
+		{Type: "session", Timeout: 5 * time.Minute},
+		{Type: "direct-*", Timeout: 90 * time.Minute},
+		{Type: "x11-connection"},
+	}
+	if len(web.ChannelTimeouts) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, web.ChannelTimeouts)
+	}
+	for i, c := range expected {
+		if web.ChannelTimeouts[i] != c {
+			t.Errorf("expected %v, got %v", c, web.ChannelTimeouts[i])
+		}
+	}
+	if v, _ := web.Get("ChannelTimeout"); v != "session=5m direct-*=1h30m x11-connection=0" {
+		t.Errorf("unexpected ChannelTimeout: %s", v)
+	}
+	if web.RequiredRSASize != 2048 {
+		t.Errorf("unexpected RequiredRSASize: %d", web.RequiredRSASize)
+	}
+	if d := web.ObscureKeystrokeInterval(); d != 80*time.Millisecond {
+		t.Errorf("unexpected keystroke interval: %s", d)
+	}
+
+	other := Lookup(hosts, "db")
+	if other.ChannelTimeouts == nil || len(other.ChannelTimeouts) != 0 {
+		t.Errorf("expected no channel timeouts, got %v", other.ChannelTimeouts)
+	}
+	if v, _ := other.Get("ChannelTimeout"); v != "none" {
+		t.Errorf("unexpected ChannelTimeout: %s", v)
+	}
+
+	for value, expected := range map[string]time.Duration{"": 20 * time.Millisecond, "yes": 20 * time.Millisecond, "no": 0} {
+		h := &SSHHost{ObscureKeystrokeTiming: value}
+		if d := h.ObscureKeystrokeInterval(); d != expected {
+			t.Errorf("%q: expected %s, got %s", value, expected, d)
+		}
+	}
+
+	for _, line := range []string{
+		"ChannelTimeout session",
+		"ChannelTimeout =5m",
+		"ChannelTimeout session=soon",
+		"RequiredRSASize big",
+		"ObscureKeystrokeTiming interval:0",
+		"ObscureKeystrokeTiming sometimes",
+	} {
+		if _, err := parse("Host web\n  "+line+"\n", "~/.ssh/config"); err == nil {
+			t.Errorf("expected error for %s", line)
+		}
+	}
+}
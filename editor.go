@@ -0,0 +1,317 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Editor edits the Host blocks of a single config file as text, leaving
+// comments, formatting and everything it doesn't touch as it is. Included
+// files are not followed.
+type Editor struct {
+	path    string
+	content string
+	blocks  []editorBlock
+}
+
+// editorBlock is the position of a Host or Match block in the content.
+type editorBlock struct {
+	// aliases are the patterns of a Host block, nil for Match blocks
+	aliases []string
+	// start is the offset of the comment lines directly above the header,
+	// or of the header itself
+	start int
+	// header is the offset of the Host or Match line
+	header int
+	// end is the offset after the last directive line of the block
+	end int
+	// directives are the keyword and value items of the block
+	directives []editorDirective
+}
+
+type editorDirective struct {
+	keyword item
+	value   item
+}
+
+// OpenEditor reads the config file given by path for editing.
+func OpenEditor(path string) (*Editor, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewEditor(content, path)
+}
+
+// NewEditor returns an Editor for the config in content. The path is used
+// for error messages and by Save.
+func NewEditor(content []byte, path string) (*Editor, error) {
+	e := &Editor{path: path}
+	if err := e.reset(string(content)); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Bytes returns the edited config.
+func (e *Editor) Bytes() []byte {
+	return []byte(e.content)
+}
+
+// Save writes the edited config back to the file it was read from. The file
+// is replaced atomically, keeping its permissions.
+func (e *Editor) Save() error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(e.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(e.path), "."+filepath.Base(e.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(e.content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), e.path)
+}
+
+// AddHost appends the block of h to the config. It fails if a Host block
+// already lists one of the aliases of h.
+func (e *Editor) AddHost(h *SSHHost) error {
+	for _, alias := range h.Host {
+		if e.find(alias) != nil {
+			return fmt.Errorf("host %s already exists", alias)
+		}
+	}
+
+	text, err := h.MarshalText()
+	if err != nil {
+		return err
+	}
+	return e.appendBlock(e.newlines(string(text)))
+}
+
+// appendBlock appends text to the config, separated from the content before
+// by a blank line.
+func (e *Editor) appendBlock(text string) error {
+	content := e.content
+	if content != "" {
+		nl := e.newlines("\n")
+		if !strings.HasSuffix(content, "\n") {
+			content += nl
+		}
+		if !strings.HasSuffix(strings.TrimSuffix(strings.TrimSuffix(content, "\n"), "\r"), "\n") {
+			content += nl
+		}
+	}
+	return e.reset(content + text)
+}
+
+// UpdateHost replaces the first Host block listing alias by the block of h.
+// Comments directly above the block are kept.
+func (e *Editor) UpdateHost(alias string, h *SSHHost) error {
+	b := e.find(alias)
+	if b == nil {
+		return fmt.Errorf("host %s not found", alias)
+	}
+
+	text, err := h.MarshalText()
+	if err != nil {
+		return err
+	}
+	return e.reset(e.content[:b.header] + e.newlines(string(text)) + e.content[b.end:])
+}
+
+// RemoveHost removes the first Host block listing alias together with the
+// comments directly above it.
+func (e *Editor) RemoveHost(alias string) error {
+	b := e.find(alias)
+	if b == nil {
+		return fmt.Errorf("host %s not found", alias)
+	}
+
+	before, after := e.content[:b.start], e.content[b.end:]
+	// don't leave two blank lines where the block was
+	if (before == "" || strings.HasSuffix(before, "\n\n")) && strings.HasPrefix(strings.TrimLeft(after, "\r"), "\n") {
+		after = strings.TrimPrefix(strings.TrimPrefix(after, "\r"), "\n")
+	}
+	return e.reset(before + after)
+}
+
+// SetKeyword sets keyword to value in the first Host block listing alias,
+// changing only the value of the directive so the indentation, separator
+// and trailing comment of the line are kept. If the block sets the keyword
+// more than once, like IdentityFile, the first value is replaced. A keyword
+// not set yet is added as a new line at the end of the block. The value is
+// given as written in a config file, see SSHHost.Set.
+func (e *Editor) SetKeyword(alias, keyword, value string) error {
+	if err := (&SSHHost{}).Set(keyword, value); err != nil {
+		return fmt.Errorf("invalid %s: %w", keyword, err)
+	}
+
+	b := e.find(alias)
+	if b == nil {
+		return fmt.Errorf("host %s not found", alias)
+	}
+
+	for _, d := range b.directives {
+		if !strings.EqualFold(d.keyword.val, keyword) {
+			continue
+		}
+		start := int(d.value.pos)
+		end := start + argsEnd(d.value.val, commandKeywords[d.keyword.typ])
+		return e.reset(e.content[:start] + value + e.content[end:])
+	}
+
+	indent := "  "
+	if n := len(b.directives); n > 0 {
+		last := b.directives[n-1].keyword
+		lineStart := strings.LastIndexByte(e.content[:last.pos], '\n') + 1
+		indent = e.content[lineStart:last.pos]
+	}
+	before, after := e.content[:b.end], e.content[b.end:]
+	if !strings.HasSuffix(before, "\n") {
+		before += e.newlines("\n")
+	}
+	return e.reset(before + e.newlines(indent+keyword+" "+value+"\n") + after)
+}
+
+// argsEnd returns the length of the arguments in the value of a directive,
+// leaving out a trailing comment and whitespace. Commands take the rest of
+// the line.
+func argsEnd(value string, command bool) int {
+	if command {
+		return len(strings.TrimRight(value, " \t"))
+	}
+
+	end := 0
+	i := 0
+	for {
+		for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+			i++
+		}
+		if i == len(value) || value[i] == '#' {
+			return end
+		}
+
+		var quote byte
+	Arg:
+		for ; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '\\' && i+1 < len(value) && isEscaped(value[i+1], quote):
+				i++
+			case quote == 0 && (c == ' ' || c == '\t'):
+				break Arg
+			case quote == 0 && (c == '"' || c == '\''):
+				quote = c
+			case quote != 0 && c == quote:
+				quote = 0
+			}
+		}
+		end = i
+	}
+}
+
+// find returns the first Host block listing alias literally.
+func (e *Editor) find(alias string) *editorBlock {
+	for i, b := range e.blocks {
+		for _, a := range b.aliases {
+			if a == alias {
+				return &e.blocks[i]
+			}
+		}
+	}
+	return nil
+}
+
+// newlines converts the line endings of text to the ones of the config.
+func (e *Editor) newlines(text string) string {
+	if strings.Contains(e.content, "\r\n") {
+		return strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	return text
+}
+
+// reset sets the content and indexes its blocks.
+func (e *Editor) reset(content string) error {
+	lines := strings.SplitAfter(content, "\n")
+	lineStart := make([]int, len(lines)+1)
+	for i, l := range lines {
+		lineStart[i+1] = lineStart[i] + len(l)
+	}
+	// lineEnd returns the offset after the given 1-based line
+	lineEnd := func(line int) int {
+		return lineStart[line]
+	}
+
+	var blocks []editorBlock
+	var current *editorBlock
+	var lastLine int
+	closeBlock := func() {
+		if current != nil {
+			current.end = lineEnd(lastLine)
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+
+	lexer := lex(content)
+	defer lexer.drain()
+	for {
+		token := lexer.nextItem()
+		switch token.typ {
+		case itemEOF:
+			closeBlock()
+			e.content = content
+			e.blocks = blocks
+			return nil
+		case itemError:
+			return newParseError(content, e.path, token, "", errors.New(token.val))
+		case itemHost, itemMatch:
+			closeBlock()
+			value := lexer.nextItem()
+			if value.typ != itemValue && value.typ != itemHostValue {
+				return newParseError(content, e.path, value, token.val, valueError(token, value))
+			}
+
+			current = &editorBlock{header: lineStart[token.line-1], aliases: []string{}}
+			if token.typ == itemMatch {
+				current.aliases = nil
+			} else if aliases, err := splitArgs(value.val); err == nil {
+				current.aliases = aliases
+			}
+
+			// comments directly above the header belong to the block
+			start := token.line - 1
+			for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "#") {
+				start--
+			}
+			current.start = lineStart[start]
+			lastLine = token.line
+		default:
+			value := lexer.nextItem()
+			if value.typ != itemValue {
+				return newParseError(content, e.path, value, token.val, valueError(token, value))
+			}
+			if current != nil {
+				current.directives = append(current.directives, editorDirective{keyword: token, value: value})
+				lastLine = token.line
+			}
+		}
+	}
+}
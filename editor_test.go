@@ -0,0 +1,195 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const editorConfig = `# managed by hand
+ServerAliveInterval 30
+
+# the web server
+Host web
+    HostName web.example.com   # keep this
+    User deploy
+
+Host db
+    User postgres
+
+Host *
+    IdentityFile ~/.ssh/id_ed25519
+`
+
+func TestEditorAddHost(t *testing.T) {
+	e, err := NewEditor([]byte(editorConfig), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to open editor: %s", err.Error())
+	}
+
+	host, err := NewHostBuilder("cache").HostName("10.0.0.5").Build()
+	if err != nil {
+		t.Fatalf("unable to build host: %s", err.Error())
+	}
+	if err := e.AddHost(host); err != nil {
+		t.Fatalf("unable to add host: %s", err.Error())
+	}
+
+	expected := editorConfig + "\nHost cache\n  HostName 10.0.0.5\n"
+	if string(e.Bytes()) != expected {
+		t.Errorf("unexpected config:\n%s", e.Bytes())
+	}
+
+	if err := e.AddHost(&SSHHost{Host: []string{"db"}}); err == nil || err.Error() != "host db already exists" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEditorUpdateHost(t *testing.T) {
+	e, err := NewEditor([]byte(editorConfig), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to open editor: %s", err.Error())
+	}
+
+	if err := e.UpdateHost("db", &SSHHost{Host: []string{"db", "db.internal"}, User: "admin", Port: 5432}); err != nil {
+		t.Fatalf("unable to update host: %s", err.Error())
+	}
+
+	expected := `# managed by hand
+ServerAliveInterval 30
+
+# the web server
+Host web
+    HostName web.example.com   # keep this
+    User deploy
+
+Host db db.internal
+  User admin
+  Port 5432
+
+Host *
+    IdentityFile ~/.ssh/id_ed25519
+`
+	if string(e.Bytes()) != expected {
+		t.Errorf("unexpected config:\n%s", e.Bytes())
+	}
+
+	if err := e.UpdateHost("missing", &SSHHost{Host: []string{"missing"}}); err == nil {
+		t.Errorf("expected error for missing host")
+	}
+}
+
+func TestEditorRemoveHost(t *testing.T) {
+	e, err := NewEditor([]byte(editorConfig), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to open editor: %s", err.Error())
+	}
+
+	if err := e.RemoveHost("web"); err != nil {
+		t.Fatalf("unable to remove host: %s", err.Error())
+	}
+	if err := e.RemoveHost("*"); err != nil {
+		t.Fatalf("unable to remove host: %s", err.Error())
+	}
+
+	expected := `# managed by hand
+ServerAliveInterval 30
+
+Host db
+    User postgres
+
+`
+	if string(e.Bytes()) != expected {
+		t.Errorf("unexpected config:\n%q", e.Bytes())
+	}
+}
+
+func TestEditorSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("Host web\r\n  User deploy\r\n"), 0640); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	e, err := OpenEditor(path)
+	if err != nil {
+		t.Fatalf("unable to open editor: %s", err.Error())
+	}
+	if err := e.AddHost(&SSHHost{Host: []string{"db"}, User: "postgres", Port: 22}); err != nil {
+		t.Fatalf("unable to add host: %s", err.Error())
+	}
+	if err := e.Save(); err != nil {
+		t.Fatalf("unable to save config: %s", err.Error())
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read file: %s", err.Error())
+	}
+	if string(content) != "Host web\r\n  User deploy\r\n\r\nHost db\r\n  User postgres\r\n" {
+		t.Errorf("unexpected config: %q", content)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unable to stat file: %s", err.Error())
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("unexpected permissions: %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected temporary file to be removed, got %d entries", len(entries))
+	}
+}
+
+func TestEditorSetKeyword(t *testing.T) {
+	e, err := NewEditor([]byte(editorConfig), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to open editor: %s", err.Error())
+	}
+
+	for _, set := range [][3]string{
+		{"web", "hostname", "web2.example.com"},
+		{"db", "Port", "5432"},
+		{"*", "IdentityFile", `"~/.ssh/id ed25519"`},
+	} {
+		if err := e.SetKeyword(set[0], set[1], set[2]); err != nil {
+			t.Fatalf("unable to set %s: %s", set[1], err.Error())
+		}
+	}
+
+	expected := `# managed by hand
+ServerAliveInterval 30
+
+# the web server
+Host web
+    HostName web2.example.com   # keep this
+    User deploy
+
+Host db
+    User postgres
+    Port 5432
+
+Host *
+    IdentityFile "~/.ssh/id ed25519"
+`
+	if string(e.Bytes()) != expected {
+		t.Errorf("unexpected config:\n%s", e.Bytes())
+	}
+
+	config, err := parse(string(e.Bytes()), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse edited config: %s", err.Error())
+	}
+	if config[2].Port != 5432 || config[3].IdentityFile != "~/.ssh/id ed25519" {
+		t.Errorf("unexpected hosts: %+v", config)
+	}
+
+	if err := e.SetKeyword("db", "Port", "none"); err == nil {
+		t.Errorf("expected error for invalid port")
+	}
+	if err := e.SetKeyword("missing", "Port", "22"); err == nil {
+		t.Errorf("expected error for missing host")
+	}
+}
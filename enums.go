@@ -0,0 +1,163 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RequestTTY is the value of the RequestTTY keyword
+type RequestTTY string
+
+const (
+	RequestTTYYes   RequestTTY = "yes"
+	RequestTTYNo    RequestTTY = "no"
+	RequestTTYForce RequestTTY = "force"
+	RequestTTYAuto  RequestTTY = "auto"
+)
+
+// SessionType is the value of the SessionType keyword
+type SessionType string
+
+const (
+	SessionTypeNone      SessionType = "none"
+	SessionTypeSubsystem SessionType = "subsystem"
+	SessionTypeDefault   SessionType = "default"
+)
+
+// UpdateHostKeys is the value of the UpdateHostKeys keyword
+type UpdateHostKeys string
+
+const (
+	UpdateHostKeysYes UpdateHostKeys = "yes"
+	UpdateHostKeysNo  UpdateHostKeys = "no"
+	UpdateHostKeysAsk UpdateHostKeys = "ask"
+)
+
+// ControlMaster is the value of the ControlMaster keyword
+type ControlMaster string
+
+const (
+	ControlMasterYes     ControlMaster = "yes"
+	ControlMasterNo      ControlMaster = "no"
+	ControlMasterAsk     ControlMaster = "ask"
+	ControlMasterAuto    ControlMaster = "auto"
+	ControlMasterAutoAsk ControlMaster = "autoask"
+)
+
+// StrictHostKeyChecking is the value of the StrictHostKeyChecking keyword
+type StrictHostKeyChecking string
+
+const (
+	StrictHostKeyCheckingYes       StrictHostKeyChecking = "yes"
+	StrictHostKeyCheckingNo        StrictHostKeyChecking = "no"
+	StrictHostKeyCheckingOff       StrictHostKeyChecking = "off"
+	StrictHostKeyCheckingAsk       StrictHostKeyChecking = "ask"
+	StrictHostKeyCheckingAcceptNew StrictHostKeyChecking = "accept-new"
+)
+
+// Tunnel is the value of the Tunnel keyword
+type Tunnel string
+
+const (
+	TunnelYes          Tunnel = "yes"
+	TunnelNo           Tunnel = "no"
+	TunnelPointToPoint Tunnel = "point-to-point"
+	TunnelEthernet     Tunnel = "ethernet"
+)
+
+// FingerprintHash is the value of the FingerprintHash keyword
+type FingerprintHash string
+
+const (
+	FingerprintHashMD5    FingerprintHash = "md5"
+	FingerprintHashSHA256 FingerprintHash = "sha256"
+)
+
+// AddKeysToAgent is the value of the AddKeysToAgent keyword. Besides the
+// constants it may be a time like 1h, optionally preceded by confirm, after
+// which the key is removed from the agent again.
+type AddKeysToAgent string
+
+const (
+	AddKeysToAgentYes     AddKeysToAgent = "yes"
+	AddKeysToAgentNo      AddKeysToAgent = "no"
+	AddKeysToAgentAsk     AddKeysToAgent = "ask"
+	AddKeysToAgentConfirm AddKeysToAgent = "confirm"
+)
+
+// parseAddKeysToAgent parses the value of AddKeysToAgent.
+func parseAddKeysToAgent(value string) (AddKeysToAgent, error) {
+	v, err := parseYesNoEnum("AddKeysToAgent", value, AddKeysToAgentYes, AddKeysToAgentNo, AddKeysToAgentAsk, AddKeysToAgentConfirm)
+	if err == nil {
+		return v, nil
+	}
+
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 2 && fields[0] == string(AddKeysToAgentConfirm) {
+		fields = fields[1:]
+	}
+	if len(fields) != 1 {
+		return "", err
+	}
+	if _, timeErr := parseTime(fields[0]); timeErr != nil {
+		return "", err
+	}
+	return AddKeysToAgent(strings.Join(strings.Fields(strings.ToLower(value)), " ")), nil
+}
+
+// TriBool is the value of a keyword which is either yes or no. Unlike a
+// bool it tells an explicit no apart from a keyword which is not set.
+type TriBool string
+
+const (
+	TriBoolUnset TriBool = ""
+	TriBoolYes   TriBool = "yes"
+	TriBoolNo    TriBool = "no"
+)
+
+// parseTriBool parses yes or no, or true or false like ssh does.
+func parseTriBool(keyword string, value string) (TriBool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "true":
+		return TriBoolYes, nil
+	case "no", "false":
+		return TriBoolNo, nil
+	}
+	return TriBoolUnset, fmt.Errorf("invalid %s value: %#v", keyword, value)
+}
+
+// IsSet reports whether the keyword is set.
+func (b TriBool) IsSet() bool {
+	return b != TriBoolUnset
+}
+
+// Bool returns whether the keyword is yes, or def if it is not set.
+func (b TriBool) Bool(def bool) bool {
+	if b == TriBoolUnset {
+		return def
+	}
+	return b == TriBoolYes
+}
+
+// parseYesNoEnum is parseEnum for keywords which, like in ssh, also accept
+// true and false for yes and no.
+func parseYesNoEnum[T ~string](keyword string, value string, values ...T) (T, error) {
+	switch strings.ToLower(value) {
+	case "true":
+		value = "yes"
+	case "false":
+		value = "no"
+	}
+	return parseEnum(keyword, value, values...)
+}
+
+// parseEnum returns value lowercased if it is one of values.
+func parseEnum[T ~string](keyword string, value string, values ...T) (T, error) {
+	v := T(strings.ToLower(value))
+	for _, valid := range values {
+		if v == valid {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s value: %#v", keyword, value)
+}
@@ -0,0 +1,37 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a problem found while parsing a config file. The
+// underlying error, if any, is available through errors.Unwrap.
+type ParseError struct {
+	File      string
+	Line      int
+	Column    int
+	Directive string
+	Msg       string
+	Err       error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns a ParseError for the item it of input read from path.
+func newParseError(input, path string, it item, directive string, err error) *ParseError {
+	return &ParseError{
+		File:      path,
+		Line:      it.line,
+		Column:    int(it.pos) - strings.LastIndex(input[:it.pos], "\n"),
+		Directive: directive,
+		Msg:       err.Error(),
+		Err:       err,
+	}
+}
@@ -0,0 +1,240 @@
+package sshconfig
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"os/user"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Expand returns the value of the string field of host with the given name
+// (e.g. "ProxyCommand" or "IdentityFile") with all percent tokens expanded
+// as described in the TOKENS section of ssh_config(5). The field name is
+// matched case-insensitively and an empty string is returned for unknown or
+// non-string fields.
+//
+// Host is expected to be a resolved host as returned by Lookup, its first
+// Host entry is used as the original host name.
+func Expand(host *SSHHost, field string) string {
+	v := reflect.ValueOf(host).Elem().FieldByNameFunc(func(name string) bool {
+		return strings.EqualFold(name, field)
+	})
+	if !v.IsValid() || v.Kind() != reflect.String {
+		return ""
+	}
+
+	return expandTokens(v.String(), host)
+}
+
+// ExpandPath expands path the way ssh expands IdentityFile, CertificateFile
+// and ControlPath: ${VAR} environment variables, then percent tokens like %d
+// and %u, then a leading ~. It fails if an environment variable is not
+// set.
+//
+// Host is expected to be a resolved host as returned by Lookup, see Expand.
+func (h *SSHHost) ExpandPath(path string) (string, error) {
+	path, err := expandEnv(path)
+	if err != nil {
+		return "", err
+	}
+	return expandHome(expandTokens(path, h))
+}
+
+// ExpandPaths returns a copy of the host with IdentityFile, CertificateFile
+// and ControlPath expanded by ExpandPath, so they can be used to load keys
+// or open sockets directly. A value of none is left alone.
+func (h *SSHHost) ExpandPaths() (*SSHHost, error) {
+	c := h.Clone()
+
+	expand := func(path *string) error {
+		if *path == "" || strings.EqualFold(*path, "none") {
+			return nil
+		}
+		expanded, err := h.ExpandPath(*path)
+		if err != nil {
+			return err
+		}
+		*path = expanded
+		return nil
+	}
+
+	paths := []*string{&c.IdentityFile, &c.ControlPath}
+	for i := range c.IdentityFiles {
+		paths = append(paths, &c.IdentityFiles[i])
+	}
+	for i := range c.CertificateFiles {
+		paths = append(paths, &c.CertificateFiles[i])
+	}
+	for _, path := range paths {
+		if err := expand(path); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// expandEnv replaces ${VAR} in s by the value of the environment variable.
+func expandEnv(s string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated environment variable in %#v", s)
+		}
+
+		name := s[start+2 : start+end]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[start+end+1:]
+	}
+}
+
+// expandTokens expands the percent tokens in s using the values of host.
+func expandTokens(s string, host *SSHHost) string {
+	return expandTokensWith(s, host, nil)
+}
+
+// expandTokensWith is expandTokens with extra tokens only some keywords
+// accept, like the %K key of KnownHostsCommand.
+func expandTokensWith(s string, host *SSHHost, extra map[byte]string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if v, ok := extra[s[i]]; ok {
+			b.WriteString(v)
+			continue
+		}
+		switch s[i] {
+		case '%':
+			b.WriteByte('%')
+		case 'C':
+			b.WriteString(ConnectionHash(host))
+		case 'd':
+			home, _ := homeDir()
+			b.WriteString(home)
+		case 'h':
+			b.WriteString(remoteHost(host))
+		case 'i':
+			b.WriteString(strconv.Itoa(os.Getuid()))
+		case 'j':
+			b.WriteString(proxyJump(host))
+		case 'k':
+			// the host key alias if set, the original host otherwise
+			if host.HostKeyAlias != "" {
+				b.WriteString(host.HostKeyAlias)
+			} else {
+				b.WriteString(originalHost(host))
+			}
+		case 'n':
+			b.WriteString(originalHost(host))
+		case 'L':
+			l := localHost()
+			if i := strings.Index(l, "."); i >= 0 {
+				l = l[:i]
+			}
+			b.WriteString(l)
+		case 'l':
+			b.WriteString(localHost())
+		case 'p':
+			b.WriteString(strconv.Itoa(host.Port))
+		case 'r':
+			b.WriteString(remoteUser(host))
+		case 'u':
+			b.WriteString(localUser())
+		default:
+			// unknown tokens are left untouched
+			b.WriteByte('%')
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// ConnectionHash returns the %C token of host, the SHA-1 hash of the local
+// host name, the remote host name, port and user and the ProxyJump, %l%h%p%r%j,
+// which ssh uses to name the ControlPath socket of a connection. Like ssh,
+// the local user is used if no User is set. Older OpenSSH releases don't hash
+// the ProxyJump, so their hashes differ for hosts setting it.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func ConnectionHash(host *SSHHost) string {
+	sum := sha1.Sum([]byte(localHost() + remoteHost(host) + strconv.Itoa(host.Port) + remoteUser(host) + proxyJump(host)))
+	return fmt.Sprintf("%x", sum)
+}
+
+// ControlSocket returns the ControlPath of the host expanded by ExpandPath,
+// the socket ssh uses to multiplex connections to it. An empty socket is
+// returned if ControlPath is not set or none.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func (h *SSHHost) ControlSocket() (string, error) {
+	if h.ControlPath == "" || strings.EqualFold(h.ControlPath, "none") {
+		return "", nil
+	}
+	return h.ExpandPath(h.ControlPath)
+}
+
+// remoteUser returns the %r token, the user to log in as.
+func remoteUser(host *SSHHost) string {
+	if host.User != "" {
+		return host.User
+	}
+	return localUser()
+}
+
+// proxyJump returns the %j token, the ProxyJump of the host unless it is
+// none.
+func proxyJump(host *SSHHost) string {
+	if strings.EqualFold(host.ProxyJump, "none") {
+		return ""
+	}
+	return host.ProxyJump
+}
+
+func originalHost(host *SSHHost) string {
+	if len(host.Host) > 0 {
+		return host.Host[0]
+	}
+	return host.HostName
+}
+
+func remoteHost(host *SSHHost) string {
+	if host.HostName != "" {
+		return host.HostName
+	}
+	return originalHost(host)
+}
+
+func localHost() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+func localUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
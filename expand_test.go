@@ -0,0 +1,140 @@
+package sshconfig
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestExpand(t *testing.T) {
+	host := &SSHHost{
+		Host:         []string{"web"},
+		HostName:     "web.example.com",
+		User:         "deploy",
+		Port:         2222,
+		ProxyCommand: "ssh -W %h:%p bastion # %r@%n 100%%",
+		IdentityFile: "~/.ssh/id_%n_%r",
+	}
+
+	expected := "ssh -W web.example.com:2222 bastion # deploy@web 100%"
+	if actual := Expand(host, "ProxyCommand"); actual != expected {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+
+	expected = "~/.ssh/id_web_deploy"
+	if actual := Expand(host, "identityfile"); actual != expected {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+
+	if actual := Expand(host, "Port"); actual != "" {
+		t.Errorf("expected empty value for non-string field, got %#v", actual)
+	}
+}
+
+func TestExpandHostKeyAlias(t *testing.T) {
+	host := &SSHHost{Host: []string{"web"}, Port: 22, ProxyCommand: "%k %n"}
+	if actual := Expand(host, "ProxyCommand"); actual != "web web" {
+		t.Errorf("expected %%k to be the original host, got %#v", actual)
+	}
+
+	host.HostKeyAlias = "web-key"
+	if actual := Expand(host, "ProxyCommand"); actual != "web-key web" {
+		t.Errorf("expected %%k to be the HostKeyAlias, got %#v", actual)
+	}
+}
+
+func TestExpandLocal(t *testing.T) {
+	hostname, _ := os.Hostname()
+	host := &SSHHost{Host: []string{"web"}, Port: 22, ProxyCommand: "%l %i %x"}
+
+	expected := hostname + " " + strconv.Itoa(os.Getuid()) + " %x"
+	if actual := Expand(host, "ProxyCommand"); actual != expected {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+
+	host.ProxyCommand = "%C"
+	if actual := Expand(host, "ProxyCommand"); len(actual) != 40 {
+		t.Errorf("expected sha1 hex digest, got %#v", actual)
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	tmpdir := t.TempDir()
+	homedir.DisableCache = true
+	homeEnv := "HOME"
+	if runtime.GOOS == "plan9" {
+		// On plan9, env vars are lowercase.
+		homeEnv = "home"
+	}
+	t.Setenv(homeEnv, tmpdir)
+	t.Setenv("KEYS", "/srv/keys")
+
+	host := &SSHHost{
+		Host:             []string{"web"},
+		User:             "deploy",
+		Port:             22,
+		IdentityFile:     "~/.ssh/id_%r",
+		IdentityFiles:    []string{"${KEYS}/%n", "~/.ssh/id_%r"},
+		CertificateFiles: []string{"%d/.ssh/cert"},
+		ControlPath:      "none",
+	}
+
+	expanded, err := host.ExpandPaths()
+	if err != nil {
+		t.Fatalf("unable to expand paths: %s", err.Error())
+	}
+
+	if expanded.IdentityFile != tmpdir+"/.ssh/id_deploy" {
+		t.Errorf("unexpected IdentityFile: %s", expanded.IdentityFile)
+	}
+	if !slices.Equal(expanded.IdentityFiles, []string{"/srv/keys/web", tmpdir + "/.ssh/id_deploy"}) {
+		t.Errorf("unexpected IdentityFiles: %v", expanded.IdentityFiles)
+	}
+	if expanded.CertificateFiles[0] != tmpdir+"/.ssh/cert" || expanded.ControlPath != "none" {
+		t.Errorf("unexpected paths: %+v", expanded)
+	}
+	if host.IdentityFiles[0] != "${KEYS}/%n" {
+		t.Errorf("host was changed: %v", host.IdentityFiles)
+	}
+
+	for _, path := range []string{"${UNSET_SSHCONFIG_TEST}/key", "${KEYS"} {
+		if _, err := host.ExpandPath(path); err == nil {
+			t.Errorf("expected error for %#v", path)
+		}
+	}
+}
+
+func TestConnectionHash(t *testing.T) {
+	hostname, _ := os.Hostname()
+	host := &SSHHost{Host: []string{"web"}, HostName: "web.example.com", Port: 2222, User: "deploy", ControlPath: "/tmp/cm-%C"}
+
+	sum := sha1.Sum([]byte(hostname + "web.example.com2222deploy"))
+	expected := fmt.Sprintf("%x", sum)
+	if actual := ConnectionHash(host); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	socket, err := host.ControlSocket()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if socket != "/tmp/cm-"+expected {
+		t.Errorf("unexpected socket %s", socket)
+	}
+
+	host.ProxyJump = "bastion"
+	if ConnectionHash(host) == expected {
+		t.Errorf("expected ProxyJump to change the hash")
+	}
+
+	host.ControlPath = "none"
+	if socket, err := host.ControlSocket(); err != nil || socket != "" {
+		t.Errorf("expected no socket, got %#v, %v", socket, err)
+	}
+}
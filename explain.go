@@ -0,0 +1,68 @@
+package sshconfig
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Explain returns the directives supplying the effective value of keyword
+// for alias, as found by Lookup with the same options. Each Directive tells
+// the file and line it was read from, including wildcard blocks and
+// included files. For keywords which may be given multiple times, like
+// IdentityFile, every directive adding a value is returned in the order the
+// values are used, otherwise the directive whose value is used. It returns
+// nil if no block sets the keyword, in which case the default applies.
+func Explain(hosts []*SSHHost, alias, keyword string, opts ...LookupOption) []Directive {
+	options := &lookupOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	typ, known := variables[strings.ToLower(keyword)]
+	all := known && (multiValued[typ] || (options.merge.lists() == MergeListsAppend && isList(keyword)))
+
+	_, applied := lookup(hosts, alias, opts)
+
+	var directives []Directive
+	for _, h := range applied {
+		var found []Directive
+		for _, d := range h.Directives {
+			if strings.EqualFold(d.Keyword, keyword) {
+				found = append(found, d)
+			}
+		}
+		switch {
+		case len(found) == 0:
+			continue
+		case all:
+			directives = append(directives, found...)
+		case !known:
+			// unknown keywords keep every value of the first block
+			if directives == nil || options.merge.values() == MergeOverwrite {
+				directives = found
+			}
+		case directives == nil || options.merge.values() == MergeOverwrite:
+			directives = found[:1]
+		}
+	}
+	return directives
+}
+
+// Explain returns the directives supplying the effective value of keyword
+// for alias, see Explain.
+func (c *Config) Explain(alias, keyword string, opts ...LookupOption) []Directive {
+	return Explain(c.hosts, alias, keyword, opts...)
+}
+
+// isList reports whether the value of keyword is stored as a list.
+func isList(keyword string) bool {
+	typ := reflect.TypeOf(SSHHost{})
+	for _, name := range []string{keyword + "s", keyword} {
+		f, ok := typ.FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		})
+		if ok {
+			return f.Type.Kind() == reflect.Slice
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package sshconfig
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExplain(t *testing.T) {
+	memfs := fstest.MapFS{
+		"ssh/team.conf": &fstest.MapFile{Data: []byte(`Host w*
+  User team
+  IdentityFile ~/.ssh/team
+`)},
+	}
+
+	config := `User global
+
+Host web
+  HostName web.example.com
+  IdentityFile ~/.ssh/web
+  UseKeychain yes
+
+Include team.conf
+
+Host *
+  User fallback
+  Ciphers aes256-ctr
+`
+
+	hosts, err := ParseString(config, "ssh/config", WithIncludeResolver(FSResolver(memfs)))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	c := NewConfig(hosts)
+
+	for _, tc := range []struct {
+		keyword  string
+		opts     []LookupOption
+		expected []string
+	}{
+		{keyword: "user", expected: []string{"ssh/config:1"}},
+		{keyword: "User", opts: []LookupOption{WithMergeValues(MergeOverwrite)}, expected: []string{"ssh/config:11"}},
+		{keyword: "IdentityFile", expected: []string{"ssh/config:5", "ssh/team.conf:3"}},
+		{keyword: "Ciphers", expected: []string{"ssh/config:12"}},
+		{keyword: "UseKeychain", expected: []string{"ssh/config:6"}},
+		{keyword: "Port"},
+	} {
+		directives := c.Explain("web", tc.keyword, tc.opts...)
+		if len(directives) != len(tc.expected) {
+			t.Errorf("unexpected directives for %s: %+v", tc.keyword, directives)
+			continue
+		}
+		for i, d := range directives {
+			if location := fmt.Sprintf("%s:%d", d.File, d.Line); location != tc.expected[i] {
+				t.Errorf("unexpected location for %s: %s, expected %s", tc.keyword, location, tc.expected[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// File check rule IDs.
+const (
+	// RuleMissingFile flags a file or socket referenced by a directive which
+	// doesn't exist or can't be read.
+	RuleMissingFile = "missing-file"
+	// RuleFilePermissions flags a private key which other users can read,
+	// ssh refuses to use it.
+	RuleFilePermissions = "file-permissions"
+	// RuleInvalidSocket flags an IdentityAgent which is not a socket.
+	RuleInvalidSocket = "invalid-socket"
+)
+
+// CheckFiles checks that the files referenced by the directives of hosts
+// exist on the local filesystem: IdentityFile keys, which other users must
+// not be able to read, CertificateFile, UserKnownHostsFile and
+// GlobalKnownHostsFile entries and the IdentityAgent socket. Missing keys
+// and sockets are warnings, missing known_hosts files are reported as info
+// as ssh creates them when needed.
+//
+// Paths are expanded like ExpandPath. Paths with percent tokens which depend
+// on the host connected to, like %h, are skipped. Included files are checked
+// by CheckIncludes.
+func CheckFiles(hosts []*SSHHost) []Finding {
+	var findings []Finding
+	for _, h := range hosts {
+		for _, d := range h.Directives {
+			findings = append(findings, checkDirectiveFiles(h, d)...)
+		}
+	}
+	return findings
+}
+
+// CheckFiles checks the files referenced by the config, see CheckFiles.
+func (c *Config) CheckFiles() []Finding {
+	return CheckFiles(c.hosts)
+}
+
+// checkDirectiveFiles checks the files referenced by directive d of h.
+func checkDirectiveFiles(h *SSHHost, d Directive) []Finding {
+	var findings []Finding
+	add := func(rule string, severity Severity, format string, args ...any) {
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: severity,
+			File:     d.File,
+			Line:     d.Line,
+			Msg:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	keyword := strings.ToLower(d.Keyword)
+	switch keyword {
+	case "identityfile", "certificatefile", "userknownhostsfile", "globalknownhostsfile", "identityagent":
+	default:
+		return nil
+	}
+	values, err := splitArgs(d.Value)
+	if err != nil {
+		return nil
+	}
+
+	for _, value := range values {
+		if strings.EqualFold(value, "none") || hostDependent(value) {
+			continue
+		}
+		if keyword == "identityagent" && (value == "SSH_AUTH_SOCK" || strings.HasPrefix(value, "$") && !strings.HasPrefix(value, "${")) {
+			continue
+		}
+		path, err := h.ExpandPath(value)
+		if err != nil {
+			add(RuleMissingFile, SeverityWarning, "%s %s: %s", d.Keyword, value, err)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && (keyword == "userknownhostsfile" || keyword == "globalknownhostsfile"):
+			add(RuleMissingFile, SeverityInfo, "%s %s does not exist", d.Keyword, path)
+		case errors.Is(err, fs.ErrNotExist):
+			add(RuleMissingFile, SeverityWarning, "%s %s does not exist", d.Keyword, path)
+		case err != nil:
+			add(RuleMissingFile, SeverityWarning, "%s %s: %s", d.Keyword, path, err)
+		case keyword == "identityagent" && info.Mode().Type() != fs.ModeSocket:
+			add(RuleInvalidSocket, SeverityWarning, "IdentityAgent %s is not a socket", path)
+		case keyword != "identityagent" && info.IsDir():
+			add(RuleMissingFile, SeverityWarning, "%s %s is a directory", d.Keyword, path)
+		case keyword == "identityfile" && runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0:
+			add(RuleFilePermissions, SeverityError, "permissions %#o of IdentityFile %s are too open", info.Mode().Perm(), path)
+		}
+	}
+	return findings
+}
+
+// hostDependent reports whether path has percent tokens which depend on the
+// host connected to.
+func hostDependent(path string) bool {
+	for i := 0; i < len(path)-1; i++ {
+		if path[i] != '%' {
+			continue
+		}
+		i++
+		if !strings.ContainsRune("%diLlu", rune(path[i])) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckIncludes checks the Include directives of the config given by path
+// and the files it includes, which are read like Parse does with the
+// IncludeResolver, IncludeMode and include depth of opts. Patterns which
+// match no files and files which can't be read are reported as errors. Unlike
+// Parse, which stops at the first of them, all problems are reported.
+func CheckIncludes(path string, opts ...Option) []Finding {
+	o := newOptions(opts)
+	content, err := o.resolver.ReadFile(path)
+	if err != nil {
+		return []Finding{{Rule: RuleMissingFile, Severity: SeverityError, File: path, Msg: err.Error()}}
+	}
+	return checkIncludes(content, path, o, 0)
+}
+
+// checkIncludes checks the Include directives of the file path with
+// content, included at depth.
+func checkIncludes(content []byte, path string, o *options, depth int) []Finding {
+	var findings []Finding
+	add := func(rule string, severity Severity, line int, format string, args ...any) {
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: severity,
+			File:     path,
+			Line:     line,
+			Msg:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		keyword, value := splitLine(line)
+		if !strings.EqualFold(keyword, "Include") {
+			continue
+		}
+		if depth >= o.includeDepth {
+			add(RuleMissingFile, SeverityError, i+1, "maximum include depth of %d exceeded", o.includeDepth)
+			continue
+		}
+		for _, pattern := range strings.Fields(value) {
+			files, err := includeFiles(path, pattern, o)
+			if err != nil {
+				add(RuleMissingFile, SeverityError, i+1, "Include %s: %s", pattern, err)
+				continue
+			}
+			for _, f := range files {
+				included, err := o.resolver.ReadFile(f)
+				if err != nil {
+					add(RuleMissingFile, SeverityError, i+1, "Include %s: %s", pattern, err)
+					continue
+				}
+				findings = append(findings, checkIncludes(included, f, o, depth+1)...)
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,82 @@
+package sshconfig
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestCheckFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions and sockets differ on windows")
+	}
+
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"good": 0600, "open": 0644, "known_hosts": 0600} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, mode); err != nil {
+			t.Fatalf("unable to write file: %s", err.Error())
+		}
+		if err := os.Chmod(filepath.Join(dir, name), mode); err != nil {
+			t.Fatalf("unable to change file mode: %s", err.Error())
+		}
+	}
+	socket := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unable to create socket: %s", err)
+	}
+	defer l.Close()
+
+	config := `Host web
+  IdentityFile ` + dir + `/good
+  IdentityFile ` + dir + `/open
+  IdentityFile ` + dir + `/missing
+  IdentityFile ` + dir + `/%h
+  UserKnownHostsFile ` + dir + `/known_hosts ` + dir + `/known_hosts2
+  IdentityAgent ` + socket + `
+
+Host db
+  IdentityAgent ` + dir + `/good
+  CertificateFile none
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	var got []string
+	for _, f := range CheckFiles(hosts) {
+		got = append(got, f.String())
+	}
+	expected := []string{
+		"~/.ssh/config:3: error: permissions 0644 of IdentityFile " + dir + "/open are too open (file-permissions)",
+		"~/.ssh/config:4: warning: IdentityFile " + dir + "/missing does not exist (missing-file)",
+		"~/.ssh/config:6: info: UserKnownHostsFile " + dir + "/known_hosts2 does not exist (missing-file)",
+		"~/.ssh/config:10: warning: IdentityAgent " + dir + "/good is not a socket (invalid-socket)",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+}
+
+func TestCheckIncludes(t *testing.T) {
+	resolver := mapResolver{
+		"/ssh/config":        "Include conf.d/*.conf missing.conf\nHost web\n",
+		"/ssh/conf.d/a.conf": "Host a\n  Include nothing/*\n",
+	}
+
+	var got []string
+	for _, f := range CheckIncludes("/ssh/config", WithIncludeResolver(resolver)) {
+		got = append(got, f.String())
+	}
+	expected := []string{
+		"/ssh/conf.d/a.conf:2: error: Include nothing/*: no files found for include path /ssh/conf.d/nothing/* (missing-file)",
+		"/ssh/config:1: error: Include missing.conf: no files found for include path /ssh/missing.conf (missing-file)",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+}
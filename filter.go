@@ -0,0 +1,93 @@
+package sshconfig
+
+import "slices"
+
+// Predicate reports whether a block is selected by Filter.
+type Predicate func(*SSHHost) bool
+
+// Filter returns the blocks of hosts for which pred returns true.
+func Filter(hosts []*SSHHost, pred func(*SSHHost) bool) []*SSHHost {
+	var filtered []*SSHHost
+	for _, h := range hosts {
+		if pred(h) {
+			filtered = append(filtered, h)
+		}
+	}
+	return filtered
+}
+
+// ByUser selects blocks setting User to user.
+func ByUser(user string) Predicate {
+	return func(h *SSHHost) bool {
+		return h.User == user
+	}
+}
+
+// ByPort selects blocks connecting to port. Blocks without Port directive
+// have the default port set by the parser.
+func ByPort(port int) Predicate {
+	return func(h *SSHHost) bool {
+		return h.Port == port
+	}
+}
+
+// ByIdentityFile selects blocks with an IdentityFile of path, as written in
+// the config.
+func ByIdentityFile(path string) Predicate {
+	return func(h *SSHHost) bool {
+		return slices.Contains(h.IdentityFiles, path)
+	}
+}
+
+// ByTag selects blocks setting Tag to tag.
+func ByTag(tag string) Predicate {
+	return func(h *SSHHost) bool {
+		return h.Tag == tag
+	}
+}
+
+// ByKeyword selects blocks with a value of keyword equal to value, as
+// returned by GetAll. Known and unknown keywords may be used.
+func ByKeyword(keyword, value string) Predicate {
+	return func(h *SSHHost) bool {
+		return slices.Contains(h.GetAll(keyword), value)
+	}
+}
+
+// HasKeyword selects blocks with a directive for keyword.
+func HasKeyword(keyword string) Predicate {
+	return func(h *SSHHost) bool {
+		return h.hasKeyword(keyword)
+	}
+}
+
+// And selects blocks selected by all of preds.
+func And(preds ...Predicate) Predicate {
+	return func(h *SSHHost) bool {
+		for _, pred := range preds {
+			if !pred(h) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects blocks selected by any of preds.
+func Or(preds ...Predicate) Predicate {
+	return func(h *SSHHost) bool {
+		for _, pred := range preds {
+			if pred(h) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not selects blocks not selected by pred.
+func Not(pred Predicate) Predicate {
+	return func(h *SSHHost) bool {
+		return !pred(h)
+	}
+}
@@ -0,0 +1,60 @@
+package sshconfig
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	config := `Host web
+  User deploy
+  IdentityFile ~/.ssh/deploy
+  ForwardAgent yes
+  Tag prod
+
+Host db
+  User postgres
+  Port 5432
+  Tag prod
+
+Host build
+  User deploy
+  IdentityFile ~/.ssh/deploy
+  UseKeychain yes
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	aliases := func(hosts []*SSHHost) []string {
+		var aliases []string
+		for _, h := range hosts {
+			aliases = append(aliases, h.Host...)
+		}
+		return aliases
+	}
+
+	for name, tc := range map[string]struct {
+		pred     Predicate
+		expected []string
+	}{
+		"user":          {ByUser("deploy"), []string{"web", "build"}},
+		"port":          {ByPort(5432), []string{"db"}},
+		"default port":  {ByPort(22), []string{"web", "build"}},
+		"identity file": {ByIdentityFile("~/.ssh/deploy"), []string{"web", "build"}},
+		"tag":           {ByTag("prod"), []string{"web", "db"}},
+		"keyword":       {ByKeyword("forwardagent", "yes"), []string{"web"}},
+		"unknown":       {ByKeyword("UseKeychain", "yes"), []string{"build"}},
+		"has keyword":   {HasKeyword("port"), []string{"db"}},
+		"and":           {And(ByUser("deploy"), ByTag("prod")), []string{"web"}},
+		"or":            {Or(ByPort(5432), HasKeyword("UseKeychain")), []string{"db", "build"}},
+		"not":           {Not(ByTag("prod")), []string{"build"}},
+	} {
+		filtered := NewConfig(hosts).Filter(tc.pred)
+		if got := aliases(filtered); !slices.Equal(got, tc.expected) {
+			t.Errorf("%s: unexpected hosts %v, expected %v", name, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package sshconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Fingerprint returns a hash of the effective content of hosts, as SHA-256
+// hex digest, to cheaply detect whether a config changed, for example to
+// invalidate caches or sync configs. Hosts parsed from the same config, and
+// its included files, have the same fingerprint regardless of file times,
+// formatting, comments and tags, and of the file a block was read from.
+// The order of blocks and their values is taken into account, as it changes
+// the effective configuration.
+func Fingerprint(hosts []*SSHHost) string {
+	hash := sha256.New()
+	for _, h := range hosts {
+		writeFingerprint(hash, h)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Fingerprint returns a hash of the effective content of the config, see
+// Fingerprint.
+func (c *Config) Fingerprint() string {
+	return Fingerprint(c.hosts)
+}
+
+// writeFingerprint writes the block h, the blocks it is included from and
+// its values to w, separating fields by a NUL byte.
+func writeFingerprint(w io.Writer, h *SSHHost) {
+	write := func(fields ...string) {
+		for _, f := range fields {
+			io.WriteString(w, f)
+			io.WriteString(w, "\x00")
+		}
+	}
+
+	for p := h.parent; p != nil; p = p.parent {
+		write("in", blockKey(p))
+	}
+	write("block", blockKey(h))
+	for _, keyword := range keywords {
+		for _, value := range h.GetAll(keyword) {
+			write(keyword, value)
+		}
+	}
+	for _, keyword := range slices.Sorted(maps.Keys(h.Unknowns)) {
+		for _, value := range h.Unknowns[keyword] {
+			write(strings.ToLower(keyword), value)
+		}
+	}
+	write("end")
+}
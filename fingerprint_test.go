@@ -0,0 +1,41 @@
+package sshconfig
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := func(config string) string {
+		t.Helper()
+		resolver := mapResolver{"/ssh/web.conf": "Host web\n  User deploy\n"}
+		hosts, err := ParseString(config, "/ssh/config", WithIncludeResolver(resolver))
+		if err != nil {
+			t.Fatalf("unable to parse config: %s", err.Error())
+		}
+		return Fingerprint(hosts)
+	}
+
+	base := fingerprint("Include web.conf\n\nHost *\n  ForwardAgent no\n  XVendor on\n")
+	if len(base) != 64 {
+		t.Errorf("expected SHA-256 hex digest, got %s", base)
+	}
+
+	for _, same := range []string{
+		"Include web.conf\n\nHost *\n  ForwardAgent no\n  XVendor on\n",
+		"# comment\ninclude   web.conf\nhost *\n\tforwardagent=no\n\tXVendor on\n",
+		"Host web\n  User deploy\n\nHost *\n  ForwardAgent no\n  XVendor on\n",
+	} {
+		if f := fingerprint(same); f != base {
+			t.Errorf("expected same fingerprint for:\n%s", same)
+		}
+	}
+
+	for _, changed := range []string{
+		"Include web.conf\n\nHost *\n  ForwardAgent yes\n  XVendor on\n",
+		"Include web.conf\n\nHost *\n  ForwardAgent no\n  XVendor off\n",
+		"Host *\n  ForwardAgent no\n  XVendor on\nInclude web.conf\n",
+		"Include web.conf\n",
+	} {
+		if f := fingerprint(changed); f == base {
+			t.Errorf("expected different fingerprint for:\n%s", changed)
+		}
+	}
+}
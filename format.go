@@ -0,0 +1,134 @@
+package sshconfig
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// canonicalKeywords maps lowercased keywords to their canonical spelling.
+var canonicalKeywords = func() map[string]string {
+	m := map[string]string{
+		"host":    "Host",
+		"match":   "Match",
+		"include": "Include",
+	}
+	for _, keyword := range keywords {
+		m[strings.ToLower(keyword)] = keyword
+	}
+	return m
+}()
+
+// formatLine is the kind of a line of a config being formatted.
+type formatLine int
+
+const (
+	formatBlank formatLine = iota
+	formatComment
+	formatHeader
+	formatDirective
+)
+
+// Format returns src in canonical form: known keywords in their canonical
+// spelling separated from their value by a single space, directives of Host
+// and Match blocks indented by two spaces, a blank line before each block
+// and no repeated or trailing blank lines. Values, comments and unknown
+// keywords are kept as written, so the formatted config has the same
+// meaning. Comments directly above a Host or Match line stay with it.
+//
+// Line endings are kept if src uses \r\n throughout. An error is returned if
+// src can not be parsed.
+func Format(src []byte) ([]byte, error) {
+	content := string(src)
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	kinds := make([]formatLine, len(lines))
+	directives := make([]string, len(lines))
+
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "#") {
+			kinds[i] = formatComment
+		}
+	}
+
+	lexer := lex(content)
+	defer lexer.drain()
+Lex:
+	for {
+		token := lexer.nextItem()
+		switch token.typ {
+		case itemEOF:
+			break Lex
+		case itemError:
+			return nil, newParseError(content, "", token, "", errors.New(token.val))
+		}
+
+		value := lexer.nextItem()
+		if value.typ != itemValue && value.typ != itemHostValue {
+			return nil, newParseError(content, "", value, token.val, valueError(token, value))
+		}
+
+		keyword := token.val
+		if canonical, ok := canonicalKeywords[strings.ToLower(keyword)]; ok {
+			keyword = canonical
+		}
+		kinds[token.line-1] = formatDirective
+		if token.typ == itemHost || token.typ == itemMatch {
+			kinds[token.line-1] = formatHeader
+		}
+		directives[token.line-1] = keyword + " " + strings.TrimRight(value.val, " \t\r")
+	}
+
+	var b bytes.Buffer
+	blank := true  // whether the last line written is blank, or nothing is
+	block := false // whether a Host or Match block has started
+	for i, l := range lines {
+		switch kinds[i] {
+		case formatBlank:
+			if !blank {
+				b.WriteByte('\n')
+				blank = true
+			}
+			continue
+		case formatHeader:
+			block = true
+			if !blank && (i == 0 || kinds[i-1] != formatComment) {
+				b.WriteByte('\n')
+			}
+			b.WriteString(directives[i])
+		case formatComment:
+			attachedComment := attached(kinds, i)
+			if attachedComment && !blank && (i == 0 || kinds[i-1] != formatComment) {
+				b.WriteByte('\n')
+			}
+			if block && !attachedComment {
+				b.WriteString("  ")
+			}
+			b.WriteString(strings.TrimSpace(l))
+		case formatDirective:
+			if block {
+				b.WriteString("  ")
+			}
+			b.WriteString(directives[i])
+		}
+		b.WriteByte('\n')
+		blank = false
+	}
+
+	out := bytes.TrimRight(b.Bytes(), "\n")
+	if len(out) == 0 {
+		return []byte{}, nil
+	}
+	out = append(out, '\n')
+	if n := strings.Count(content, "\n"); n > 0 && strings.Count(content, "\r\n") == n {
+		out = bytes.ReplaceAll(out, []byte("\n"), []byte("\r\n"))
+	}
+	return out, nil
+}
+
+// attached reports whether the comment on line i is part of a group of
+// comment lines directly above a Host or Match line.
+func attached(kinds []formatLine, i int) bool {
+	for ; i < len(kinds) && kinds[i] == formatComment; i++ {
+	}
+	return i < len(kinds) && kinds[i] == formatHeader
+}
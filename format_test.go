@@ -0,0 +1,97 @@
+package sshconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	src := `
+
+  # defaults for everything
+  serveraliveinterval=30
+
+
+# the web server
+# behind the proxy
+  host web   # production
+hostname   web.example.com
+	  USER deploy
+   # unknown keywords keep their spelling
+	  SomeOption  yes
+Host db
+  ProxyCommand  ssh -W %h:%p bastion
+  identityfile "~/.ssh/id db"   # comment
+
+
+match host *.internal exec "true"
+  User admin
+`
+	expected := `# defaults for everything
+ServerAliveInterval 30
+
+# the web server
+# behind the proxy
+Host web   # production
+  HostName web.example.com
+  User deploy
+  # unknown keywords keep their spelling
+  SomeOption yes
+
+Host db
+  ProxyCommand ssh -W %h:%p bastion
+  IdentityFile "~/.ssh/id db"   # comment
+
+Match host *.internal exec "true"
+  User admin
+`
+
+	formatted, err := Format([]byte(src))
+	if err != nil {
+		t.Fatalf("unable to format config: %s", err.Error())
+	}
+	if string(formatted) != expected {
+		t.Errorf("unexpected config:\n%s", formatted)
+	}
+
+	again, err := Format(formatted)
+	if err != nil {
+		t.Fatalf("unable to format config: %s", err.Error())
+	}
+	if string(again) != expected {
+		t.Errorf("formatting is not idempotent:\n%s", again)
+	}
+
+	before, err := parse(src, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	after, err := parse(string(formatted), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse formatted config: %s", err.Error())
+	}
+	compare(t, before, after)
+}
+
+func TestFormatLineEndings(t *testing.T) {
+	formatted, err := Format([]byte("Host web\r\nUser deploy\r\n\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("unable to format config: %s", err.Error())
+	}
+	if string(formatted) != "Host web\r\n  User deploy\r\n" {
+		t.Errorf("unexpected config: %q", formatted)
+	}
+
+	formatted, err = Format([]byte("\n\n"))
+	if err != nil || len(formatted) != 0 {
+		t.Errorf("unexpected result: %q, %v", formatted, err)
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	_, err := Format([]byte("Host web\n  HostName\n"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -1,5 +1,12 @@
 module github.com/mikkeloscar/sshconfig
 
-go 1.21
+go 1.23.0
 
-require github.com/mitchellh/go-homedir v1.1.0
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/mitchellh/go-homedir v1.1.0
+	golang.org/x/crypto v0.36.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.31.0 // indirect
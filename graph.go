@@ -0,0 +1,99 @@
+package sshconfig
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// JumpGraph is the topology of the jump hosts of a config, to visualize
+// bastion setups.
+type JumpGraph struct {
+	// Nodes are the aliases of the config followed by the jump hosts which
+	// are not an alias of the config, in order of appearance.
+	Nodes []string
+	// Edges are the hops between the nodes, without duplicates.
+	Edges []JumpEdge
+}
+
+// JumpEdge is a hop of a JumpGraph: From is reached through To.
+type JumpEdge struct {
+	From string
+	To   string
+	// Keyword is the keyword defining the hop, ProxyJump or ProxyCommand.
+	Keyword string
+}
+
+// JumpTopology returns the graph of the jump hosts of every alias of hosts,
+// which are looked up with opts. For a ProxyJump of several hops, the alias
+// is reached through the last hop, which is reached through the one before
+// it and so on. ProxyCommands are included if they run ssh -W or nc like
+// ProxyJump does, see ProxyCommandJump, other commands are left out.
+func JumpTopology(hosts []*SSHHost, opts ...LookupOption) *JumpGraph {
+	g := &JumpGraph{}
+	seen := map[string]bool{}
+	seenEdges := map[JumpEdge]bool{}
+	addNode := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			g.Nodes = append(g.Nodes, name)
+		}
+	}
+
+	aliases := (&Config{hosts: hosts}).Aliases()
+	for _, alias := range aliases {
+		addNode(alias)
+	}
+	for _, alias := range aliases {
+		h := Lookup(hosts, alias, opts...)
+		keyword := "ProxyJump"
+		hops, err := h.JumpHops()
+		if err != nil {
+			continue
+		}
+		if hops == nil && h.ProxyCommand != "" {
+			var ok bool
+			if hops, ok = ProxyCommandJump(h.ProxyCommand); !ok {
+				continue
+			}
+			keyword = "ProxyCommand"
+		}
+
+		from := alias
+		for i := len(hops) - 1; i >= 0; i-- {
+			addNode(hops[i].Host)
+			e := JumpEdge{From: from, To: hops[i].Host, Keyword: keyword}
+			if !seenEdges[e] {
+				seenEdges[e] = true
+				g.Edges = append(g.Edges, e)
+			}
+			from = hops[i].Host
+		}
+	}
+	return g
+}
+
+// JumpTopology returns the graph of the jump hosts, see JumpTopology.
+func (c *Config) JumpTopology(opts ...LookupOption) *JumpGraph {
+	return JumpTopology(c.hosts, opts...)
+}
+
+// DOT returns the graph in the DOT language of Graphviz, with edges pointing
+// from a host to the jump host it is reached through.
+func (g *JumpGraph) DOT() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph ssh {\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&buf, "  %s;\n", dotQuote(node))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&buf, "  %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Keyword))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// dotQuote returns s as quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
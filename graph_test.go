@@ -0,0 +1,39 @@
+package sshconfig
+
+import "testing"
+
+func TestJumpTopology(t *testing.T) {
+	config := `Host bastion
+  HostName bastion.example.com
+
+Host web db
+  ProxyJump bastion,admin@inner:2222
+
+Host legacy
+  ProxyCommand ssh -W %h:%p bastion
+
+Host local
+  ProxyCommand /usr/bin/corkscrew proxy 8080 %h %p
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := `digraph ssh {
+  "bastion";
+  "web";
+  "db";
+  "legacy";
+  "local";
+  "inner";
+  "web" -> "inner" [label="ProxyJump"];
+  "inner" -> "bastion" [label="ProxyJump"];
+  "db" -> "inner" [label="ProxyJump"];
+  "legacy" -> "bastion" [label="ProxyCommand"];
+}
+`
+	if dot := string(JumpTopology(hosts).DOT()); dot != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, dot)
+	}
+}
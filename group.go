@@ -0,0 +1,66 @@
+package sshconfig
+
+// Group is a named group of blocks, as returned by GroupBy.
+type Group struct {
+	Name  string
+	Hosts []*SSHHost
+}
+
+// GroupKey returns the name of the group of a block for GroupBy.
+type GroupKey func(*SSHHost) string
+
+// GroupBy returns hosts grouped by the name key gives them, for rendering
+// tree views of large configs. Groups are ordered by their first block and
+// keep the blocks in the order of hosts.
+func GroupBy(hosts []*SSHHost, key GroupKey) []Group {
+	var groups []Group
+	index := map[string]int{}
+	for _, h := range hosts {
+		name := key(h)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, Group{Name: name})
+		}
+		groups[i].Hosts = append(groups[i].Hosts, h)
+	}
+	return groups
+}
+
+// GroupByFile groups blocks by the file they were read from, which puts
+// the blocks of each included file in a group of its own.
+func GroupByFile() GroupKey {
+	return func(h *SSHHost) string {
+		return h.SourceFile
+	}
+}
+
+// GroupBySection groups blocks by their Section, named by a comment
+// between blocks like
+//
+//	# --- Work ---
+//
+// Blocks before the first section are in the group named "".
+func GroupBySection() GroupKey {
+	return func(h *SSHHost) string {
+		return h.Section
+	}
+}
+
+// GroupByTag groups blocks by their value of the tag key, set by annotation
+// comments like
+//
+//	# sshconfig: key=value
+//
+// Blocks without the tag are in the group named "".
+func GroupByTag(key string) GroupKey {
+	return func(h *SSHHost) string {
+		return h.Tags[key]
+	}
+}
+
+// GroupBy returns the blocks grouped by key, see GroupBy.
+func (c *Config) GroupBy(key GroupKey) []Group {
+	return GroupBy(c.hosts, key)
+}
@@ -0,0 +1,77 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func groupNames(groups []Group) map[string][]string {
+	names := map[string][]string{}
+	for _, g := range groups {
+		for _, h := range g.Hosts {
+			names[g.Name] = append(names[g.Name], h.Host...)
+		}
+	}
+	return names
+}
+
+func TestGroupBy(t *testing.T) {
+	config := `Host bastion
+  HostName bastion.example.com
+
+# ==========
+# Work
+# ==========
+
+# sshconfig: env=prod
+Host web
+  HostName web.example.com
+
+# sshconfig: env=staging
+Host ci
+  HostName ci.example.com
+
+### Home ###
+
+Host nas
+  HostName nas.local
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	groups := GroupBy(hosts, GroupBySection())
+	if len(groups) != 3 || groups[0].Name != "" || groups[1].Name != "Work" || groups[2].Name != "Home" {
+		t.Fatalf("unexpected groups: %v", groupNames(groups))
+	}
+	expected := map[string][]string{"": {"bastion"}, "Work": {"web", "ci"}, "Home": {"nas"}}
+	if names := groupNames(groups); !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected sections: %v", names)
+	}
+	if len(hosts[0].Comments) != 0 {
+		t.Errorf("expected section comments not to be block comments, got %q", hosts[0].Comments)
+	}
+
+	expected = map[string][]string{"prod": {"web"}, "staging": {"ci"}, "": {"bastion", "nas"}}
+	if names := groupNames(GroupBy(hosts, GroupByTag("env"))); !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected tag groups: %v", names)
+	}
+
+	groups = NewConfig(hosts).GroupBy(GroupByFile())
+	if len(groups) != 1 || groups[0].Name != "~/.ssh/config" || len(groups[0].Hosts) != 4 {
+		t.Errorf("unexpected file groups: %v", groupNames(groups))
+	}
+
+	out, err := Marshal(hosts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	reparsed, err := parse(string(out), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse output: %s", err)
+	}
+	if names := groupNames(GroupBy(reparsed, GroupBySection())); !reflect.DeepEqual(names, map[string][]string{"": {"bastion"}, "Work": {"web", "ci"}, "Home": {"nas"}}) {
+		t.Errorf("sections not kept:\n%s", out)
+	}
+}
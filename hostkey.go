@@ -0,0 +1,206 @@
+package sshconfig
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyCallback returns a callback verifying host keys against the
+// known_hosts files of host the way ssh does, see KnownHostsFiles. It can be
+// passed to ClientConfig and Dial.
+//
+// A key listed for the host or a certificate signed by one of its
+// @cert-authority keys is accepted and a @revoked key is rejected with
+// *knownhosts.RevokedError. Other keys are rejected with *knownhosts.KeyError,
+// whose Want lists the known keys if the key of the host changed and is
+// empty if the host is unknown. StrictHostKeyChecking decides about unknown
+// hosts: yes and ask, as there is no one to ask, reject them, accept-new
+// adds their key to the first UserKnownHostsFile, hashed if HashKnownHosts
+// is set, and no or off add it as well and also accept changed keys. With
+// NoHostAuthenticationForLocalhost any key of a loopback address is
+// accepted.
+//
+// If HostKeyAlias is set, keys are looked up and added under the alias
+// instead of the host name and port, like for a host reached through a
+// forwarded port, and certificates must name the alias as principal.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func HostKeyCallback(host *SSHHost) (ssh.HostKeyCallback, error) {
+	files, err := host.KnownHostsFiles()
+	if err != nil {
+		return nil, err
+	}
+	user, err := host.userKnownHostsFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &hostKeyChecker{
+		strict: strings.ToLower(string(host.StrictHostKeyChecking)),
+		hash:   host.HashKnownHosts.Bool(false),
+		local:  host.NoHostAuthenticationForLocalhost.Bool(false) && host.HostKeyAlias == "",
+		alias:  host.HostKeyAlias,
+	}
+	if len(user) > 0 {
+		c.userFile = user[0]
+	}
+	for _, f := range files {
+		entries, err := ParseKnownHosts(f)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		c.entries = append(c.entries, entries...)
+	}
+	return c.check, nil
+}
+
+// hostKeyChecker verifies host keys against known_hosts entries.
+type hostKeyChecker struct {
+	strict   string
+	hash     bool
+	local    bool
+	alias    string
+	userFile string
+
+	mu      sync.Mutex
+	entries []KnownHost
+}
+
+func (c *hostKeyChecker) check(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.local && isLoopback(remote) {
+		return nil
+	}
+	if c.alias != "" {
+		hostname = net.JoinHostPort(c.alias, "22")
+	}
+
+	host, port := splitHostPort(hostname)
+	var cas []ssh.PublicKey
+	var want []knownhosts.KnownKey
+	for _, e := range c.entries {
+		if !e.Matches(host, port) {
+			continue
+		}
+		known := knownhosts.KnownKey{Key: e.Key, Filename: e.File, Line: e.Line}
+		switch e.Marker {
+		case MarkerRevoked:
+			if sameKey(e.Key, key) || (isCert(key) && sameKey(e.Key, key.(*ssh.Certificate).SignatureKey)) {
+				return &knownhosts.RevokedError{Revoked: known}
+			}
+		case MarkerCertAuthority:
+			cas = append(cas, e.Key)
+		default:
+			if sameKey(e.Key, key) {
+				return nil
+			}
+			want = append(want, known)
+		}
+	}
+
+	if isCert(key) && len(cas) > 0 {
+		checker := &ssh.CertChecker{
+			IsHostAuthority: func(auth ssh.PublicKey, _ string) bool {
+				for _, ca := range cas {
+					if sameKey(ca, auth) {
+						return true
+					}
+				}
+				return false
+			},
+		}
+		if err := checker.CheckHostKey(hostname, remote, key); err == nil {
+			return nil
+		}
+	}
+
+	if len(want) > 0 {
+		if c.strict == "no" || c.strict == "off" {
+			return nil
+		}
+		return &knownhosts.KeyError{Want: want}
+	}
+
+	switch c.strict {
+	case "accept-new", "no", "off":
+		return c.add(hostname, key)
+	}
+	return &knownhosts.KeyError{}
+}
+
+// add appends key as the key of hostname to the user known_hosts file.
+func (c *hostKeyChecker) add(hostname string, key ssh.PublicKey) error {
+	if c.userFile == "" {
+		return nil
+	}
+
+	address := knownhosts.Normalize(hostname)
+	if c.hash {
+		address = knownhosts.HashHostname(address)
+	}
+	line := knownhosts.Line([]string{address}, key)
+
+	f, err := os.OpenFile(c.userFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	c.entries = append(c.entries, KnownHost{
+		Hosts: []string{address},
+		Key:   key,
+		File:  c.userFile,
+	})
+	return nil
+}
+
+// isLoopback reports whether remote is a loopback address.
+func isLoopback(remote net.Addr) bool {
+	if addr, ok := remote.(*net.TCPAddr); ok {
+		return addr.IP.IsLoopback()
+	}
+	return false
+}
+
+// splitHostPort splits an address as passed to a host key callback into
+// host and port, which is 22 if the address has none.
+func splitHostPort(addr string) (string, int) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, 22
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return host, 22
+	}
+	return host, p
+}
+
+func sameKey(a, b ssh.PublicKey) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
+
+func isCert(key ssh.PublicKey) bool {
+	_, ok := key.(*ssh.Certificate)
+	return ok
+}
@@ -0,0 +1,150 @@
+package sshconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestHostKeyCallback(t *testing.T) {
+	tmpdir := t.TempDir()
+	knownHosts := filepath.Join(tmpdir, "known_hosts")
+
+	key, line := testPublicKey(t)
+	other, _ := testPublicKey(t)
+	revoked, revokedLine := testPublicKey(t)
+	caPub, caPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+	caSigner, err := ssh.NewSignerFromKey(caPriv)
+	if err != nil {
+		t.Fatalf("unable to create signer: %s", err.Error())
+	}
+	ca, err := ssh.NewPublicKey(caPub)
+	if err != nil {
+		t.Fatalf("unable to create public key: %s", err.Error())
+	}
+	caLine := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(ca)))
+
+	content := "known.example.com " + line + "\n" +
+		"@revoked * " + revokedLine + "\n" +
+		"@cert-authority *.example.org " + caLine + "\n"
+	if err := os.WriteFile(knownHosts, []byte(content), 0600); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	cert := &ssh.Certificate{
+		Key:             other,
+		CertType:        ssh.HostCert,
+		ValidPrincipals: []string{"web.example.org"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatalf("unable to sign certificate: %s", err.Error())
+	}
+
+	callback := func(strict StrictHostKeyChecking) ssh.HostKeyCallback {
+		t.Helper()
+		h := &SSHHost{
+			UserKnownHostsFiles:   []string{knownHosts},
+			GlobalKnownHostsFiles: []string{"none"},
+			StrictHostKeyChecking: strict,
+		}
+		cb, err := HostKeyCallback(h)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return cb
+	}
+	remote := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 22}
+
+	cb := callback("yes")
+	if err := cb("known.example.com:22", remote, key); err != nil {
+		t.Errorf("known key: unexpected error: %s", err)
+	}
+	if err := cb("web.example.org:22", remote, cert); err != nil {
+		t.Errorf("certificate: unexpected error: %s", err)
+	}
+
+	var keyErr *knownhosts.KeyError
+	if err := cb("known.example.com:22", remote, other); !errors.As(err, &keyErr) || len(keyErr.Want) != 1 {
+		t.Errorf("changed key: expected key error with known key, got %v", err)
+	}
+	if err := cb("unknown.example.com:22", remote, key); !errors.As(err, &keyErr) || len(keyErr.Want) != 0 {
+		t.Errorf("unknown host: expected key error without known keys, got %v", err)
+	}
+	var revokedErr *knownhosts.RevokedError
+	if err := cb("known.example.com:22", remote, revoked); !errors.As(err, &revokedErr) || revokedErr.Revoked.Line != 2 {
+		t.Errorf("revoked key: expected revoked error, got %v", err)
+	}
+
+	if err := callback("no")("known.example.com:22", remote, other); err != nil {
+		t.Errorf("changed key with StrictHostKeyChecking no: unexpected error: %s", err)
+	}
+
+	cb = callback("accept-new")
+	if err := cb("new.example.com:2222", remote, other); err != nil {
+		t.Errorf("accept-new: unexpected error: %s", err)
+	}
+	if err := cb("new.example.com:2222", remote, key); !errors.As(err, &keyErr) || len(keyErr.Want) != 1 {
+		t.Errorf("accept-new: expected key error for changed key, got %v", err)
+	}
+	if err := callback("yes")("new.example.com:2222", remote, other); err != nil {
+		t.Errorf("accept-new: key was not added: %s", err)
+	}
+
+	local := &SSHHost{
+		UserKnownHostsFiles:              []string{knownHosts},
+		GlobalKnownHostsFiles:            []string{"none"},
+		NoHostAuthenticationForLocalhost: TriBoolYes,
+	}
+	cb, err = HostKeyCallback(local)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	loopback := &net.TCPAddr{IP: net.IPv6loopback, Port: 2222}
+	if err := cb("localhost:2222", loopback, other); err != nil {
+		t.Errorf("localhost: unexpected error: %s", err)
+	}
+	if err := cb("unknown.example.com:22", remote, key); !errors.As(err, &keyErr) {
+		t.Errorf("remote host: expected key error, got %v", err)
+	}
+
+	// a forwarded port verified against the key of the alias
+	local.HostKeyAlias = "known.example.com"
+	local.StrictHostKeyChecking = StrictHostKeyCheckingAcceptNew
+	cb, err = HostKeyCallback(local)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := cb("localhost:2222", loopback, key); err != nil {
+		t.Errorf("alias: unexpected error: %s", err)
+	}
+	if err := cb("localhost:2222", loopback, other); !errors.As(err, &keyErr) || len(keyErr.Want) != 1 {
+		t.Errorf("alias: expected key error with known key, got %v", err)
+	}
+	local.HostKeyAlias = "fresh.example.com"
+	cb, err = HostKeyCallback(local)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := cb("localhost:2222", loopback, other); err != nil {
+		t.Errorf("new alias: unexpected error: %s", err)
+	}
+	added, err := os.ReadFile(knownHosts)
+	if err != nil {
+		t.Fatalf("unable to read file: %s", err.Error())
+	}
+	if !strings.Contains(string(added), "\nfresh.example.com ssh-ed25519 ") {
+		t.Errorf("expected the key to be added for the alias, got:\n%s", added)
+	}
+}
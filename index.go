@@ -0,0 +1,85 @@
+package sshconfig
+
+// HostIndex maps aliases to the blocks which may apply to them, so lookups
+// in configs with many hosts don't need to check every block. The index does
+// not follow changes to the hosts it was built from.
+type HostIndex struct {
+	hosts []*SSHHost
+	// aliases holds the positions of the blocks naming an alias literally
+	aliases map[string][]int
+	// patterns holds the positions of Match blocks and blocks with a pattern,
+	// which have to be checked for every alias
+	patterns []int
+}
+
+// NewHostIndex builds the index for hosts.
+func NewHostIndex(hosts []*SSHHost) *HostIndex {
+	x := &HostIndex{
+		hosts:   hosts,
+		aliases: map[string][]int{},
+	}
+
+	for i, h := range hosts {
+		if h.Match != nil || hasPattern(h.Host) {
+			x.patterns = append(x.patterns, i)
+			continue
+		}
+
+		for _, alias := range h.Host {
+			positions := x.aliases[alias]
+			if len(positions) > 0 && positions[len(positions)-1] == i {
+				continue
+			}
+			x.aliases[alias] = append(positions, i)
+		}
+	}
+
+	return x
+}
+
+// Hosts returns the blocks which may apply to alias in file order. These are
+// the blocks naming alias and all Match and pattern blocks.
+func (x *HostIndex) Hosts(alias string) []*SSHHost {
+	literal := x.aliases[alias]
+	hosts := make([]*SSHHost, 0, len(literal)+len(x.patterns))
+
+	// merge both position lists to keep file order
+	i, j := 0, 0
+	for i < len(literal) || j < len(x.patterns) {
+		if j == len(x.patterns) || (i < len(literal) && literal[i] < x.patterns[j]) {
+			hosts = append(hosts, x.hosts[literal[i]])
+			i++
+		} else {
+			hosts = append(hosts, x.hosts[x.patterns[j]])
+			j++
+		}
+	}
+
+	return hosts
+}
+
+// Lookup returns the effective configuration for alias, see Lookup.
+func (x *HostIndex) Lookup(alias string, opts ...LookupOption) *SSHHost {
+	options := &lookupOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// the canonical name and the HostName blocks are matched against are
+	// only known while looking up
+	if options.resolver != nil || options.matchHostName {
+		return Lookup(x.hosts, alias, opts...)
+	}
+
+	return Lookup(x.Hosts(alias), alias, opts...)
+}
+
+// hasPattern reports whether any of hosts is a pattern.
+func hasPattern(hosts []string) bool {
+	for _, h := range hosts {
+		if isPattern(h) {
+			return true
+		}
+	}
+	return false
+}
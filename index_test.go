@@ -0,0 +1,83 @@
+package sshconfig
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestHostIndex(t *testing.T) {
+	config := `Host *
+  User nobody
+
+Host web web.example.com
+  HostName web.example.com
+
+Match tagged prod
+  User deploy
+
+Host db
+  HostName db.example.com
+
+Host *.example.com !db.example.com
+  Port 2222
+
+Host web
+  IdentityFile ~/.ssh/web`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	index := NewHostIndex(hosts)
+
+	candidates := index.Hosts("web")
+	expected := []*SSHHost{hosts[0], hosts[1], hosts[2], hosts[4], hosts[5]}
+	if !reflect.DeepEqual(expected, candidates) {
+		t.Errorf("unexpected candidates for web: %v", candidates)
+	}
+
+	for _, alias := range []string{"web", "web.example.com", "db", "db.example.com", "other"} {
+		for _, opts := range [][]LookupOption{nil, {WithTags("prod")}, {WithOpenSSHPrecedence()}} {
+			expected := Lookup(hosts, alias, opts...)
+			if actual := index.Lookup(alias, opts...); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("%s: expected %+v, got %+v", alias, expected, actual)
+			}
+		}
+	}
+}
+
+func BenchmarkHostIndexLookup(b *testing.B) {
+	hosts, err := parse(generateConfig(10000), "~/.ssh/config")
+	if err != nil {
+		b.Fatal(err)
+	}
+	index := NewHostIndex(hosts)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		index.Lookup(fmt.Sprintf("node-%d", i%10000))
+	}
+}
+
+func TestHostIndexHostNameMatching(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+
+Host web.example.com
+  User deploy`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := Lookup(hosts, "web", WithHostNameMatching())
+	if expected.User != "deploy" {
+		t.Fatalf("expected the HostName block to apply, got user %q", expected.User)
+	}
+	if actual := NewHostIndex(hosts).Lookup("web", WithHostNameMatching()); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
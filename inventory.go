@@ -0,0 +1,166 @@
+package sshconfig
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InventoryHost is a host record of an inventory, like an instance listed by
+// a cloud provider, to generate a Host block for.
+type InventoryHost struct {
+	// Aliases are the patterns of the Host line, at least one is required.
+	Aliases      []string `json:"aliases" yaml:"aliases"`
+	HostName     string   `json:"hostName,omitempty" yaml:"hostName,omitempty"`
+	User         string   `json:"user,omitempty" yaml:"user,omitempty"`
+	Port         int      `json:"port,omitempty" yaml:"port,omitempty"`
+	IdentityFile string   `json:"identityFile,omitempty" yaml:"identityFile,omitempty"`
+	ProxyJump    string   `json:"proxyJump,omitempty" yaml:"proxyJump,omitempty"`
+	// Options are any other keywords with their value as written in a
+	// config file.
+	Options map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// FromInventory returns a Host block for each record, built with
+// HostBuilder. Defaults are keywords with their value as written in a
+// config file, which are set in every block not setting the keyword itself.
+// Defaults are repeated in each block rather than written as `Host *`, so
+// the generated blocks don't change other hosts when added to an existing
+// config.
+func FromInventory(records []InventoryHost, defaults map[string]string) ([]*SSHHost, error) {
+	var errs []error
+	hosts := make([]*SSHHost, 0, len(records))
+	for i, r := range records {
+		h, err := r.build(defaults)
+		if err != nil {
+			name := strings.Join(r.Aliases, " ")
+			if name == "" {
+				name = "record " + strconv.Itoa(i+1)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		hosts = append(hosts, h)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// GenerateConfig returns the config generated from records and defaults by
+// FromInventory, written by Marshal.
+func GenerateConfig(records []InventoryHost, defaults map[string]string, opts ...WriteOption) ([]byte, error) {
+	hosts, err := FromInventory(records, defaults)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(hosts, opts...)
+}
+
+// build returns the Host block of the record.
+func (r InventoryHost) build(defaults map[string]string) (*SSHHost, error) {
+	b := NewHostBuilder(r.Aliases...)
+	set := map[string]bool{}
+	if r.HostName != "" {
+		b.HostName(r.HostName)
+		set["hostname"] = true
+	}
+	if r.User != "" {
+		b.User(r.User)
+		set["user"] = true
+	}
+	if r.Port != 0 {
+		b.Port(r.Port)
+		set["port"] = true
+	}
+	if r.IdentityFile != "" {
+		b.IdentityFile(r.IdentityFile)
+		set["identityfile"] = true
+	}
+	if r.ProxyJump != "" {
+		b.ProxyJump(r.ProxyJump)
+		set["proxyjump"] = true
+	}
+	for _, keyword := range sortedKeys(r.Options) {
+		b.Set(keyword, r.Options[keyword])
+		set[strings.ToLower(keyword)] = true
+	}
+	for _, keyword := range sortedKeys(defaults) {
+		if !set[strings.ToLower(keyword)] {
+			b.Set(keyword, defaults[keyword])
+		}
+	}
+	return b.Build()
+}
+
+// sortedKeys returns the keys of m in sorted order, so blocks are built
+// deterministically.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ReadInventoryCSV reads host records from CSV with a header line. The
+// columns alias, hostname, user, port, identityfile and proxyjump, matched
+// case-insensitively, fill the fields of the same name, where alias may
+// list several aliases separated by spaces. Other columns are keywords
+// stored in Options. Empty cells are left out.
+func ReadInventoryCSV(r io.Reader) ([]InventoryHost, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []InventoryHost
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record InventoryHost
+		for i, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			switch column := strings.TrimSpace(header[i]); strings.ToLower(column) {
+			case "alias":
+				record.Aliases = strings.Fields(cell)
+			case "hostname":
+				record.HostName = cell
+			case "user":
+				record.User = cell
+			case "port":
+				line, _ := cr.FieldPos(i)
+				if record.Port, err = strconv.Atoi(cell); err != nil {
+					return nil, fmt.Errorf("line %d: invalid port: %#v", line, cell)
+				}
+			case "identityfile":
+				record.IdentityFile = cell
+			case "proxyjump":
+				record.ProxyJump = cell
+			default:
+				if record.Options == nil {
+					record.Options = map[string]string{}
+				}
+				record.Options[column] = cell
+			}
+		}
+		records = append(records, record)
+	}
+}
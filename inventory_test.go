@@ -0,0 +1,91 @@
+package sshconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	records := []InventoryHost{
+		{
+			Aliases:  []string{"web", "web-1"},
+			HostName: "10.0.0.1",
+			User:     "deploy",
+			Options:  map[string]string{"ServerAliveInterval": "30", "Tag": "prod"},
+		},
+		{
+			Aliases:      []string{"db"},
+			HostName:     "10.0.0.2",
+			Port:         2222,
+			IdentityFile: "~/.ssh/db key",
+			ProxyJump:    "bastion",
+			Options:      map[string]string{"serveraliveinterval": "60"},
+		},
+	}
+	defaults := map[string]string{
+		"User":                "admin",
+		"ServerAliveInterval": "15",
+	}
+
+	config, err := GenerateConfig(records, defaults)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `Host web web-1
+  HostName 10.0.0.1
+  User deploy
+  Tag prod
+  ServerAliveInterval 30
+
+Host db
+  HostName 10.0.0.2
+  User admin
+  Port 2222
+  IdentityFile "~/.ssh/db key"
+  ProxyJump bastion
+  ServerAliveInterval 1m
+`
+	if string(config) != expected {
+		t.Errorf("expected config:\n%s\ngot:\n%s", expected, config)
+	}
+
+	_, err = FromInventory([]InventoryHost{{HostName: "10.0.0.3"}, {Aliases: []string{"bad"}, Port: 70000}}, nil)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, msg := range []string{"record 1: Host requires at least one pattern", "bad: Port out of range: 70000"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error to contain %q, got %q", msg, err)
+		}
+	}
+}
+
+func TestReadInventoryCSV(t *testing.T) {
+	input := `alias,HostName,user,port,ForwardAgent
+web web-1,10.0.0.1,deploy,,yes
+db,10.0.0.2,,5432,
+`
+	records, err := ReadInventoryCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	web := records[0]
+	if strings.Join(web.Aliases, " ") != "web web-1" || web.HostName != "10.0.0.1" || web.User != "deploy" || web.Port != 0 {
+		t.Errorf("unexpected record: %+v", web)
+	}
+	if web.Options["ForwardAgent"] != "yes" {
+		t.Errorf("expected ForwardAgent option, got %v", web.Options)
+	}
+	if db := records[1]; db.Port != 5432 || db.Options != nil {
+		t.Errorf("unexpected record: %+v", db)
+	}
+
+	if _, err := ReadInventoryCSV(strings.NewReader("alias,port\nweb,ssh\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error on line 2, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package sshconfig
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ResolveJumpChain follows the ProxyJump of alias through hosts and returns
+// the resolved hosts to connect through in order, starting with the first
+// hop. Like ssh, the ProxyJump of the first hop is followed as well, while
+// the ProxyJump of later hops is not used. User and port given in a hop
+// override the ones configured for it.
+//
+// Hops not named by a Host block, like `ProxyJump bastion.example.com` or
+// `ProxyJump admin@10.0.0.1:2222`, are taken as host names, with the
+// values of `Host *` and Match blocks applied, like ssh does. An error is
+// returned if a hop can't be parsed or the chain contains a cycle.
+func ResolveJumpChain(hosts []*SSHHost, alias string, opts ...LookupOption) ([]*SSHHost, error) {
+	return resolveJumpChain(hosts, alias, opts, []string{alias})
+}
+
+// ResolveJumpChain returns the jump chain for alias, see ResolveJumpChain.
+func (c *Config) ResolveJumpChain(alias string, opts ...LookupOption) ([]*SSHHost, error) {
+	return ResolveJumpChain(c.hosts, alias, opts...)
+}
+
+// resolveJumpChain resolves the chain of alias, path holds the aliases
+// resolved so far to detect cycles.
+func resolveJumpChain(hosts []*SSHHost, alias string, opts []LookupOption, path []string) ([]*SSHHost, error) {
+	hops, err := Lookup(hosts, alias, opts...).JumpHops()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", alias, err)
+	}
+
+	var chain []*SSHHost
+	for i, hop := range hops {
+		for _, p := range path {
+			if p == hop.Host {
+				return nil, fmt.Errorf("%s: jump host cycle: %s -> %s", alias, strings.Join(path, " -> "), hop.Host)
+			}
+		}
+
+		if i == 0 {
+			prefix, err := resolveJumpChain(hosts, hop.Host, opts, append(path[:len(path):len(path)], hop.Host))
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, prefix...)
+		}
+
+		resolved := Lookup(hosts, hop.Host, opts...)
+		if hop.User != "" {
+			resolved.User = hop.User
+		}
+		if hop.Port != 0 {
+			resolved.Port = hop.Port
+		}
+		chain = append(chain, resolved)
+	}
+
+	return chain, nil
+}
+
+// JumpHop is a single hop of a ProxyJump, written as [user@]host[:port].
+// User and Port are empty if not given.
+type JumpHop struct {
+	User string
+	Host string
+	Port int
+}
+
+// ParseJumpHop parses a hop given as [user@]host[:port] or
+// ssh://[user@]host[:port]. IPv6 addresses are given in brackets when
+// followed by a port.
+func ParseJumpHop(spec string) (JumpHop, error) {
+	var hop JumpHop
+
+	s := strings.TrimPrefix(strings.TrimSpace(spec), "ssh://")
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		hop.User, s = s[:i], s[i+1:]
+	}
+
+	hop.Host = s
+	if host, port, err := net.SplitHostPort(s); err == nil {
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 || p > 65535 {
+			return JumpHop{}, fmt.Errorf("invalid jump host port: %#v", spec)
+		}
+		hop.Host, hop.Port = host, p
+	} else if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		hop.Host = s[1 : len(s)-1]
+	}
+
+	if hop.Host == "" {
+		return JumpHop{}, fmt.Errorf("invalid jump host: %#v", spec)
+	}
+
+	return hop, nil
+}
+
+// ParseProxyJump parses the value of a ProxyJump directive, a comma
+// separated list of hops. It returns no hops for none.
+func ParseProxyJump(value string) ([]JumpHop, error) {
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+
+	var hops []JumpHop
+	for _, spec := range strings.Split(value, ",") {
+		hop, err := ParseJumpHop(spec)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, hop)
+	}
+	return hops, nil
+}
+
+// String returns the hop as written in a ProxyJump directive.
+func (j JumpHop) String() string {
+	s := j.Host
+	if j.Port != 0 {
+		s = net.JoinHostPort(j.Host, strconv.Itoa(j.Port))
+	} else if strings.Contains(j.Host, ":") {
+		s = "[" + j.Host + "]"
+	}
+	if j.User != "" {
+		s = j.User + "@" + s
+	}
+	return s
+}
+
+// JumpHops returns the hops of the ProxyJump of the host. The raw ProxyJump
+// is kept as written so it is written back unchanged.
+func (h *SSHHost) JumpHops() ([]JumpHop, error) {
+	return ParseProxyJump(h.ProxyJump)
+}
@@ -0,0 +1,138 @@
+package sshconfig
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResolveJumpChain(t *testing.T) {
+	config := `Host web
+  HostName web.internal
+  ProxyJump deploy@bastion:2222,gateway
+
+Host gateway
+  HostName gateway.internal
+  ProxyJump edge
+
+Host bastion
+  HostName bastion.example.com
+  User admin
+  ProxyJump edge
+
+Host edge
+  HostName edge.example.com
+
+Host loop1
+  ProxyJump loop2
+
+Host loop2
+  ProxyJump loop1
+
+Host direct
+  ProxyJump bastion.example.com,admin@10.0.0.1:2222
+
+Host *
+  User nobody`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	chain, err := ResolveJumpChain(hosts, "web")
+	if err != nil {
+		t.Fatalf("unable to resolve chain: %s", err)
+	}
+
+	var hops []string
+	for _, h := range chain {
+		hops = append(hops, h.User+"@"+h.HostName+":"+strconv.Itoa(h.Port))
+	}
+	expected := "nobody@edge.example.com:22 deploy@bastion.example.com:2222 nobody@gateway.internal:22"
+	if strings.Join(hops, " ") != expected {
+		t.Errorf("unexpected chain: %v", hops)
+	}
+
+	if chain, err := ResolveJumpChain(hosts, "edge"); err != nil || len(chain) != 0 {
+		t.Errorf("expected empty chain for edge, got %v, %v", chain, err)
+	}
+
+	_, err = ResolveJumpChain(hosts, "loop1")
+	if err == nil || err.Error() != "loop2: jump host cycle: loop1 -> loop2 -> loop1" {
+		t.Errorf("unexpected error for cycle: %v", err)
+	}
+
+	chain, err = ResolveJumpChain(hosts, "direct")
+	if err != nil {
+		t.Fatalf("unable to resolve chain: %s", err)
+	}
+	hops = nil
+	for _, h := range chain {
+		hops = append(hops, h.User+"@"+h.HostName+":"+strconv.Itoa(h.Port))
+	}
+	expected = "nobody@bastion.example.com:22 admin@10.0.0.1:2222"
+	if strings.Join(hops, " ") != expected {
+		t.Errorf("unexpected chain for hops not in the config: %v", hops)
+	}
+
+	// the parser rejects invalid hops, hosts may be built without it
+	hosts = append([]*SSHHost{{Host: []string{"invalid"}, ProxyJump: "bastion:99999"}}, hosts...)
+	_, err = ResolveJumpChain(hosts, "invalid")
+	if err == nil || err.Error() != `invalid: invalid jump host port: "bastion:99999"` {
+		t.Errorf("unexpected error for invalid hop: %v", err)
+	}
+}
+
+func TestParseJumpHop(t *testing.T) {
+	for _, tc := range []struct {
+		spec     string
+		expected JumpHop
+	}{
+		{"bastion", JumpHop{Host: "bastion"}},
+		{"me@bastion:2222", JumpHop{User: "me", Host: "bastion", Port: 2222}},
+		{"ssh://me@[::1]:22", JumpHop{User: "me", Host: "::1", Port: 22}},
+		{"[fe80::1]", JumpHop{Host: "fe80::1"}},
+	} {
+		hop, err := ParseJumpHop(tc.spec)
+		if err != nil || hop != tc.expected {
+			t.Errorf("ParseJumpHop(%q): expected %+v, got %+v, %v", tc.spec, tc.expected, hop, err)
+		}
+	}
+
+	for _, spec := range []string{"me@", "bastion:0", "bastion:ssh"} {
+		if _, err := ParseJumpHop(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestJumpHops(t *testing.T) {
+	config := `Host web
+  ProxyJump ssh://me@[::1]:2222,bastion,[fe80::1]
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	hops, err := hosts[0].JumpHops()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var specs []string
+	for _, hop := range hops {
+		specs = append(specs, hop.String())
+	}
+	if strings.Join(specs, ",") != "me@[::1]:2222,bastion,[fe80::1]" {
+		t.Errorf("unexpected hops: %v", specs)
+	}
+	if hosts[0].ProxyJump != "ssh://me@[::1]:2222,bastion,[fe80::1]" {
+		t.Errorf("expected raw ProxyJump to be kept, got %s", hosts[0].ProxyJump)
+	}
+
+	_, err = parse("Host web\n  ProxyJump bastion,,gateway\n", "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:2:13: invalid jump host: ""` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
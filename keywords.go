@@ -0,0 +1,427 @@
+package sshconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keywords lists the keywords known to the parser which can be set on a
+// host, in their canonical spelling.
+var keywords = []string{
+	"HostName",
+	"User",
+	"Port",
+	"ProxyCommand",
+	"HostKeyAlgorithms",
+	"IdentityFile",
+	"CertificateFile",
+	"LocalForward",
+	"RemoteForward",
+	"DynamicForward",
+	"Ciphers",
+	"MACs",
+	"Tag",
+	"ProxyJump",
+	"ForwardAgent",
+	"ControlMaster",
+	"ControlPath",
+	"ControlPersist",
+	"ServerAliveInterval",
+	"ServerAliveCountMax",
+	"StrictHostKeyChecking",
+	"UserKnownHostsFile",
+	"GlobalKnownHostsFile",
+	"PreferredAuthentications",
+	"PubkeyAuthentication",
+	"PasswordAuthentication",
+	"KbdInteractiveAuthentication",
+	"KexAlgorithms",
+	"PubkeyAcceptedAlgorithms",
+	"SendEnv",
+	"SetEnv",
+	"LocalCommand",
+	"PermitLocalCommand",
+	"RemoteCommand",
+	"RequestTTY",
+	"SessionType",
+	"ForwardX11",
+	"ForwardX11Trusted",
+	"ForwardX11Timeout",
+	"CanonicalizeHostname",
+	"CanonicalDomains",
+	"CanonicalizeMaxDots",
+	"CanonicalizeFallbackLocal",
+	"CanonicalizePermittedCNAMEs",
+	"HashKnownHosts",
+	"CheckHostIP",
+	"VerifyHostKeyDNS",
+	"TCPKeepAlive",
+	"Tunnel",
+	"TunnelDevice",
+	"GatewayPorts",
+	"ExitOnForwardFailure",
+	"ClearAllForwardings",
+	"LogLevel",
+	"SyslogFacility",
+	"BatchMode",
+	"NumberOfPasswordPrompts",
+	"EscapeChar",
+	"EnableEscapeCommandline",
+	"PKCS11Provider",
+	"SecurityKeyProvider",
+	"GSSAPIAuthentication",
+	"GSSAPIDelegateCredentials",
+	"GSSAPIKeyExchange",
+	"GSSAPITrustDns",
+	"GSSAPIClientIdentity",
+	"GSSAPIServerIdentity",
+	"RekeyLimit",
+	"UpdateHostKeys",
+	"IgnoreUnknown",
+	"PermitOpen",
+	"PermitRemoteOpen",
+	"IdentityAgent",
+	"ConnectTimeout",
+	"AddKeysToAgent",
+	"KnownHostsCommand",
+	"FingerprintHash",
+	"RequiredRSASize",
+	"ChannelTimeout",
+	"ObscureKeystrokeTiming",
+	"StdinNull",
+	"ForkAfterAuthentication",
+	"ProxyUseFdpass",
+	"StreamLocalBindMask",
+	"StreamLocalBindUnlink",
+	"HostbasedAuthentication",
+	"HostbasedAcceptedAlgorithms",
+	"NoHostAuthenticationForLocalhost",
+	"HostKeyAlias",
+}
+
+// Get returns the value of keyword for the host and whether it is set.
+// Keywords are matched case-insensitively and may be any keyword known to
+// the parser or any unknown keyword recorded in Unknowns. For keywords given
+// multiple times the first value is returned, see GetAll.
+func (h *SSHHost) Get(keyword string) (string, bool) {
+	values := h.GetAll(keyword)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// GetAll returns all values of keyword for the host in the form they are
+// written in a config file.
+func (h *SSHHost) GetAll(keyword string) []string {
+	typ, ok := variables[strings.ToLower(keyword)]
+	if !ok {
+		typ = itemUnknown
+	}
+
+	switch typ {
+	case itemTag:
+		return nonEmpty(h.Tag)
+	case itemHostName:
+		return nonEmpty(h.HostName)
+	case itemUser:
+		return nonEmpty(h.User)
+	case itemPort:
+		if h.Port == 0 {
+			return nil
+		}
+		return []string{strconv.Itoa(h.Port)}
+	case itemProxyCommand:
+		return nonEmpty(h.ProxyCommand)
+	case itemHostKeyAlgorithms:
+		return joinedList(h.HostKeyAlgorithms)
+	case itemIdentityFile:
+		return h.IdentityFiles
+	case itemCertificateFile:
+		return h.CertificateFiles
+	case itemLocalForward:
+		return stringValues(h.LocalForwards)
+	case itemRemoteForward:
+		return stringValues(h.RemoteForwards)
+	case itemDynamicForward:
+		return stringValues(h.DynamicForwards)
+	case itemCiphers:
+		return joinedList(h.Ciphers)
+	case itemMACs:
+		return joinedList(h.MACs)
+	case itemProxyJump:
+		return nonEmpty(h.ProxyJump)
+	case itemForwardAgent:
+		return nonEmpty(h.ForwardAgent)
+	case itemControlMaster:
+		return nonEmpty(string(h.ControlMaster))
+	case itemControlPath:
+		return nonEmpty(h.ControlPath)
+	case itemControlPersist:
+		return nonEmpty(h.ControlPersist)
+	case itemServerAliveInterval:
+		return nonZeroDuration(h.ServerAliveInterval)
+	case itemServerAliveCountMax:
+		return nonZero(h.ServerAliveCountMax)
+	case itemStrictHostKeyChecking:
+		return nonEmpty(string(h.StrictHostKeyChecking))
+	case itemUserKnownHostsFile:
+		return spacedList(h.UserKnownHostsFiles)
+	case itemGlobalKnownHostsFile:
+		return spacedList(h.GlobalKnownHostsFiles)
+	case itemPreferredAuthentications:
+		return joinedList(h.PreferredAuthentications)
+	case itemPubkeyAuthentication:
+		return nonEmpty(h.PubkeyAuthentication)
+	case itemPasswordAuthentication:
+		return nonEmpty(string(h.PasswordAuthentication))
+	case itemKbdInteractiveAuthentication:
+		return nonEmpty(string(h.KbdInteractiveAuthentication))
+	case itemKexAlgorithms:
+		return joinedList(h.KexAlgorithms)
+	case itemPubkeyAcceptedAlgorithms:
+		return joinedList(h.PubkeyAcceptedAlgorithms)
+	case itemSendEnv:
+		return h.SendEnv
+	case itemSetEnv:
+		var values []string
+		for name, v := range h.SetEnv {
+			values = append(values, name+"="+v)
+		}
+		sort.Strings(values)
+		return values
+	case itemLocalCommand:
+		return nonEmpty(h.LocalCommand)
+	case itemPermitLocalCommand:
+		return nonEmpty(string(h.PermitLocalCommand))
+	case itemRemoteCommand:
+		return nonEmpty(h.RemoteCommand)
+	case itemRequestTTY:
+		return nonEmpty(string(h.RequestTTY))
+	case itemSessionType:
+		return nonEmpty(string(h.SessionType))
+	case itemForwardX11:
+		return nonEmpty(string(h.ForwardX11))
+	case itemForwardX11Trusted:
+		return nonEmpty(string(h.ForwardX11Trusted))
+	case itemForwardX11Timeout:
+		return nonZeroDuration(h.ForwardX11Timeout)
+	case itemCanonicalizeHostname:
+		return nonEmpty(h.CanonicalizeHostname)
+	case itemCanonicalDomains:
+		return spacedList(h.CanonicalDomains)
+	case itemCanonicalizeMaxDots:
+		if h.CanonicalizeMaxDotsSet {
+			return []string{strconv.Itoa(h.CanonicalizeMaxDots)}
+		}
+		return nonZero(h.CanonicalizeMaxDots)
+	case itemCanonicalizeFallbackLocal:
+		return nonEmpty(string(h.CanonicalizeFallbackLocal))
+	case itemCanonicalizePermittedCNAMEs:
+		return spacedList(h.CanonicalizePermittedCNAMEs)
+	case itemHashKnownHosts:
+		return nonEmpty(string(h.HashKnownHosts))
+	case itemCheckHostIP:
+		return nonEmpty(string(h.CheckHostIP))
+	case itemVerifyHostKeyDNS:
+		return nonEmpty(h.VerifyHostKeyDNS)
+	case itemTCPKeepAlive:
+		return nonEmpty(string(h.TCPKeepAlive))
+	case itemTunnel:
+		return nonEmpty(string(h.Tunnel))
+	case itemTunnelDevice:
+		return nonEmpty(h.TunnelDevice)
+	case itemGatewayPorts:
+		return nonEmpty(string(h.GatewayPorts))
+	case itemExitOnForwardFailure:
+		return nonEmpty(string(h.ExitOnForwardFailure))
+	case itemClearAllForwardings:
+		return nonEmpty(string(h.ClearAllForwardings))
+	case itemLogLevel:
+		return nonEmpty(h.LogLevel)
+	case itemSyslogFacility:
+		return nonEmpty(h.SyslogFacility)
+	case itemBatchMode:
+		return nonEmpty(string(h.BatchMode))
+	case itemNumberOfPasswordPrompts:
+		return nonZero(h.NumberOfPasswordPrompts)
+	case itemEscapeChar:
+		return nonEmpty(h.EscapeChar)
+	case itemEnableEscapeCommandline:
+		return nonEmpty(string(h.EnableEscapeCommandline))
+	case itemPKCS11Provider:
+		return nonEmpty(h.PKCS11Provider)
+	case itemSecurityKeyProvider:
+		return nonEmpty(h.SecurityKeyProvider)
+	case itemGSSAPIAuthentication:
+		return nonEmpty(string(h.GSSAPIAuthentication))
+	case itemGSSAPIDelegateCredentials:
+		return nonEmpty(string(h.GSSAPIDelegateCredentials))
+	case itemGSSAPIKeyExchange:
+		return nonEmpty(string(h.GSSAPIKeyExchange))
+	case itemGSSAPITrustDns:
+		return nonEmpty(string(h.GSSAPITrustDns))
+	case itemGSSAPIClientIdentity:
+		return nonEmpty(h.GSSAPIClientIdentity)
+	case itemGSSAPIServerIdentity:
+		return nonEmpty(h.GSSAPIServerIdentity)
+	case itemRekeyLimit:
+		if h.RekeyLimit == nil {
+			return nil
+		}
+		return []string{h.RekeyLimit.String()}
+	case itemUpdateHostKeys:
+		return nonEmpty(string(h.UpdateHostKeys))
+	case itemIgnoreUnknown:
+		return joinedList(h.IgnoreUnknown)
+	case itemPermitOpen:
+		return spacedList(h.PermitOpen)
+	case itemPermitRemoteOpen:
+		return spacedList(h.PermitRemoteOpen)
+	case itemIdentityAgent:
+		return nonEmpty(h.IdentityAgent)
+	case itemConnectTimeout:
+		return nonZeroDuration(h.ConnectTimeout)
+	case itemAddKeysToAgent:
+		return nonEmpty(string(h.AddKeysToAgent))
+	case itemKnownHostsCommand:
+		return nonEmpty(h.KnownHostsCommand)
+	case itemFingerprintHash:
+		return nonEmpty(string(h.FingerprintHash))
+	case itemRequiredRSASize:
+		return nonZero(h.RequiredRSASize)
+	case itemChannelTimeout:
+		if h.ChannelTimeouts == nil {
+			return nil
+		}
+		if len(h.ChannelTimeouts) == 0 {
+			return []string{"none"}
+		}
+		return []string{strings.Join(stringValues(h.ChannelTimeouts), " ")}
+	case itemObscureKeystrokeTiming:
+		return nonEmpty(h.ObscureKeystrokeTiming)
+	case itemStdinNull:
+		return nonEmpty(string(h.StdinNull))
+	case itemForkAfterAuthentication:
+		return nonEmpty(string(h.ForkAfterAuthentication))
+	case itemProxyUseFdpass:
+		return nonEmpty(string(h.ProxyUseFdpass))
+	case itemStreamLocalBindMask:
+		if h.StreamLocalBindMask == nil {
+			return nil
+		}
+		return []string{fmt.Sprintf("%04o", uint32(*h.StreamLocalBindMask))}
+	case itemStreamLocalBindUnlink:
+		return nonEmpty(string(h.StreamLocalBindUnlink))
+	case itemHostbasedAuthentication:
+		return nonEmpty(string(h.HostbasedAuthentication))
+	case itemHostbasedAcceptedAlgorithms:
+		return joinedList(h.HostbasedAcceptedAlgorithms)
+	case itemNoHostAuthenticationForLocalhost:
+		return nonEmpty(string(h.NoHostAuthenticationForLocalhost))
+	case itemHostKeyAlias:
+		return nonEmpty(h.HostKeyAlias)
+	case itemUnknown:
+		for k, v := range h.Unknowns {
+			if strings.EqualFold(k, keyword) {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// Set sets keyword to value, replacing all previous values of the keyword.
+// Keywords are matched case-insensitively, unknown keywords are stored in
+// Unknowns. Directives of the keyword are replaced by a single directive
+// without position. Host, Match and Include can not be set.
+func (h *SSHHost) Set(keyword, value string) error {
+	typ, ok := variables[strings.ToLower(keyword)]
+	if !ok {
+		typ = itemUnknown
+	}
+
+	switch typ {
+	case itemHost, itemMatch, itemInclude:
+		return fmt.Errorf("%s can not be set on a host", keyword)
+	case itemIdentityFile:
+		h.IdentityFiles = nil
+	case itemCertificateFile:
+		h.CertificateFiles = nil
+	case itemLocalForward:
+		h.LocalForwards = nil
+	case itemRemoteForward:
+		h.RemoteForwards = nil
+	case itemDynamicForward:
+		h.DynamicForwards = nil
+	case itemSendEnv:
+		h.SendEnv = nil
+	case itemSetEnv:
+		h.SetEnv = nil
+	case itemUnknown:
+		for k := range h.Unknowns {
+			if strings.EqualFold(k, keyword) {
+				delete(h.Unknowns, k)
+			}
+		}
+		if name, _, ok := registeredKeyword(keyword); ok {
+			delete(h.Extensions, name)
+		}
+	}
+
+	if err := h.setValue(typ, keyword, value); err != nil {
+		return err
+	}
+
+	directives := h.Directives[:0]
+	for _, d := range h.Directives {
+		if !strings.EqualFold(d.Keyword, keyword) {
+			directives = append(directives, d)
+		}
+	}
+	h.Directives = append(directives, Directive{Keyword: keyword, Value: value})
+
+	return nil
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+func nonZero(value int) []string {
+	if value == 0 {
+		return nil
+	}
+	return []string{strconv.Itoa(value)}
+}
+
+func joinedList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return []string{strings.Join(values, ",")}
+}
+
+func spacedList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteArg(v)
+	}
+	return []string{strings.Join(quoted, " ")}
+}
+
+func stringValues[T fmt.Stringer](items []T) []string {
+	var values []string
+	for _, item := range items {
+		values = append(values, item.String())
+	}
+	return values
+}
@@ -0,0 +1,97 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	config := `Host google
+  HostName google.se
+  Port 2222
+  IdentityFile ~/.ssh/id_ed25519
+  IdentityFile ~/.ssh/id_rsa
+  LocalForward 0.0.0.0:666 instagram.com:1234
+  DynamicForward 8080
+  Ciphers aes256-ctr,aes128-cbc
+  VisualHostKey yes`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	h := hosts[0]
+
+	for _, tc := range []struct {
+		keyword string
+		value   string
+		ok      bool
+	}{
+		{"HostName", "google.se", true},
+		{"port", "2222", true},
+		{"IdentityFile", "~/.ssh/id_ed25519", true},
+		{"localforward", "0.0.0.0:666 instagram.com:1234", true},
+		{"DynamicForward", "8080", true},
+		{"Ciphers", "aes256-ctr,aes128-cbc", true},
+		{"visualhostkey", "yes", true},
+		{"User", "", false},
+		{"Compression", "", false},
+	} {
+		value, ok := h.Get(tc.keyword)
+		if value != tc.value || ok != tc.ok {
+			t.Errorf("Get(%s): expected (%#v, %t), got (%#v, %t)", tc.keyword, tc.value, tc.ok, value, ok)
+		}
+	}
+
+	expected := []string{"~/.ssh/id_ed25519", "~/.ssh/id_rsa"}
+	if actual := h.GetAll("identityfile"); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("unexpected identity files: %v", actual)
+	}
+}
+
+func TestSet(t *testing.T) {
+	h := &SSHHost{Host: []string{"google"}, Port: 22}
+
+	for _, kv := range [][2]string{
+		{"HostName", "google.se"},
+		{"PORT", "2222"},
+		{"IdentityFile", "~/.ssh/id_rsa"},
+		{"IdentityFile", "~/.ssh/id_ed25519"},
+		{"LocalForward", "1337 duckduckgo.com:443"},
+		{"VisualHostKey", "yes"},
+		{"visualhostkey", "no"},
+	} {
+		if err := h.Set(kv[0], kv[1]); err != nil {
+			t.Errorf("Set(%s, %s): unexpected error: %s", kv[0], kv[1], err)
+		}
+	}
+
+	if h.HostName != "google.se" || h.Port != 2222 {
+		t.Errorf("unexpected host: %+v", h)
+	}
+
+	if !reflect.DeepEqual([]string{"~/.ssh/id_ed25519"}, h.IdentityFiles) || h.IdentityFile != "~/.ssh/id_ed25519" {
+		t.Errorf("unexpected identity files: %v", h.IdentityFiles)
+	}
+
+	expected := []Forward{{InPort: 1337, OutHost: "duckduckgo.com", OutPort: 443}}
+	if !reflect.DeepEqual(expected, h.LocalForwards) {
+		t.Errorf("unexpected local forwards: %v", h.LocalForwards)
+	}
+
+	if !reflect.DeepEqual(map[string][]string{"visualhostkey": {"no"}}, h.Unknowns) {
+		t.Errorf("unexpected unknowns: %v", h.Unknowns)
+	}
+
+	if len(h.Directives) != 5 || h.Directives[4] != (Directive{Keyword: "visualhostkey", Value: "no"}) {
+		t.Errorf("unexpected directives: %+v", h.Directives)
+	}
+
+	if err := h.Set("Port", "abc"); err == nil {
+		t.Error("expected error for invalid port")
+	}
+
+	if err := h.Set("Host", "other"); err == nil {
+		t.Error("expected error when setting Host")
+	}
+}
@@ -0,0 +1,268 @@
+package sshconfig
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Markers of known_hosts entries.
+const (
+	// MarkerCertAuthority marks a key of a certificate authority trusted to
+	// sign host certificates.
+	MarkerCertAuthority = "@cert-authority"
+	// MarkerRevoked marks a key which must never be accepted.
+	MarkerRevoked = "@revoked"
+)
+
+// KnownHost is an entry of a known_hosts file.
+type KnownHost struct {
+	// Marker is MarkerCertAuthority, MarkerRevoked or empty.
+	Marker string
+	// Hosts are the host patterns of the entry, like `[example.com]:2222`
+	// or `!*.example.com`, or hashed host names like `|1|salt|hash`.
+	Hosts   []string
+	Key     ssh.PublicKey
+	Comment string
+	File    string
+	Line    int
+}
+
+// ParseKnownHosts parses the known_hosts file at path.
+func ParseKnownHosts(path string) ([]KnownHost, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseKnownHostsReader(f, path)
+}
+
+// ParseKnownHostsReader parses a known_hosts file from r, path is used in
+// errors and as File of the entries. Blank lines and comments are skipped,
+// an invalid entry is reported as *ParseError.
+func ParseKnownHostsReader(r io.Reader, path string) ([]KnownHost, error) {
+	var entries []KnownHost
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+
+		marker, hosts, key, comment, _, err := ssh.ParseKnownHosts(text)
+		if err != nil {
+			return nil, &ParseError{File: path, Line: line, Column: 1, Msg: err.Error(), Err: err}
+		}
+		if marker != "" {
+			marker = "@" + marker
+		}
+		entries = append(entries, KnownHost{
+			Marker:  marker,
+			Hosts:   hosts,
+			Key:     key,
+			Comment: comment,
+			File:    path,
+			Line:    line,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Matches reports whether the entry applies to host at port. Like ssh, a
+// port other than 22 is matched as `[host]:port` and a negated pattern
+// matching host excludes the entry.
+func (k KnownHost) Matches(host string, port int) bool {
+	name := knownHostName(host, port)
+	matched := false
+	for _, p := range k.Hosts {
+		if strings.HasPrefix(p, "|1|") {
+			matched = matched || matchHashedHost(p, name)
+			continue
+		}
+		if strings.HasPrefix(p, "!") {
+			if matchPattern(strings.ToLower(p[1:]), name) {
+				return false
+			}
+			continue
+		}
+		if matchPattern(strings.ToLower(p), name) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// knownHostName returns the name host at port is written as in a
+// known_hosts file.
+func knownHostName(host string, port int) string {
+	host = strings.ToLower(host)
+	if port == 0 || port == 22 {
+		return host
+	}
+	return "[" + host + "]:" + strconv.Itoa(port)
+}
+
+// matchHashedHost reports whether name matches a host hashed as
+// `|1|salt|hash`, where hash is the HMAC-SHA1 of name keyed with salt.
+func matchHashedHost(hashed string, name string) bool {
+	salt, hash, ok := strings.Cut(strings.TrimPrefix(hashed, "|1|"), "|")
+	if !ok {
+		return false
+	}
+	key, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return false
+	}
+	want, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, key)
+	mac.Write([]byte(name))
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// KnownHostsFiles returns the known_hosts files ssh reads for host: the
+// UserKnownHostsFile entries followed by the GlobalKnownHostsFile entries,
+// or their defaults, expanded by ExpandPath. A value of none is left out.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func (h *SSHHost) KnownHostsFiles() ([]string, error) {
+	user, err := h.userKnownHostsFiles()
+	if err != nil {
+		return nil, err
+	}
+	global := h.GlobalKnownHostsFiles
+	if len(global) == 0 {
+		global = []string{
+			filepath.Join(systemDir, "ssh_known_hosts"),
+			filepath.Join(systemDir, "ssh_known_hosts2"),
+		}
+	}
+	globalFiles, err := h.expandKnownHostsFiles(global)
+	if err != nil {
+		return nil, err
+	}
+	return append(user, globalFiles...), nil
+}
+
+// KeyFingerprint returns the fingerprint of key the way ssh displays it,
+// using the FingerprintHash of host: SHA256:base64 by default, or MD5:hex
+// colon separated.
+func (h *SSHHost) KeyFingerprint(key ssh.PublicKey) string {
+	if h.FingerprintHash == FingerprintHashMD5 {
+		return "MD5:" + ssh.FingerprintLegacyMD5(key)
+	}
+	return ssh.FingerprintSHA256(key)
+}
+
+// KnownHostsCommandArgs returns the KnownHostsCommand of host split into
+// its arguments, with environment variables and percent tokens expanded
+// like ssh does before running it to obtain further known_hosts lines. Name
+// is the host name, HostKeyAlias or address looked up, %H, and reason why,
+// %I, one of ADDRESS, HOSTNAME or ORDER. The key presented by the server
+// gives %f, %K and %t, it is nil when ssh only asks for the preferred host
+// key order.
+// It returns nil if no command is set or it is none.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func (h *SSHHost) KnownHostsCommandArgs(name, reason string, key ssh.PublicKey) ([]string, error) {
+	if h.KnownHostsCommand == "" || strings.EqualFold(h.KnownHostsCommand, "none") {
+		return nil, nil
+	}
+	args, err := splitArgs(h.KnownHostsCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := map[byte]string{'H': name, 'I': reason}
+	if key != nil {
+		tokens['f'] = h.KeyFingerprint(key)
+		tokens['K'] = base64.StdEncoding.EncodeToString(key.Marshal())
+		tokens['t'] = key.Type()
+	}
+	for i, arg := range args {
+		arg, err = expandEnv(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = expandTokensWith(arg, h, tokens)
+	}
+	return args, nil
+}
+
+// userKnownHostsFiles returns the expanded UserKnownHostsFile entries of h,
+// or their defaults.
+func (h *SSHHost) userKnownHostsFiles() ([]string, error) {
+	user := h.UserKnownHostsFiles
+	if len(user) == 0 {
+		user = []string{"~/.ssh/known_hosts", "~/.ssh/known_hosts2"}
+	}
+	return h.expandKnownHostsFiles(user)
+}
+
+// expandKnownHostsFiles expands files by ExpandPath, leaving out none.
+func (h *SSHHost) expandKnownHostsFiles(files []string) ([]string, error) {
+	var expanded []string
+	for _, f := range files {
+		if strings.EqualFold(f, "none") {
+			continue
+		}
+		path, err := h.ExpandPath(f)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, path)
+	}
+	return expanded, nil
+}
+
+// KnownKeys returns the entries of the known_hosts files of host, see
+// KnownHostsFiles, which match its host name and port, or its HostKeyAlias
+// if set. Files which don't exist are skipped.
+//
+// Host is expected to be a resolved host as returned by Lookup.
+func (h *SSHHost) KnownKeys() ([]KnownHost, error) {
+	files, err := h.KnownHostsFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	host, port := remoteHost(h), h.Port
+	if h.HostKeyAlias != "" {
+		host, port = h.HostKeyAlias, 22
+	}
+	var keys []KnownHost
+	for _, f := range files {
+		entries, err := ParseKnownHosts(f)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Matches(host, port) {
+				keys = append(keys, e)
+			}
+		}
+	}
+	return keys, nil
+}
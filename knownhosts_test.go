@@ -0,0 +1,247 @@
+package sshconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+)
+
+// testPublicKey returns a new public key and its authorized_keys form.
+func testPublicKey(t *testing.T) (ssh.PublicKey, string) {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("unable to create public key: %s", err.Error())
+	}
+	return key, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+// hashHost returns name hashed like `ssh-keygen -H` does.
+func hashHost(name string) string {
+	salt := []byte("0123456789abcdefghij")
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(name))
+	return "|1|" + base64.StdEncoding.EncodeToString(salt) + "|" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseKnownHosts(t *testing.T) {
+	key, line := testPublicKey(t)
+	content := `# known hosts
+example.com,192.0.2.1 ` + line + ` comment
+
+[example.com]:2222 ` + line + `
+*.example.org,!bad.example.org ` + line + `
+` + hashHost("hashed.example.com") + ` ` + line + `
+@cert-authority *.example.net ` + line + `
+@revoked revoked.example.com ` + line + `
+`
+
+	entries, err := ParseKnownHostsReader(strings.NewReader(content), "known_hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Line != 2 || first.File != "known_hosts" || first.Comment != "comment" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if strings.Join(first.Hosts, ",") != "example.com,192.0.2.1" {
+		t.Errorf("unexpected hosts: %v", first.Hosts)
+	}
+	if string(first.Key.Marshal()) != string(key.Marshal()) {
+		t.Errorf("unexpected key")
+	}
+	if entries[4].Marker != MarkerCertAuthority || entries[5].Marker != MarkerRevoked {
+		t.Errorf("unexpected markers: %q, %q", entries[4].Marker, entries[5].Marker)
+	}
+
+	for _, tc := range []struct {
+		entry int
+		host  string
+		port  int
+		match bool
+	}{
+		{0, "example.com", 22, true},
+		{0, "EXAMPLE.com", 0, true},
+		{0, "192.0.2.1", 22, true},
+		{0, "example.com", 2222, false},
+		{1, "example.com", 2222, true},
+		{1, "example.com", 22, false},
+		{2, "web.example.org", 22, true},
+		{2, "bad.example.org", 22, false},
+		{3, "hashed.example.com", 22, true},
+		{3, "other.example.com", 22, false},
+	} {
+		if match := entries[tc.entry].Matches(tc.host, tc.port); match != tc.match {
+			t.Errorf("entry %d, %s:%d: expected match %t, got %t", tc.entry, tc.host, tc.port, tc.match, match)
+		}
+	}
+
+	_, err = ParseKnownHostsReader(strings.NewReader("# comment\nexample.com ssh-ed25519 invalid\n"), "known_hosts")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Errorf("expected parse error on line 2, got %v", err)
+	}
+}
+
+func TestKnownKeys(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	homedir.DisableCache = true
+	homeEnv := "HOME"
+	if runtime.GOOS == "plan9" {
+		homeEnv = "home"
+	}
+	t.Setenv(homeEnv, tmpdir)
+
+	_, line := testPublicKey(t)
+	if err := os.WriteFile(filepath.Join(tmpdir, "known_hosts"), []byte("web.example.com "+line+"\n[web.example.com]:2222 "+line+"\n"), 0600); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	config := `Host web
+  HostName web.example.com
+  UserKnownHostsFile ~/known_hosts ~/missing
+  GlobalKnownHostsFile none
+
+Host alt
+  HostName web.example.com
+  Port 2222
+  UserKnownHostsFile ~/known_hosts
+
+Host tunnel
+  HostName localhost
+  Port 2200
+  HostKeyAlias web.example.com
+  UserKnownHostsFile ~/known_hosts
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	web := Lookup(hosts, "web")
+	files, err := web.KnownHostsFiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{filepath.Join(tmpdir, "known_hosts"), filepath.Join(tmpdir, "missing")}
+	if strings.Join(files, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected files %v, got %v", expected, files)
+	}
+
+	keys, err := web.KnownKeys()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(keys) != 1 || keys[0].Line != 1 {
+		t.Errorf("expected the key on line 1, got %+v", keys)
+	}
+
+	keys, err = Lookup(hosts, "alt").KnownKeys()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(keys) != 1 || keys[0].Line != 2 {
+		t.Errorf("expected the key on line 2, got %+v", keys)
+	}
+
+	keys, err = Lookup(hosts, "tunnel").KnownKeys()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(keys) != 1 || keys[0].Line != 1 {
+		t.Errorf("expected the key of the alias on line 1, got %+v", keys)
+	}
+}
+
+func TestKnownHostsCommandArgs(t *testing.T) {
+	t.Setenv("CA_URL", "https://ca.example.com")
+	config := `Host web
+  HostName web.example.com
+  KnownHostsCommand /usr/bin/ca-lookup --url ${CA_URL} "%n %H" %I %t %K %f %%
+
+Host none
+  KnownHostsCommand none
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	web := Lookup(hosts, "web")
+	if web.KnownHostsCommand != `/usr/bin/ca-lookup --url ${CA_URL} "%n %H" %I %t %K %f %%` {
+		t.Errorf("unexpected command: %s", web.KnownHostsCommand)
+	}
+
+	key, _ := testPublicKey(t)
+	args, err := web.KnownHostsCommandArgs("10.0.0.1", "ADDRESS", key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{"/usr/bin/ca-lookup", "--url", "https://ca.example.com", "web 10.0.0.1", "ADDRESS", "ssh-ed25519", base64.StdEncoding.EncodeToString(key.Marshal()), ssh.FingerprintSHA256(key), "%"}
+	if strings.Join(args, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+
+	args, err = web.KnownHostsCommandArgs("web.example.com", "ORDER", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(args) != 9 || args[3] != "web web.example.com" || args[5] != "%t" {
+		t.Errorf("unexpected args: %q", args)
+	}
+
+	if args, err := Lookup(hosts, "none").KnownHostsCommandArgs("none", "HOSTNAME", key); args != nil || err != nil {
+		t.Errorf("expected no command, got %q, %v", args, err)
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	config := `Host legacy
+  FingerprintHash MD5
+
+Host *
+  FingerprintHash sha256
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	key, _ := testPublicKey(t)
+	legacy := Lookup(hosts, "legacy")
+	if legacy.FingerprintHash != FingerprintHashMD5 {
+		t.Errorf("unexpected FingerprintHash: %q", legacy.FingerprintHash)
+	}
+	if f := legacy.KeyFingerprint(key); f != "MD5:"+ssh.FingerprintLegacyMD5(key) {
+		t.Errorf("unexpected fingerprint: %s", f)
+	}
+	if f := Lookup(hosts, "web").KeyFingerprint(key); f != ssh.FingerprintSHA256(key) {
+		t.Errorf("unexpected fingerprint: %s", f)
+	}
+	if f := (&SSHHost{}).KeyFingerprint(key); f != ssh.FingerprintSHA256(key) {
+		t.Errorf("unexpected default fingerprint: %s", f)
+	}
+
+	if _, err := parse("Host web\n  FingerprintHash sha1\n", "~/.ssh/config"); err == nil {
+		t.Errorf("expected error for FingerprintHash sha1")
+	}
+}
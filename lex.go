@@ -8,7 +8,6 @@ package sshconfig
 
 import (
 	"fmt"
-	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -17,9 +16,10 @@ import (
 type pos int
 
 type item struct {
-	typ itemType
-	pos pos
-	val string
+	typ  itemType
+	pos  pos
+	val  string
+	line int
 }
 
 func (i item) String() string {
@@ -39,6 +39,7 @@ type itemType int
 const (
 	itemError itemType = iota
 	itemEOF
+	itemComment
 	itemValue
 	itemHost
 	itemHostValue
@@ -54,23 +55,182 @@ const (
 	itemInclude
 	itemCiphers
 	itemMACs
+	itemMatch
+	itemTag
+	itemCertificateFile
+	itemProxyJump
+	itemForwardAgent
+	itemControlMaster
+	itemControlPath
+	itemControlPersist
+	itemServerAliveInterval
+	itemServerAliveCountMax
+	itemStrictHostKeyChecking
+	itemUserKnownHostsFile
+	itemGlobalKnownHostsFile
+	itemPreferredAuthentications
+	itemPubkeyAuthentication
+	itemPasswordAuthentication
+	itemKbdInteractiveAuthentication
+	itemKexAlgorithms
+	itemPubkeyAcceptedAlgorithms
+	itemSendEnv
+	itemSetEnv
+	itemLocalCommand
+	itemPermitLocalCommand
+	itemRemoteCommand
+	itemRequestTTY
+	itemSessionType
+	itemForwardX11
+	itemForwardX11Trusted
+	itemForwardX11Timeout
+	itemCanonicalizeHostname
+	itemCanonicalDomains
+	itemCanonicalizeMaxDots
+	itemCanonicalizeFallbackLocal
+	itemCanonicalizePermittedCNAMEs
+	itemHashKnownHosts
+	itemCheckHostIP
+	itemVerifyHostKeyDNS
+	itemTCPKeepAlive
+	itemTunnel
+	itemTunnelDevice
+	itemGatewayPorts
+	itemExitOnForwardFailure
+	itemClearAllForwardings
+	itemLogLevel
+	itemSyslogFacility
+	itemBatchMode
+	itemNumberOfPasswordPrompts
+	itemEscapeChar
+	itemEnableEscapeCommandline
+	itemPKCS11Provider
+	itemSecurityKeyProvider
+	itemGSSAPIAuthentication
+	itemGSSAPIDelegateCredentials
+	itemGSSAPIKeyExchange
+	itemGSSAPITrustDns
+	itemGSSAPIClientIdentity
+	itemGSSAPIServerIdentity
+	itemRekeyLimit
+	itemUpdateHostKeys
+	itemIgnoreUnknown
+	itemPermitOpen
+	itemPermitRemoteOpen
+	itemIdentityAgent
+	itemConnectTimeout
+	itemAddKeysToAgent
+	itemKnownHostsCommand
+	itemFingerprintHash
+	itemRequiredRSASize
+	itemChannelTimeout
+	itemObscureKeystrokeTiming
+	itemStdinNull
+	itemForkAfterAuthentication
+	itemProxyUseFdpass
+	itemStreamLocalBindMask
+	itemStreamLocalBindUnlink
+	itemHostbasedAuthentication
+	itemHostbasedAcceptedAlgorithms
+	itemNoHostAuthenticationForLocalhost
+	itemHostKeyAlias
+	itemUnknown
 )
 
 // variables
 var variables = map[string]itemType{
-	"host":              itemHost,
-	"hostname":          itemHostName,
-	"user":              itemUser,
-	"port":              itemPort,
-	"proxycommand":      itemProxyCommand,
-	"hostkeyalgorithms": itemHostKeyAlgorithms,
-	"identityfile":      itemIdentityFile,
-	"localforward":      itemLocalForward,
-	"remoteforward":     itemRemoteForward,
-	"dynamicforward":    itemDynamicForward,
-	"include":           itemInclude,
-	"ciphers":           itemCiphers,
-	"macs":              itemMACs,
+	"host":                             itemHost,
+	"hostname":                         itemHostName,
+	"user":                             itemUser,
+	"port":                             itemPort,
+	"proxycommand":                     itemProxyCommand,
+	"hostkeyalgorithms":                itemHostKeyAlgorithms,
+	"identityfile":                     itemIdentityFile,
+	"localforward":                     itemLocalForward,
+	"remoteforward":                    itemRemoteForward,
+	"dynamicforward":                   itemDynamicForward,
+	"include":                          itemInclude,
+	"ciphers":                          itemCiphers,
+	"macs":                             itemMACs,
+	"match":                            itemMatch,
+	"tag":                              itemTag,
+	"certificatefile":                  itemCertificateFile,
+	"proxyjump":                        itemProxyJump,
+	"forwardagent":                     itemForwardAgent,
+	"controlmaster":                    itemControlMaster,
+	"controlpath":                      itemControlPath,
+	"controlpersist":                   itemControlPersist,
+	"serveraliveinterval":              itemServerAliveInterval,
+	"serveralivecountmax":              itemServerAliveCountMax,
+	"stricthostkeychecking":            itemStrictHostKeyChecking,
+	"userknownhostsfile":               itemUserKnownHostsFile,
+	"globalknownhostsfile":             itemGlobalKnownHostsFile,
+	"preferredauthentications":         itemPreferredAuthentications,
+	"pubkeyauthentication":             itemPubkeyAuthentication,
+	"passwordauthentication":           itemPasswordAuthentication,
+	"kbdinteractiveauthentication":     itemKbdInteractiveAuthentication,
+	"kexalgorithms":                    itemKexAlgorithms,
+	"pubkeyacceptedalgorithms":         itemPubkeyAcceptedAlgorithms,
+	"sendenv":                          itemSendEnv,
+	"setenv":                           itemSetEnv,
+	"localcommand":                     itemLocalCommand,
+	"permitlocalcommand":               itemPermitLocalCommand,
+	"remotecommand":                    itemRemoteCommand,
+	"requesttty":                       itemRequestTTY,
+	"sessiontype":                      itemSessionType,
+	"forwardx11":                       itemForwardX11,
+	"forwardx11trusted":                itemForwardX11Trusted,
+	"forwardx11timeout":                itemForwardX11Timeout,
+	"canonicalizehostname":             itemCanonicalizeHostname,
+	"canonicaldomains":                 itemCanonicalDomains,
+	"canonicalizemaxdots":              itemCanonicalizeMaxDots,
+	"canonicalizefallbacklocal":        itemCanonicalizeFallbackLocal,
+	"canonicalizepermittedcnames":      itemCanonicalizePermittedCNAMEs,
+	"hashknownhosts":                   itemHashKnownHosts,
+	"checkhostip":                      itemCheckHostIP,
+	"verifyhostkeydns":                 itemVerifyHostKeyDNS,
+	"tcpkeepalive":                     itemTCPKeepAlive,
+	"tunnel":                           itemTunnel,
+	"tunneldevice":                     itemTunnelDevice,
+	"gatewayports":                     itemGatewayPorts,
+	"exitonforwardfailure":             itemExitOnForwardFailure,
+	"clearallforwardings":              itemClearAllForwardings,
+	"loglevel":                         itemLogLevel,
+	"syslogfacility":                   itemSyslogFacility,
+	"batchmode":                        itemBatchMode,
+	"numberofpasswordprompts":          itemNumberOfPasswordPrompts,
+	"escapechar":                       itemEscapeChar,
+	"enableescapecommandline":          itemEnableEscapeCommandline,
+	"pkcs11provider":                   itemPKCS11Provider,
+	"securitykeyprovider":              itemSecurityKeyProvider,
+	"gssapiauthentication":             itemGSSAPIAuthentication,
+	"gssapidelegatecredentials":        itemGSSAPIDelegateCredentials,
+	"gssapikeyexchange":                itemGSSAPIKeyExchange,
+	"gssapitrustdns":                   itemGSSAPITrustDns,
+	"gssapiclientidentity":             itemGSSAPIClientIdentity,
+	"gssapiserveridentity":             itemGSSAPIServerIdentity,
+	"rekeylimit":                       itemRekeyLimit,
+	"updatehostkeys":                   itemUpdateHostKeys,
+	"ignoreunknown":                    itemIgnoreUnknown,
+	"permitopen":                       itemPermitOpen,
+	"permitremoteopen":                 itemPermitRemoteOpen,
+	"identityagent":                    itemIdentityAgent,
+	"connecttimeout":                   itemConnectTimeout,
+	"addkeystoagent":                   itemAddKeysToAgent,
+	"knownhostscommand":                itemKnownHostsCommand,
+	"fingerprinthash":                  itemFingerprintHash,
+	"requiredrsasize":                  itemRequiredRSASize,
+	"channeltimeout":                   itemChannelTimeout,
+	"obscurekeystroketiming":           itemObscureKeystrokeTiming,
+	"stdinnull":                        itemStdinNull,
+	"forkafterauthentication":          itemForkAfterAuthentication,
+	"proxyusefdpass":                   itemProxyUseFdpass,
+	"streamlocalbindmask":              itemStreamLocalBindMask,
+	"streamlocalbindunlink":            itemStreamLocalBindUnlink,
+	"hostbasedauthentication":          itemHostbasedAuthentication,
+	"hostbasedacceptedalgorithms":      itemHostbasedAcceptedAlgorithms,
+	"nohostauthenticationforlocalhost": itemNoHostAuthenticationForLocalhost,
+	"hostkeyalias":                     itemHostKeyAlias,
 }
 
 const eof = -1
@@ -80,13 +240,17 @@ type stateFn func(*lexer) stateFn
 
 // lexer holds the state of the scanner
 type lexer struct {
-	input   string
-	state   stateFn
-	pos     pos
-	start   pos
-	width   pos
-	lastPos pos
-	items   chan item // channel of scanned items
+	input     string
+	state     stateFn
+	pos       pos
+	start     pos
+	width     pos
+	lastPos   pos
+	items     []item // scanned items not yet returned by nextItem
+	head      int    // index of the next item to return
+	line      int    // 1+number of newlines seen
+	startLine int    // start line of this item
+	comments  bool   // whether comments are emitted
 }
 
 // next returns the next rune in the input
@@ -98,6 +262,9 @@ func (l *lexer) next() rune {
 	r, w := utf8.DecodeRuneInString(l.input[l.pos:])
 	l.width = pos(w)
 	l.pos += l.width
+	if r == '\n' {
+		l.line++
+	}
 	return r
 }
 
@@ -111,48 +278,93 @@ func (l *lexer) peek() rune {
 // backup steps back one rune. Can only be called once per call of next
 func (l *lexer) backup() {
 	l.pos -= l.width
+	if l.width == 1 && l.input[l.pos] == '\n' {
+		l.line--
+	}
 }
 
 // emit passes an item back to the client
 func (l *lexer) emit(t itemType) {
-	l.items <- item{t, l.start, l.input[l.start:l.pos]}
+	l.items = append(l.items, item{t, l.start, l.input[l.start:l.pos], l.startLine})
 	l.start = l.pos
+	l.startLine = l.line
 }
 
 // ignore skips over the pending input before this point
 func (l *lexer) ignore() {
 	l.start = l.pos
+	l.startLine = l.line
 }
 
 // errorf returns an error token and terminates the scan by passing
 // back a nil pointer that will be the next state, terminating l.nextItem.
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- item{itemError, l.start, fmt.Sprintf(format, args...)}
+	l.items = append(l.items, item{itemError, l.start, fmt.Sprintf(format, args...), l.startLine})
 	return nil
 }
 
-// nextItem returns the next item from the input.
+// nextItem returns the next item from the input, running the state machine
+// until an item is available. Once the input is consumed EOF is returned.
 func (l *lexer) nextItem() item {
-	item := <-l.items
+	for l.head == len(l.items) {
+		if l.state == nil {
+			return item{itemEOF, l.pos, "", l.line}
+		}
+		l.items = l.items[:0]
+		l.head = 0
+		l.state = l.state(l)
+	}
+
+	item := l.items[l.head]
+	l.head++
 	l.lastPos = item.pos
 	return item
 }
 
 func lex(input string) *lexer {
-	l := &lexer{
-		input: input,
-		items: make(chan item),
+	return &lexer{
+		input:     input,
+		state:     lexEnv,
+		items:     make([]item, 0, 2),
+		line:      1,
+		startLine: 1,
 	}
-	go l.run()
+}
+
+// lexWithComments returns a lexer which also emits comment lines.
+func lexWithComments(input string) *lexer {
+	l := lex(input)
+	l.comments = true
 	return l
 }
 
-func (l *lexer) run() {
-	for l.state = lexEnv; l.state != nil; {
-		l.state = l.state(l)
+// lexAll returns all items of input, including comments, up to the first
+// EOF or error item.
+func lexAll(input string) []item {
+	l := lexWithComments(input)
+	var items []item
+	for {
+		item := l.nextItem()
+		items = append(items, item)
+		if item.typ == itemEOF || item.typ == itemError {
+			return items
+		}
 	}
 }
 
+// replay returns a lexer returning items lexed before by lexAll. The items
+// are not modified, so they may be replayed concurrently.
+func replay(items []item) *lexer {
+	return &lexer{items: items}
+}
+
+// drain stops the lexer, discarding any items not yet returned.
+func (l *lexer) drain() {
+	l.state = nil
+	l.items = nil
+	l.head = 0
+}
+
 func lexEnv(l *lexer) stateFn {
 	switch r := l.next(); {
 	case r == eof:
@@ -181,20 +393,18 @@ func lexEnv(l *lexer) stateFn {
 
 func lexComment(l *lexer) stateFn {
 	for {
-		switch l.next() {
-		case '\r':
-			l.ignore()
-			if l.next() != '\n' {
-				l.errorf("expected \\n")
-				return nil
+		switch r := l.next(); r {
+		case '\r', '\n', eof:
+			// the line ending is left to lexEnv
+			if r != eof {
+				l.backup()
+			}
+			if l.comments {
+				l.emit(itemComment)
+			} else {
+				l.ignore()
 			}
-			l.ignore()
-			return lexEnv
-		case '\n', eof:
-			l.ignore()
 			return lexEnv
-		default:
-			l.ignore()
 		}
 	}
 }
@@ -204,18 +414,14 @@ func lexVariable(l *lexer) stateFn {
 		switch r := l.next(); {
 		case isAlphaNumeric(r):
 			// absorb
-		case r == ' ' || r == '=':
+		case r == ' ' || r == '\t' || r == '=':
 			l.backup()
-			variable := strings.ToLower(l.input[l.start:l.pos])
+			typ := keyword(l.input[l.start:l.pos])
 
-			if _, ok := variables[variable]; ok {
-				l.emit(variables[variable])
-				l.next()
-				l.ignore()
-				if variable == "host" {
-					return lexHostValue
-				}
-				return lexValue
+			l.emit(typ)
+			l.skipSeparator()
+			if typ == itemHost {
+				return lexHostValue
 			}
 			return lexValue
 		default:
@@ -225,43 +431,35 @@ func lexVariable(l *lexer) stateFn {
 	}
 }
 
-func lexHostValue(l *lexer) stateFn {
+// skipSeparator skips the whitespace between a keyword and its value, which
+// may contain a single `=` as in `Port = 2222` or `Port=2222`.
+func (l *lexer) skipSeparator() {
+	equals := false
 	for {
-		switch l.next() {
-		case ' ':
-			switch l.peek() {
-			case '\r':
-				if l.peek() != '\n' {
-					return l.errorf("expected \\n")
-				}
-				fallthrough
-			case '\n', eof:
-				break
-			default:
-				// more coming, wait
-				continue
-			}
-			l.emit(itemValue)
-		case '\r':
-			if l.peek() != '\n' {
-				return l.errorf("expected \\n")
+		switch r := l.next(); {
+		case r == ' ' || r == '\t':
+		case r == '=' && !equals:
+			equals = true
+		default:
+			if r != eof {
+				l.backup()
 			}
-			fallthrough
-		case '\n':
-			l.backup()
-			l.emit(itemHostValue)
-			return lexEnv
-		case eof:
-			l.backup()
-			l.emit(itemHostValue)
-			l.next()
-			l.emit(itemEOF)
-			return nil
+			l.ignore()
+			return
 		}
 	}
 }
 
+func lexHostValue(l *lexer) stateFn {
+	return lexLine(l, itemHostValue)
+}
+
 func lexValue(l *lexer) stateFn {
+	return lexLine(l, itemValue)
+}
+
+// lexLine emits the rest of the line as an item of type typ.
+func lexLine(l *lexer, typ itemType) stateFn {
 	for {
 		switch l.next() {
 		case '\r':
@@ -271,11 +469,11 @@ func lexValue(l *lexer) stateFn {
 			fallthrough
 		case '\n':
 			l.backup()
-			l.emit(itemValue)
+			l.emit(typ)
 			return lexEnv
 		case eof:
 			l.backup()
-			l.emit(itemValue)
+			l.emit(typ)
 			l.next()
 			l.emit(itemEOF)
 			return nil
@@ -283,6 +481,30 @@ func lexValue(l *lexer) stateFn {
 	}
 }
 
+// keyword returns the item type of the keyword s, matched case-insensitively.
+// Known keywords are ASCII, so s is lowered into a stack buffer to avoid
+// allocating a string for every keyword.
+func keyword(s string) itemType {
+	var buf [32]byte
+	if len(s) > len(buf) {
+		return itemUnknown
+	}
+
+	b := buf[:len(s)]
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		b[i] = c
+	}
+
+	if typ, ok := variables[string(b)]; ok {
+		return typ
+	}
+	return itemUnknown
+}
+
 // isAlphaNumeric reports whether r is an alphabetic or digit.
 func isAlphaNumeric(r rune) bool {
 	return unicode.IsLetter(r) || unicode.IsDigit(r)
@@ -0,0 +1,70 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// generateConfig returns a config with n hosts as written by inventory tools
+func generateConfig(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "Host node-%d node-%d.internal\n", i, i)
+		fmt.Fprintf(&b, "  HostName 10.0.%d.%d\n", i/256%256, i%256)
+		b.WriteString("  User deploy\n")
+		b.WriteString("  Port 2222\n")
+		b.WriteString("  IdentityFile ~/.ssh/inventory\n")
+		b.WriteString("  LocalForward 8080 localhost:80\n\n")
+	}
+	return b.String()
+}
+
+func TestLexItems(t *testing.T) {
+	l := lex("Host web\n  HOSTNAME web.example.com\n  Unknown yes")
+	defer l.drain()
+
+	expected := []item{
+		{typ: itemHost, val: "Host"},
+		{typ: itemHostValue, val: "web"},
+		{typ: itemHostName, val: "HOSTNAME"},
+		{typ: itemValue, val: "web.example.com"},
+		{typ: itemUnknown, val: "Unknown"},
+		{typ: itemValue, val: "yes"},
+		{typ: itemEOF},
+		{typ: itemEOF},
+	}
+	for _, e := range expected {
+		it := l.nextItem()
+		if it.typ != e.typ || it.val != e.val {
+			t.Errorf("expected item %d %q, got %d %q", e.typ, e.val, it.typ, it.val)
+		}
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	config := generateConfig(10000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(config)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l := lex(config)
+		for l.nextItem().typ != itemEOF {
+		}
+		l.drain()
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	config := generateConfig(10000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(config)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parse(config, "~/.ssh/config"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
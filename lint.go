@@ -0,0 +1,218 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is the importance of a lint finding.
+type Severity int
+
+const (
+	// SeverityInfo is a suggestion, the config works as intended.
+	SeverityInfo Severity = iota
+	// SeverityWarning is a likely mistake, like a directive without effect.
+	SeverityWarning
+	// SeverityError is a config which doesn't work as written.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Lint rule IDs.
+const (
+	// RuleUnreachable flags Host blocks which can never match.
+	RuleUnreachable = "unreachable-block"
+	// RuleMissingHostName flags aliases which are not a host name and have
+	// no HostName or proxy to connect through.
+	RuleMissingHostName = "missing-hostname"
+	// RuleEmptyBlock flags blocks without directives.
+	RuleEmptyBlock = "empty-block"
+	// RuleDuplicateKeyword flags keywords given more than once in a block,
+	// of which only the first has an effect.
+	RuleDuplicateKeyword = "duplicate-keyword"
+	// RuleDuplicateAlias flags directives without effect because an
+	// earlier block for the same alias sets them, see FindDuplicates.
+	RuleDuplicateAlias = "duplicate-alias"
+	// RuleProxyConflict flags blocks setting both ProxyCommand and
+	// ProxyJump, of which only the first one has an effect.
+	RuleProxyConflict = "proxy-conflict"
+	// RuleProxyCommandJump flags a ProxyCommand running `ssh -W`, which
+	// ProxyJump does without a shell.
+	RuleProxyCommandJump = "proxycommand-jump"
+)
+
+// Finding is a problem found by Lint.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	File     string
+	Line     int
+	Msg      string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", f.File, f.Line, f.Severity, f.Msg, f.Rule)
+}
+
+// Lint checks hosts for problems which don't prevent parsing, like
+// unreachable blocks, directives without effect or suspicious proxy
+// settings. Findings are returned in the order of the blocks they are found
+// in.
+func Lint(hosts []*SSHHost) []Finding {
+	var findings []Finding
+	add := func(rule string, severity Severity, file string, line int, format string, args ...any) {
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: severity,
+			File:     file,
+			Line:     line,
+			Msg:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	shadowedBy := map[*SSHHost][]Duplicate{}
+	for _, d := range FindDuplicates(hosts) {
+		for _, h := range d.Hosts[1:] {
+			shadowedBy[h] = append(shadowedBy[h], d)
+		}
+	}
+
+	for _, h := range hosts {
+		unreachable := h.Match == nil && neverMatches(h.Host)
+		if unreachable {
+			add(RuleUnreachable, SeverityWarning, h.SourceFile, h.SourceLine,
+				"Host %s can never match", strings.Join(h.Host, " "))
+		}
+
+		if len(h.Directives) == 0 && !h.IsGlobal() {
+			add(RuleEmptyBlock, SeverityInfo, h.SourceFile, h.SourceLine, "block has no directives")
+		}
+
+		if h.Match == nil && !unreachable {
+			for _, alias := range h.Host {
+				if isPattern(alias) || strings.ContainsAny(alias, ".:") {
+					continue
+				}
+				// the alias is used as host name if no HostName is set
+				effective := Lookup(hosts, alias)
+				noHostName := effective.HostName == "" || effective.HostName == alias
+				if noHostName && effective.ProxyCommand == "" && effective.ProxyJump == "" {
+					add(RuleMissingHostName, SeverityInfo, h.SourceFile, h.SourceLine,
+						"%s has no HostName and is not a host name", alias)
+				}
+			}
+		}
+
+		seen := map[string]Directive{}
+		var proxy *Directive
+		for i, d := range h.Directives {
+			typ, ok := variables[strings.ToLower(d.Keyword)]
+			if !ok {
+				continue
+			}
+
+			if !multiValued[typ] && typ != itemInclude {
+				if first, ok := seen[strings.ToLower(d.Keyword)]; ok {
+					add(RuleDuplicateKeyword, SeverityWarning, d.File, d.Line,
+						"%s has no effect, it is already set on line %d", d.Keyword, first.Line)
+				} else {
+					seen[strings.ToLower(d.Keyword)] = d
+				}
+			}
+
+			switch typ {
+			case itemProxyCommand, itemProxyJump:
+				if proxy != nil && !strings.EqualFold(proxy.Keyword, d.Keyword) {
+					add(RuleProxyConflict, SeverityWarning, d.File, d.Line,
+						"%s has no effect, %s is set on line %d", d.Keyword, proxy.Keyword, proxy.Line)
+				} else if proxy == nil {
+					proxy = &h.Directives[i]
+				}
+				if typ == itemProxyCommand && isSSHJump(d.Value) {
+					add(RuleProxyCommandJump, SeverityInfo, d.File, d.Line,
+						"ProxyCommand runs ssh -W, use ProxyJump instead")
+				}
+			}
+		}
+
+		for _, dup := range shadowedBy[h] {
+			for _, d := range dup.Shadowed {
+				if h.hasDirective(d) {
+					add(RuleDuplicateAlias, SeverityWarning, d.File, d.Line,
+						"%s has no effect for %s, an earlier block sets it", d.Keyword, dup.Alias)
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+// Lint checks the hosts of the config for problems, see Lint.
+func (c *Config) Lint() []Finding {
+	return Lint(c.hosts)
+}
+
+// neverMatches reports whether a Host line can't match any alias, because it
+// only has negated patterns or negates each of its aliases. Wildcard
+// patterns are assumed to match something.
+func neverMatches(patterns []string) bool {
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			continue
+		}
+		if isPattern(p) || !negatedBy(patterns, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// negatedBy reports whether alias matches one of the negated patterns.
+func negatedBy(patterns []string, alias string) bool {
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") && matchPattern(p[1:], alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSSHJump reports whether a ProxyCommand runs ssh to forward stdin and
+// stdout, like `ssh -W %h:%p bastion`.
+func isSSHJump(command string) bool {
+	args, err := splitArgs(command)
+	if err != nil || len(args) == 0 {
+		return false
+	}
+	if name := args[0]; name != "ssh" && !strings.HasSuffix(name, "/ssh") {
+		return false
+	}
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-W") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDirective reports whether d is one of the directives of h.
+func (h *SSHHost) hasDirective(d Directive) bool {
+	for _, own := range h.Directives {
+		if own == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  User deploy
+  User admin
+
+Host !web
+  User nobody
+
+Host db !db
+  User postgres
+
+Host empty
+
+Host bastion
+  ProxyCommand ssh -W %h:%p jump.example.com
+  ProxyJump jump.example.com
+
+Host web
+  User root
+
+Host build.example.com 10.0.0.1
+  User ci
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []Finding{
+		{Rule: RuleDuplicateKeyword, Severity: SeverityWarning, File: "~/.ssh/config", Line: 4, Msg: "User has no effect, it is already set on line 3"},
+		{Rule: RuleUnreachable, Severity: SeverityWarning, File: "~/.ssh/config", Line: 6, Msg: "Host !web can never match"},
+		{Rule: RuleUnreachable, Severity: SeverityWarning, File: "~/.ssh/config", Line: 9, Msg: "Host db !db can never match"},
+		{Rule: RuleEmptyBlock, Severity: SeverityInfo, File: "~/.ssh/config", Line: 12, Msg: "block has no directives"},
+		{Rule: RuleMissingHostName, Severity: SeverityInfo, File: "~/.ssh/config", Line: 12, Msg: "empty has no HostName and is not a host name"},
+		{Rule: RuleProxyCommandJump, Severity: SeverityInfo, File: "~/.ssh/config", Line: 15, Msg: "ProxyCommand runs ssh -W, use ProxyJump instead"},
+		{Rule: RuleProxyConflict, Severity: SeverityWarning, File: "~/.ssh/config", Line: 16, Msg: "ProxyJump has no effect, ProxyCommand is set on line 15"},
+		{Rule: RuleDuplicateAlias, Severity: SeverityWarning, File: "~/.ssh/config", Line: 19, Msg: "User has no effect for web, an earlier block sets it"},
+	}
+
+	findings := NewConfig(hosts).Lint()
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %v", len(expected), len(findings), findings)
+	}
+	for i, f := range findings {
+		if f != expected[i] {
+			t.Errorf("unexpected finding %d: %v, expected %v", i, f, expected[i])
+		}
+	}
+
+	if s := findings[0].String(); s != "~/.ssh/config:4: warning: User has no effect, it is already set on line 3 (duplicate-keyword)" {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
@@ -0,0 +1,433 @@
+package sshconfig
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// LookupOption configures a call to Lookup
+type LookupOption func(*lookupOptions)
+
+type lookupOptions struct {
+	tags          []string
+	precedence    Precedence
+	resolver      HostResolver
+	merge         *mergeOptions
+	matchHostName bool
+	logger        *slog.Logger
+}
+
+// WithTags sets the tags which are active for the lookup, the same way
+// `ssh -P tag` does. They are matched by `Match tagged` criteria in addition
+// to any tag set by a Tag directive.
+func WithTags(tags ...string) LookupOption {
+	return func(o *lookupOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// Precedence is the order in which Lookup applies the blocks matching an
+// alias. The first value obtained for a keyword wins.
+type Precedence int
+
+const (
+	// PrecedenceAliases applies the Host blocks naming the alias explicitly
+	// first, regardless of where they appear, followed by the pattern and
+	// Match blocks in the order they are read, with the blocks of an
+	// included file at the place of the Include. This is the default.
+	PrecedenceAliases Precedence = iota
+	// PrecedenceOpenSSH applies all blocks in the order they are read, with
+	// the blocks of an included file at the place of the Include, exactly
+	// like ssh does.
+	PrecedenceOpenSSH
+	// PrecedenceFiles applies all blocks in the order they are read, but
+	// the blocks of the config first, followed by the blocks of the files
+	// it includes, followed by the blocks of the files those include and so
+	// on. Blocks of the config, like `Host *` at its end, thus take
+	// precedence over the blocks of included files, wherever the Include
+	// is.
+	PrecedenceFiles
+)
+
+// WithPrecedence sets the order in which blocks are applied, see
+// Precedence.
+func WithPrecedence(p Precedence) LookupOption {
+	return func(o *lookupOptions) {
+		o.precedence = p
+	}
+}
+
+// WithOpenSSHPrecedence makes the lookup apply every matching Host and Match
+// block strictly in file order so the first obtained value for each keyword
+// wins, exactly like ssh does. It is short for
+// WithPrecedence(PrecedenceOpenSSH).
+func WithOpenSSHPrecedence() LookupOption {
+	return WithPrecedence(PrecedenceOpenSSH)
+}
+
+// WithHostNameMatching makes Host patterns match the HostName resolved so
+// far as well as the alias, so `Host *.internal.corp` applies to a short
+// alias with a HostName in that domain. ssh itself only matches Host
+// patterns against the alias.
+func WithHostNameMatching() LookupOption {
+	return func(o *lookupOptions) {
+		o.matchHostName = true
+	}
+}
+
+// WithLookupLogger logs every block applied by the lookup, in the order they
+// are applied, to logger at debug level, to debug why a host resolves the
+// way it does.
+func WithLookupLogger(logger *slog.Logger) LookupOption {
+	return func(o *lookupOptions) {
+		o.logger = logger
+	}
+}
+
+// Lookup returns the effective configuration for alias. Values from Host
+// blocks naming alias explicitly take precedence, after which Host pattern
+// and Match blocks are applied in file order to fill in unset values. See
+// WithPrecedence for other orders, like ssh's own precedence.
+func Lookup(hosts []*SSHHost, alias string, opts ...LookupOption) *SSHHost {
+	result, _ := lookup(hosts, alias, opts)
+	return result
+}
+
+// lookup returns the effective configuration for alias together with the
+// blocks applied to it in the order they were applied.
+func lookup(hosts []*SSHHost, alias string, opts []LookupOption) (*SSHHost, []*SSHHost) {
+	options := &lookupOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.precedence == PrecedenceFiles {
+		hosts = slices.Clone(hosts)
+		slices.SortStableFunc(hosts, func(a, b *SSHHost) int {
+			return a.depth - b.depth
+		})
+	}
+
+	result := &SSHHost{Host: []string{alias}}
+	applied := map[*SSHHost]bool{}
+	var order []*SSHHost
+
+	apply := func(h *SSHHost) {
+		if options.logger != nil {
+			options.logger.Debug("applying block", "alias", alias, "block", blockKey(h), "file", h.SourceFile, "line", h.SourceLine)
+		}
+		mergeHosts(result, h, options.merge)
+		applied[h] = true
+		order = append(order, h)
+	}
+
+	// pass applies the blocks not applied yet which match host. The
+	// canonical pass follows host name canonicalization.
+	pass := func(host string, canonical bool) {
+		// matches reports whether the block applies given the values
+		// resolved so far. Blocks read from a file included within
+		// another block only apply if that block does as well.
+		var matches func(h *SSHHost) bool
+		matches = func(h *SSHHost) bool {
+			if h.parent != nil && !matches(h.parent) {
+				return false
+			}
+			if h.Match != nil {
+				ctx := matchContextFor(result, alias, options)
+				ctx.canonical = canonical
+				return ctx.matches(h.Match)
+			}
+			if options.matchHostName && result.HostName != "" &&
+				matchHost(h.Host, expandHostName(result.HostName, alias)) {
+				return true
+			}
+			return matchHost(h.Host, host)
+		}
+
+		if options.precedence == PrecedenceAliases {
+			for _, h := range hosts {
+				if !applied[h] && h.Match == nil && hasAlias(h.Host, host) && matches(h) {
+					apply(h)
+				}
+			}
+		}
+
+		for _, h := range hosts {
+			if !applied[h] && matches(h) {
+				apply(h)
+			}
+		}
+	}
+
+	pass(alias, false)
+
+	if options.resolver != nil {
+		name, ok, err := result.Canonicalize(context.Background(), remoteHost(result), options.resolver)
+		if err == nil && ok {
+			result.HostName = name
+			pass(name, true)
+		}
+	}
+
+	if result.HostName == "" {
+		result.HostName = alias
+	}
+	if result.Port == 0 {
+		result.Port = 22
+	}
+
+	return result, order
+}
+
+// GlobalOptions returns the directives found before the first Host or Match
+// of the config and its included files, merged in file order with the first
+// value winning. It returns nil if there are none.
+func GlobalOptions(hosts []*SSHHost) *SSHHost {
+	var global *SSHHost
+	for _, h := range hosts {
+		if !h.global {
+			continue
+		}
+		if global == nil {
+			global = &SSHHost{Host: []string{"*"}, SourceFile: h.SourceFile, SourceLine: h.SourceLine, global: true}
+		}
+		mergeSSHHost(global, h)
+	}
+	return global
+}
+
+// hasAlias reports whether alias is listed literally in hosts.
+func hasAlias(hosts []string, alias string) bool {
+	for _, h := range hosts {
+		if !isPattern(h) && h == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// matchContextFor returns the context Match criteria are evaluated in given
+// the values resolved so far.
+func matchContextFor(h *SSHHost, alias string, options *lookupOptions) matchContext {
+	ctx := matchContext{
+		host:         h.HostName,
+		originalHost: alias,
+		user:         h.User,
+		localUser:    localUser(),
+		tags:         options.tags,
+	}
+
+	if ctx.host == "" {
+		ctx.host = alias
+	}
+
+	if ctx.user == "" {
+		ctx.user = ctx.localUser
+	}
+
+	if h.Tag != "" {
+		ctx.tags = append([]string{h.Tag}, ctx.tags...)
+	}
+
+	return ctx
+}
+
+// mergeSSHHost sets every field of dst which is still unset to the value
+// from src and appends the values of keywords which may be given multiple
+// times. Map entries are merged per key. A Port of 22 is considered unset as
+// it is the parser default.
+func mergeSSHHost(dst, src *SSHHost) {
+	mergeHosts(dst, src, nil)
+}
+
+// mergeHosts merges src into dst following o, see mergeSSHHost for the
+// default without options.
+func mergeHosts(dst, src *SSHHost, o *mergeOptions) {
+	mergeValue(o, "HostName", &dst.HostName, src.HostName)
+	mergeValue(o, "User", &dst.User, src.User)
+	mergePort(o, dst, src)
+	mergeValue(o, "ProxyCommand", &dst.ProxyCommand, src.ProxyCommand)
+	mergeList(o, "HostKeyAlgorithms", &dst.HostKeyAlgorithms, src.HostKeyAlgorithms, false)
+	mergeValue(o, "IdentityFile", &dst.IdentityFile, src.IdentityFile)
+	mergeList(o, "IdentityFiles", &dst.IdentityFiles, src.IdentityFiles, true)
+	mergeList(o, "CertificateFiles", &dst.CertificateFiles, src.CertificateFiles, true)
+	mergeList(o, "LocalForwards", &dst.LocalForwards, src.LocalForwards, true)
+	mergeList(o, "RemoteForwards", &dst.RemoteForwards, src.RemoteForwards, true)
+	mergeList(o, "DynamicForwards", &dst.DynamicForwards, src.DynamicForwards, true)
+	mergeList(o, "Ciphers", &dst.Ciphers, src.Ciphers, false)
+	mergeList(o, "MACs", &dst.MACs, src.MACs, false)
+	mergeValue(o, "Tag", &dst.Tag, src.Tag)
+	mergeValue(o, "ProxyJump", &dst.ProxyJump, src.ProxyJump)
+	mergeValue(o, "ForwardAgent", &dst.ForwardAgent, src.ForwardAgent)
+	mergeValue(o, "ControlMaster", &dst.ControlMaster, src.ControlMaster)
+	mergeValue(o, "ControlPath", &dst.ControlPath, src.ControlPath)
+	mergeValue(o, "ControlPersist", &dst.ControlPersist, src.ControlPersist)
+	mergeValue(o, "ServerAliveInterval", &dst.ServerAliveInterval, src.ServerAliveInterval)
+	mergeValue(o, "ServerAliveCountMax", &dst.ServerAliveCountMax, src.ServerAliveCountMax)
+	mergeValue(o, "StrictHostKeyChecking", &dst.StrictHostKeyChecking, src.StrictHostKeyChecking)
+	mergeList(o, "UserKnownHostsFiles", &dst.UserKnownHostsFiles, src.UserKnownHostsFiles, false)
+	mergeList(o, "GlobalKnownHostsFiles", &dst.GlobalKnownHostsFiles, src.GlobalKnownHostsFiles, false)
+	mergeList(o, "PreferredAuthentications", &dst.PreferredAuthentications, src.PreferredAuthentications, false)
+	mergeValue(o, "PubkeyAuthentication", &dst.PubkeyAuthentication, src.PubkeyAuthentication)
+	mergeValue(o, "PasswordAuthentication", &dst.PasswordAuthentication, src.PasswordAuthentication)
+	mergeValue(o, "KbdInteractiveAuthentication", &dst.KbdInteractiveAuthentication, src.KbdInteractiveAuthentication)
+	mergeList(o, "KexAlgorithms", &dst.KexAlgorithms, src.KexAlgorithms, false)
+	mergeList(o, "PubkeyAcceptedAlgorithms", &dst.PubkeyAcceptedAlgorithms, src.PubkeyAcceptedAlgorithms, false)
+	mergeList(o, "SendEnv", &dst.SendEnv, src.SendEnv, true)
+	mergeMap(o, "SetEnv", &dst.SetEnv, src.SetEnv)
+	mergeValue(o, "LocalCommand", &dst.LocalCommand, src.LocalCommand)
+	mergeValue(o, "PermitLocalCommand", &dst.PermitLocalCommand, src.PermitLocalCommand)
+	mergeValue(o, "RemoteCommand", &dst.RemoteCommand, src.RemoteCommand)
+	mergeValue(o, "RequestTTY", &dst.RequestTTY, src.RequestTTY)
+	mergeValue(o, "SessionType", &dst.SessionType, src.SessionType)
+	mergeValue(o, "ForwardX11", &dst.ForwardX11, src.ForwardX11)
+	mergeValue(o, "ForwardX11Trusted", &dst.ForwardX11Trusted, src.ForwardX11Trusted)
+	mergeValue(o, "ForwardX11Timeout", &dst.ForwardX11Timeout, src.ForwardX11Timeout)
+	mergeValue(o, "CanonicalizeHostname", &dst.CanonicalizeHostname, src.CanonicalizeHostname)
+	mergeList(o, "CanonicalDomains", &dst.CanonicalDomains, src.CanonicalDomains, false)
+	mergeCanonicalizeMaxDots(o, dst, src)
+	mergeValue(o, "CanonicalizeFallbackLocal", &dst.CanonicalizeFallbackLocal, src.CanonicalizeFallbackLocal)
+	mergeList(o, "CanonicalizePermittedCNAMEs", &dst.CanonicalizePermittedCNAMEs, src.CanonicalizePermittedCNAMEs, false)
+	mergeValue(o, "HashKnownHosts", &dst.HashKnownHosts, src.HashKnownHosts)
+	mergeValue(o, "CheckHostIP", &dst.CheckHostIP, src.CheckHostIP)
+	mergeValue(o, "VerifyHostKeyDNS", &dst.VerifyHostKeyDNS, src.VerifyHostKeyDNS)
+	mergeValue(o, "TCPKeepAlive", &dst.TCPKeepAlive, src.TCPKeepAlive)
+	mergeValue(o, "Tunnel", &dst.Tunnel, src.Tunnel)
+	mergeValue(o, "TunnelDevice", &dst.TunnelDevice, src.TunnelDevice)
+	mergeValue(o, "GatewayPorts", &dst.GatewayPorts, src.GatewayPorts)
+	mergeValue(o, "ExitOnForwardFailure", &dst.ExitOnForwardFailure, src.ExitOnForwardFailure)
+	mergeValue(o, "ClearAllForwardings", &dst.ClearAllForwardings, src.ClearAllForwardings)
+	mergeValue(o, "LogLevel", &dst.LogLevel, src.LogLevel)
+	mergeValue(o, "SyslogFacility", &dst.SyslogFacility, src.SyslogFacility)
+	mergeValue(o, "BatchMode", &dst.BatchMode, src.BatchMode)
+	mergeValue(o, "NumberOfPasswordPrompts", &dst.NumberOfPasswordPrompts, src.NumberOfPasswordPrompts)
+	mergeValue(o, "EscapeChar", &dst.EscapeChar, src.EscapeChar)
+	mergeValue(o, "EnableEscapeCommandline", &dst.EnableEscapeCommandline, src.EnableEscapeCommandline)
+	mergeValue(o, "PKCS11Provider", &dst.PKCS11Provider, src.PKCS11Provider)
+	mergeValue(o, "SecurityKeyProvider", &dst.SecurityKeyProvider, src.SecurityKeyProvider)
+	mergeValue(o, "GSSAPIAuthentication", &dst.GSSAPIAuthentication, src.GSSAPIAuthentication)
+	mergeValue(o, "GSSAPIDelegateCredentials", &dst.GSSAPIDelegateCredentials, src.GSSAPIDelegateCredentials)
+	mergeValue(o, "GSSAPIKeyExchange", &dst.GSSAPIKeyExchange, src.GSSAPIKeyExchange)
+	mergeValue(o, "GSSAPITrustDns", &dst.GSSAPITrustDns, src.GSSAPITrustDns)
+	mergeValue(o, "GSSAPIClientIdentity", &dst.GSSAPIClientIdentity, src.GSSAPIClientIdentity)
+	mergeValue(o, "GSSAPIServerIdentity", &dst.GSSAPIServerIdentity, src.GSSAPIServerIdentity)
+	mergeValue(o, "RekeyLimit", &dst.RekeyLimit, src.RekeyLimit)
+	mergeValue(o, "UpdateHostKeys", &dst.UpdateHostKeys, src.UpdateHostKeys)
+	mergeList(o, "IgnoreUnknown", &dst.IgnoreUnknown, src.IgnoreUnknown, false)
+	mergeList(o, "PermitOpen", &dst.PermitOpen, src.PermitOpen, false)
+	mergeList(o, "PermitRemoteOpen", &dst.PermitRemoteOpen, src.PermitRemoteOpen, false)
+	mergeValue(o, "IdentityAgent", &dst.IdentityAgent, src.IdentityAgent)
+	mergeValue(o, "ConnectTimeout", &dst.ConnectTimeout, src.ConnectTimeout)
+	mergeValue(o, "AddKeysToAgent", &dst.AddKeysToAgent, src.AddKeysToAgent)
+	mergeValue(o, "KnownHostsCommand", &dst.KnownHostsCommand, src.KnownHostsCommand)
+	mergeValue(o, "FingerprintHash", &dst.FingerprintHash, src.FingerprintHash)
+	mergeValue(o, "RequiredRSASize", &dst.RequiredRSASize, src.RequiredRSASize)
+	mergeList(o, "ChannelTimeouts", &dst.ChannelTimeouts, src.ChannelTimeouts, false)
+	mergeValue(o, "ObscureKeystrokeTiming", &dst.ObscureKeystrokeTiming, src.ObscureKeystrokeTiming)
+	mergeValue(o, "StdinNull", &dst.StdinNull, src.StdinNull)
+	mergeValue(o, "ForkAfterAuthentication", &dst.ForkAfterAuthentication, src.ForkAfterAuthentication)
+	mergeValue(o, "ProxyUseFdpass", &dst.ProxyUseFdpass, src.ProxyUseFdpass)
+	mergeValue(o, "StreamLocalBindMask", &dst.StreamLocalBindMask, src.StreamLocalBindMask)
+	mergeValue(o, "StreamLocalBindUnlink", &dst.StreamLocalBindUnlink, src.StreamLocalBindUnlink)
+	mergeValue(o, "HostbasedAuthentication", &dst.HostbasedAuthentication, src.HostbasedAuthentication)
+	mergeList(o, "HostbasedAcceptedAlgorithms", &dst.HostbasedAcceptedAlgorithms, src.HostbasedAcceptedAlgorithms, false)
+	mergeValue(o, "NoHostAuthenticationForLocalhost", &dst.NoHostAuthenticationForLocalhost, src.NoHostAuthenticationForLocalhost)
+	mergeValue(o, "HostKeyAlias", &dst.HostKeyAlias, src.HostKeyAlias)
+	mergeMap(o, "Unknowns", &dst.Unknowns, src.Unknowns)
+	mergeMap(o, "Extensions", &dst.Extensions, src.Extensions)
+	dst.Comments = append(dst.Comments, src.Comments...)
+	mergeMap(o, "Tags", &dst.Tags, src.Tags)
+	mergeValue(o, "Section", &dst.Section, src.Section)
+	dst.Directives = append(dst.Directives, src.Directives...)
+	mergeValue(o, "SourceFile", &dst.SourceFile, src.SourceFile)
+	mergeValue(o, "SourceLine", &dst.SourceLine, src.SourceLine)
+
+	if o != nil {
+		for _, h := range o.hooks {
+			h.hook(dst, src)
+		}
+	}
+}
+
+// mergeValue sets *dst to src if it is unset, or to any set src if values
+// are overwritten.
+func mergeValue[T comparable](o *mergeOptions, field string, dst *T, src T) {
+	var zero T
+	if o.hooked(field) || src == zero {
+		return
+	}
+	if *dst == zero || o.values() == MergeOverwrite {
+		*dst = src
+	}
+}
+
+// mergeList merges the list src into *dst. Lists of keywords which may be
+// given multiple times are appended unless lists are replaced, other lists
+// are merged like values unless lists are appended. An empty list counts as
+// set.
+func mergeList[T any](o *mergeOptions, field string, dst *[]T, src []T, accumulating bool) {
+	if o.hooked(field) || src == nil {
+		return
+	}
+	lists := o.lists()
+	if lists == MergeListsAppend || (accumulating && lists == MergeListsDefault) {
+		*dst = append(*dst, src...)
+		return
+	}
+	if *dst == nil || o.values() == MergeOverwrite {
+		*dst = src
+	}
+}
+
+// mergeMap merges the entries of src into *dst like values, creating the
+// map if needed.
+func mergeMap[V any](o *mergeOptions, field string, dst *map[string]V, src map[string]V) {
+	if o.hooked(field) || len(src) == 0 {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]V, len(src))
+	}
+	for k, v := range src {
+		if _, ok := (*dst)[k]; !ok || o.values() == MergeOverwrite {
+			(*dst)[k] = v
+		}
+	}
+}
+
+// mergePort merges the port like a value, a port of 22 is taken as unset
+// unless set by a Port directive.
+func mergePort(o *mergeOptions, dst, src *SSHHost) {
+	if o.hooked("Port") || src.Port == 0 {
+		return
+	}
+	if dst.Port == 0 || (dst.Port == 22 && !dst.PortSet) {
+		dst.Port, dst.PortSet = src.Port, src.PortSet
+		return
+	}
+	if o.values() == MergeOverwrite && (src.Port != 22 || src.PortSet) {
+		dst.Port, dst.PortSet = src.Port, src.PortSet
+	}
+}
+
+// mergeCanonicalizeMaxDots merges CanonicalizeMaxDots like a value, a value
+// of 0 is taken as unset unless set by a CanonicalizeMaxDots directive.
+func mergeCanonicalizeMaxDots(o *mergeOptions, dst, src *SSHHost) {
+	if o.hooked("CanonicalizeMaxDots") || (src.CanonicalizeMaxDots == 0 && !src.CanonicalizeMaxDotsSet) {
+		return
+	}
+	if (dst.CanonicalizeMaxDots == 0 && !dst.CanonicalizeMaxDotsSet) || o.values() == MergeOverwrite {
+		dst.CanonicalizeMaxDots, dst.CanonicalizeMaxDotsSet = src.CanonicalizeMaxDots, src.CanonicalizeMaxDotsSet
+	}
+}
+
+// hasKeyword reports whether h has a directive for keyword.
+func (h *SSHHost) hasKeyword(keyword string) bool {
+	for _, d := range h.Directives {
+		if strings.EqualFold(d.Keyword, keyword) {
+			return true
+		}
+	}
+	return false
+}
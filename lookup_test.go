@@ -0,0 +1,399 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMatch(t *testing.T) {
+	config := `Host google
+  HostName google.se
+
+Match tagged prod !host *.internal
+  User deploy
+  Tag prod`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(hosts))
+	}
+
+	expected := []MatchCriterion{
+		{Keyword: "tagged", Value: "prod"},
+		{Keyword: "host", Negate: true, Value: "*.internal"},
+	}
+	if ok := reflect.DeepEqual(expected, hosts[1].Match); !ok {
+		t.Errorf("unexpected criteria: %+v", hosts[1].Match)
+	}
+
+	if hosts[1].Tag != "prod" {
+		t.Errorf("expected tag prod, got %s", hosts[1].Tag)
+	}
+}
+
+func TestParseMatchInvalid(t *testing.T) {
+	config := `Match host`
+
+	expectedErr := "~/.ssh/config:1:7: missing argument for Match host"
+
+	_, err := parse(config, "~/.ssh/config")
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err)
+	}
+}
+
+func TestLookupTagged(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+
+Match tagged prod
+  User deploy
+
+Match tagged dev,staging
+  User developer
+
+Host *
+  User nobody
+  Port 2222`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	for _, tc := range []struct {
+		tags []string
+		user string
+	}{
+		{nil, "nobody"},
+		{[]string{"prod"}, "deploy"},
+		{[]string{"staging"}, "developer"},
+	} {
+		h := Lookup(hosts, "web", WithTags(tc.tags...))
+		if h.User != tc.user {
+			t.Errorf("tags %v: expected user %s, got %s", tc.tags, tc.user, h.User)
+		}
+		if h.HostName != "web.example.com" {
+			t.Errorf("tags %v: unexpected hostname %s", tc.tags, h.HostName)
+		}
+		if h.Port != 2222 {
+			t.Errorf("tags %v: expected port 2222, got %d", tc.tags, h.Port)
+		}
+	}
+}
+
+func TestLookupTagDirective(t *testing.T) {
+	config := `Host db
+  Tag prod
+
+Match tagged prod
+  User deploy`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "db")
+	if h.User != "deploy" {
+		t.Errorf("expected user deploy, got %s", h.User)
+	}
+
+	h = Lookup(hosts, "other")
+	if h.User != "" {
+		t.Errorf("expected no user, got %s", h.User)
+	}
+	if h.HostName != "other" {
+		t.Errorf("expected hostname other, got %s", h.HostName)
+	}
+}
+
+func TestLookupNegatedPattern(t *testing.T) {
+	config := `Host bastion
+  HostName bastion.example.com
+
+Host * !bastion
+  ProxyCommand ssh -W %h:%p bastion
+
+Host !*.internal
+  User admin`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "bastion")
+	if h.ProxyCommand != "" {
+		t.Errorf("expected no proxy command for bastion, got %s", h.ProxyCommand)
+	}
+
+	h = Lookup(hosts, "web")
+	if h.ProxyCommand != "ssh -W %h:%p bastion" {
+		t.Errorf("unexpected proxy command for web: %s", h.ProxyCommand)
+	}
+
+	// a pattern list with only negations never matches
+	if h.User != "" {
+		t.Errorf("expected no user for web, got %s", h.User)
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	for _, tc := range []struct {
+		patterns []string
+		host     string
+		expected bool
+	}{
+		{[]string{"*.example.com"}, "web.example.com", true},
+		{[]string{"*.example.com"}, "web.exampleXcom", false},
+		{[]string{"*", "!web"}, "web", false},
+		{[]string{"*", "!web"}, "db", true},
+		{[]string{"!web"}, "db", false},
+		{[]string{"web?"}, "web1", true},
+		{[]string{"web?"}, "web12", false},
+		{[]string{"*.*.com"}, "a.b.com", true},
+		{[]string{"a*b*c"}, "axxbyybzc", true},
+		{[]string{"a*b*c"}, "axxbyybz", false},
+		{[]string{"10.0.?.*"}, "10.0.1.25", true},
+		{[]string{"[a-z]+"}, "web", false},
+		{[]string{"[a-z]+"}, "[a-z]+", true},
+	} {
+		if actual := matchHost(tc.patterns, tc.host); actual != tc.expected {
+			t.Errorf("matchHost(%v, %s): expected %t, got %t", tc.patterns, tc.host, tc.expected, actual)
+		}
+	}
+}
+
+func TestMatchPatternList(t *testing.T) {
+	if !matchPatternList("db,web?,!web2", "web1") {
+		t.Error("expected web1 to match")
+	}
+	if matchPatternList("db,web?,!web2", "web2") {
+		t.Error("expected web2 not to match")
+	}
+	if matchPatternList("db,web?", "api") {
+		t.Error("expected api not to match")
+	}
+}
+
+func TestLookupOpenSSHPrecedence(t *testing.T) {
+	config := `Host *
+  User nobody
+
+Host web
+  HostName web.example.com
+  User deploy
+  Port 2222`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "web")
+	if h.User != "deploy" {
+		t.Errorf("expected user deploy, got %s", h.User)
+	}
+
+	h = Lookup(hosts, "web", WithOpenSSHPrecedence())
+	if h.User != "nobody" {
+		t.Errorf("expected user nobody, got %s", h.User)
+	}
+	if h.HostName != "web.example.com" || h.Port != 2222 {
+		t.Errorf("unexpected host: %+v", h)
+	}
+}
+
+func TestLookupPrecedence(t *testing.T) {
+	resolver := mapResolver{
+		"/ssh/team.conf": "Host *\n  User team\n  Port 2200\n\nHost web\n  HostName web.team.example.com\n",
+	}
+	config := `Include team.conf
+
+Host web
+  HostName web.example.com
+
+Host *
+  User me
+`
+	hosts, err := ParseString(config, "/ssh/config", WithIncludeResolver(resolver))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	for _, tc := range []struct {
+		precedence Precedence
+		hostName   string
+		user       string
+	}{
+		{PrecedenceAliases, "web.team.example.com", "team"},
+		{PrecedenceOpenSSH, "web.team.example.com", "team"},
+		{PrecedenceFiles, "web.example.com", "me"},
+	} {
+		h := Lookup(hosts, "web", WithPrecedence(tc.precedence))
+		if h.HostName != tc.hostName || h.User != tc.user || h.Port != 2200 {
+			t.Errorf("precedence %d: unexpected host %s, %s, %d", tc.precedence, h.HostName, h.User, h.Port)
+		}
+	}
+
+	if h := Lookup(hosts, "db", WithPrecedence(PrecedenceFiles)); h.User != "me" {
+		t.Errorf("expected user me, got %s", h.User)
+	}
+}
+
+func TestLookupAccumulatesIdentityFiles(t *testing.T) {
+	config := `Host web
+  IdentityFile ~/.ssh/web
+
+Host *
+  IdentityFile ~/.ssh/id_ed25519`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []string{"~/.ssh/web", "~/.ssh/id_ed25519"}
+	h := Lookup(hosts, "web")
+	if ok := reflect.DeepEqual(expected, h.IdentityFiles); !ok {
+		t.Errorf("unexpected identity files: %v", h.IdentityFiles)
+	}
+}
+
+func TestLookupUnknowns(t *testing.T) {
+	config := `Host web
+  VisualHostKey yes
+
+Host *
+  VisualHostKey no
+  Compression yes`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := map[string][]string{
+		"VisualHostKey": {"yes"},
+		"Compression":   {"yes"},
+	}
+	h := Lookup(hosts, "web")
+	if ok := reflect.DeepEqual(expected, h.Unknowns); !ok {
+		t.Errorf("unexpected unknowns: %v", h.Unknowns)
+	}
+
+	if len(hosts[0].Unknowns) != 1 {
+		t.Errorf("lookup modified parsed host: %v", hosts[0].Unknowns)
+	}
+}
+
+func TestMergeSSHHostFields(t *testing.T) {
+	// every field set on src has to end up on an empty dst
+	src := &SSHHost{}
+	sv := reflect.ValueOf(src).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		field := sv.Field(i)
+		if !sv.Type().Field(i).IsExported() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString("value")
+		case reflect.Int, reflect.Int64:
+			field.SetInt(2222)
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+		case reflect.Map:
+			field.Set(reflect.MakeMap(field.Type()))
+			field.SetMapIndex(reflect.ValueOf("key"), reflect.Zero(field.Type().Elem()))
+		case reflect.Pointer:
+			field.Set(reflect.New(field.Type().Elem()))
+		default:
+			t.Fatalf("unexpected kind of field %s", sv.Type().Field(i).Name)
+		}
+	}
+
+	dst := &SSHHost{}
+	mergeSSHHost(dst, src)
+
+	dv := reflect.ValueOf(dst).Elem()
+	for i := 0; i < dv.NumField(); i++ {
+		name := dv.Type().Field(i).Name
+		if name == "Host" || name == "Match" || !dv.Type().Field(i).IsExported() {
+			continue
+		}
+		if dv.Field(i).IsZero() {
+			t.Errorf("field %s is not merged", name)
+		}
+	}
+
+	// set values are kept, lists of keywords given multiple times collected
+	dst = &SSHHost{HostName: "kept", Port: 22, Ciphers: []string{"aes128-ctr"}, IdentityFiles: []string{"a"}, SetEnv: map[string]string{"key": "kept"}}
+	mergeSSHHost(dst, src)
+	if dst.HostName != "kept" || dst.Port != 2222 || len(dst.Ciphers) != 1 || len(dst.IdentityFiles) != 2 || dst.SetEnv["key"] != "kept" {
+		t.Errorf("unexpected merged host: %+v", dst)
+	}
+}
+
+func TestLookupHostNameMatching(t *testing.T) {
+	config := `Host db
+  HostName db.internal.corp
+
+Host app
+  HostName %h.internal.corp
+
+Host *.internal.corp
+  User ops
+  ProxyJump bastion
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if db := Lookup(hosts, "db"); db.User != "" {
+		t.Errorf("expected pattern not to match the HostName by default: %+v", db)
+	}
+
+	for _, alias := range []string{"db", "app"} {
+		h := Lookup(hosts, alias, WithHostNameMatching())
+		if h.User != "ops" || h.ProxyJump != "bastion" {
+			t.Errorf("expected pattern to match the HostName of %s: %+v", alias, h)
+		}
+	}
+}
+
+func TestMatchesPattern(t *testing.T) {
+	for _, tc := range []struct {
+		patterns string
+		name     string
+		expected bool
+	}{
+		{"web", "web", true},
+		{"web", "Web", false},
+		{"*.example.com", "db.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"db?", "db1", true},
+		{"db?", "db10", false},
+		{"*.example.com,!secret.example.com", "secret.example.com", false},
+		{"*.example.com,!secret.example.com", "web.example.com", true},
+		{"!web", "db", false},
+		{"10.0.0.*,192.168.*", "192.168.1.1", true},
+		{"a.b", "axb", false},
+		{"a*b", "a*xb", true},
+		{"a*", "a*", true},
+		{"", "web", false},
+	} {
+		if got := MatchesPattern(tc.patterns, tc.name); got != tc.expected {
+			t.Errorf("MatchesPattern(%#v, %#v) = %v, expected %v", tc.patterns, tc.name, got, tc.expected)
+		}
+	}
+}
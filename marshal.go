@@ -0,0 +1,177 @@
+package sshconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// MarshalText implements encoding.TextMarshaler. The host is rendered as a
+// Host or Match block with every keyword set on it, which can be appended
+// to a config file. The default Port of 22 is left out unless PortSet
+// tells it was given by a Port directive.
+func (h *SSHHost) MarshalText() ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeHost(&b, h, newWriteOptions(nil)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// MarshalJSON encodes the host as a JSON object with its fields, instead of
+// the text block MarshalText would give. Unset fields are left out, forwards
+// are given as strings in the form used in config files.
+func (h *SSHHost) MarshalJSON() ([]byte, error) {
+	type plain SSHHost
+	return json.Marshal((*plain)(h))
+}
+
+// UnmarshalJSON decodes a host encoded by MarshalJSON. IdentityFile is set
+// to the last of IdentityFiles if it is not given, like the parser does.
+func (h *SSHHost) UnmarshalJSON(data []byte) error {
+	type plain SSHHost
+	if err := json.Unmarshal(data, (*plain)(h)); err != nil {
+		return err
+	}
+	if h.IdentityFile == "" && len(h.IdentityFiles) > 0 {
+		h.IdentityFile = h.IdentityFiles[len(h.IdentityFiles)-1]
+	}
+	return nil
+}
+
+// MarshalYAML encodes the host as a YAML mapping with its fields, instead
+// of the text block MarshalText would give.
+func (h *SSHHost) MarshalYAML() (interface{}, error) {
+	type plain SSHHost
+	return (*plain)(h), nil
+}
+
+// UnmarshalYAML decodes a host encoded by MarshalYAML, see UnmarshalJSON.
+func (h *SSHHost) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SSHHost
+	if err := unmarshal((*plain)(h)); err != nil {
+		return err
+	}
+	if h.IdentityFile == "" && len(h.IdentityFiles) > 0 {
+		h.IdentityFile = h.IdentityFiles[len(h.IdentityFiles)-1]
+	}
+	return nil
+}
+
+// MarshalJSON encodes the forward as a string, see String.
+func (f Forward) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON decodes a forward from a string as written in a config file.
+func (f *Forward) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	forward, err := newRemoteForward(s)
+	if err != nil {
+		return err
+	}
+	*f = forward
+	return nil
+}
+
+// MarshalJSON encodes the dynamic forward as a string, see String.
+func (f DynamicForward) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON decodes a dynamic forward from a string as written in a
+// config file.
+func (f *DynamicForward) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	forward, err := NewDynamicForward(s)
+	if err != nil {
+		return err
+	}
+	*f = forward
+	return nil
+}
+
+// String returns the criterion as written on a Match line.
+func (c MatchCriterion) String() string {
+	s := c.Keyword
+	if c.Negate {
+		s = "!" + s
+	}
+	if c.Value != "" {
+		s += " " + quoteArg(c.Value)
+	}
+	return s
+}
+
+// writeHost writes the block of h to b.
+func writeHost(b *bytes.Buffer, h *SSHHost, o *writeOptions) error {
+	switch {
+	case h.Match != nil:
+		b.WriteString(o.keyword("Match"))
+		for _, c := range h.Match {
+			b.WriteString(" " + c.String())
+		}
+	case len(h.Host) > 0:
+		b.WriteString(o.keyword("Host"))
+		for _, alias := range h.Host {
+			b.WriteString(" " + quoteArg(alias))
+		}
+	default:
+		return errors.New("host has neither aliases nor Match criteria")
+	}
+	b.WriteByte('\n')
+
+	var directives []Directive
+	for _, keyword := range keywords {
+		typ := variables[strings.ToLower(keyword)]
+		if typ == itemPort && h.Port == 22 && !h.PortSet && !o.defaults {
+			continue
+		}
+		for _, value := range h.GetAll(keyword) {
+			directives = append(directives, Directive{Keyword: keyword, Value: directiveValue(typ, value)})
+		}
+	}
+
+	unknowns := make([]string, 0, len(h.Unknowns))
+	for keyword := range h.Unknowns {
+		unknowns = append(unknowns, keyword)
+	}
+	sort.Strings(unknowns)
+	for _, keyword := range unknowns {
+		for _, value := range h.Unknowns[keyword] {
+			directives = append(directives, Directive{Keyword: keyword, Value: value})
+		}
+	}
+
+	width := 0
+	if o.align {
+		for _, d := range directives {
+			width = max(width, len(d.Keyword))
+		}
+	}
+	for _, d := range directives {
+		keyword := o.keyword(d.Keyword)
+		if width > len(keyword) {
+			keyword += strings.Repeat(" ", width-len(keyword))
+		}
+		b.WriteString(o.indent + keyword + " " + d.Value + "\n")
+	}
+	return nil
+}
+
+// directiveValue returns the value of a directive as written in a config,
+// quoting it unless it is a command or already made up of quoted arguments.
+func directiveValue(typ itemType, value string) string {
+	if !commandKeywords[typ] && !multiArgKeywords[typ] && typ != itemUnknown {
+		return quoteArg(value)
+	}
+	return value
+}
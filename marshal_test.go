@@ -0,0 +1,190 @@
+package sshconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshalText(t *testing.T) {
+	config := `Host web "my server"
+  HostName web.example.com
+  User deploy
+  Port 2222
+  IdentityFile "~/keys/my key"
+  IdentityFile ~/.ssh/id_ed25519
+  LocalForward 8080 localhost:80
+  UserKnownHostsFile "~/.ssh/known hosts" ~/.ssh/known_hosts2
+  ProxyCommand ssh -W %h:%p "jump host"
+  SetEnv GREETING="hello world"
+  UseKeychain yes
+
+Match host *.internal !user root
+  User admin
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := `Host web "my server"
+  HostName web.example.com
+  User deploy
+  Port 2222
+  ProxyCommand ssh -W %h:%p "jump host"
+  IdentityFile "~/keys/my key"
+  IdentityFile ~/.ssh/id_ed25519
+  LocalForward 8080 localhost:80
+  UserKnownHostsFile "~/.ssh/known hosts" ~/.ssh/known_hosts2
+  SetEnv "GREETING=hello world"
+  UseKeychain yes
+`
+	text, err := hosts[0].MarshalText()
+	if err != nil {
+		t.Fatalf("unable to marshal host: %s", err.Error())
+	}
+	if string(text) != expected {
+		t.Errorf("unexpected text:\n%s\nexpected:\n%s", text, expected)
+	}
+
+	text, err = hosts[1].MarshalText()
+	if err != nil {
+		t.Fatalf("unable to marshal host: %s", err.Error())
+	}
+	if string(text) != "Match host *.internal !user root\n  User admin\n" {
+		t.Errorf("unexpected text:\n%s", text)
+	}
+
+	// the rendered blocks parse back into the same hosts
+	for _, h := range hosts {
+		text, _ := h.MarshalText()
+		parsed, err := parse(string(text), "~/.ssh/config")
+		if err != nil {
+			t.Fatalf("unable to parse rendered host: %s", err.Error())
+		}
+		compare(t, []*SSHHost{h}, parsed)
+	}
+
+	if _, err := (&SSHHost{}).MarshalText(); err == nil {
+		t.Errorf("expected error for host without aliases")
+	}
+
+	// JSON still encodes the fields
+	b, err := json.Marshal(hosts[1])
+	if err != nil {
+		t.Fatalf("unable to marshal JSON: %s", err.Error())
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil || fields["user"] != "admin" {
+		t.Errorf("unexpected JSON: %s", b)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  IdentityFile ~/.ssh/a
+  IdentityFile ~/.ssh/b
+  LocalForward [::1]:8080 localhost:80
+  RemoteForward 9090
+  DynamicForward *:1080
+  RekeyLimit 1G 1h
+  RequestTTY force`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	b, err := json.Marshal(hosts[0])
+	if err != nil {
+		t.Fatalf("unable to marshal JSON: %s", err.Error())
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatalf("unable to unmarshal JSON: %s", err.Error())
+	}
+	expected := map[string]interface{}{
+		"localForwards":   []interface{}{"[::1]:8080 localhost:80"},
+		"remoteForwards":  []interface{}{"9090"},
+		"dynamicForwards": []interface{}{"*:1080"},
+		"rekeyLimit":      "1G 1h",
+		"requestTTY":      "force",
+		"port":            float64(22),
+	}
+	for k, v := range expected {
+		if !reflect.DeepEqual(fields[k], v) {
+			t.Errorf("unexpected %s: %#v", k, fields[k])
+		}
+	}
+	if _, ok := fields["certificateFiles"]; ok {
+		t.Errorf("expected unset fields to be left out: %s", b)
+	}
+
+	var h SSHHost
+	if err := json.Unmarshal(b, &h); err != nil {
+		t.Fatalf("unable to unmarshal host: %s", err.Error())
+	}
+	if !reflect.DeepEqual(&h, hosts[0]) {
+		t.Errorf("unexpected host after round trip:\n%+v\nexpected:\n%+v", h, hosts[0])
+	}
+
+	// IdentityFile follows IdentityFiles when left out
+	if err := json.Unmarshal([]byte(`{"identityFiles":["~/.ssh/a","~/.ssh/b"]}`), &h); err != nil || h.IdentityFile != "~/.ssh/b" {
+		t.Errorf("unexpected IdentityFile: %s %v", h.IdentityFile, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"localForwards":["bogus"]}`), &h); err == nil {
+		t.Errorf("expected error for invalid forward")
+	}
+}
+
+func TestYAML(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  IdentityFile ~/.ssh/a
+  LocalForward [::1]:8080 localhost:80
+  RemoteForward /tmp/agent.sock /run/agent.sock
+  DynamicForward *:1080
+  RekeyLimit 1G 1h
+  SetEnv GREETING=hello
+
+Match host *.internal
+  User admin`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	b, err := yaml.Marshal(hosts)
+	if err != nil {
+		t.Fatalf("unable to marshal YAML: %s", err.Error())
+	}
+
+	var fields []map[string]interface{}
+	if err := yaml.Unmarshal(b, &fields); err != nil {
+		t.Fatalf("unable to unmarshal YAML: %s", err.Error())
+	}
+	forward := map[string]interface{}{"inHost": "::1", "inPort": 8080, "outHost": "localhost", "outPort": 80}
+	if !reflect.DeepEqual(fields[0]["localForwards"], []interface{}{forward}) {
+		t.Errorf("unexpected localForwards: %#v", fields[0]["localForwards"])
+	}
+	if fields[0]["rekeyLimit"] != "1G 1h" || fields[1]["user"] != "admin" {
+		t.Errorf("unexpected YAML:\n%s", b)
+	}
+
+	var decoded []*SSHHost
+	if err := yaml.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unable to unmarshal hosts: %s", err.Error())
+	}
+	if !reflect.DeepEqual(decoded[0], hosts[0]) {
+		t.Errorf("unexpected host after round trip:\n%+v\nexpected:\n%+v", decoded[0], hosts[0])
+	}
+	// an empty Host list of a Match block is left out
+	compare(t, hosts, decoded)
+}
@@ -0,0 +1,168 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchCriterion defines a single criterion of a Match block
+type MatchCriterion struct {
+	Keyword string `json:"keyword" yaml:"keyword"`
+	Negate  bool   `json:"negate,omitempty" yaml:"negate,omitempty"`
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// parseMatch parses the arguments of a Match directive into its criteria.
+func parseMatch(value string) ([]MatchCriterion, error) {
+	fields, err := splitArgs(value)
+	if err != nil {
+		return nil, err
+	}
+	criteria := []MatchCriterion{}
+
+	for i := 0; i < len(fields); i++ {
+		c := MatchCriterion{Keyword: strings.ToLower(fields[i])}
+		if strings.HasPrefix(c.Keyword, "!") {
+			c.Negate = true
+			c.Keyword = c.Keyword[1:]
+		}
+
+		switch c.Keyword {
+		case "all", "canonical", "final":
+			// no argument
+		case "host", "originalhost", "user", "localuser", "exec", "tagged":
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("missing argument for Match %s", c.Keyword)
+			}
+			i++
+			c.Value = fields[i]
+		default:
+			return nil, fmt.Errorf("unsupported Match criteria %s", fields[i])
+		}
+
+		criteria = append(criteria, c)
+	}
+
+	if len(criteria) == 0 {
+		return nil, fmt.Errorf("missing Match criteria")
+	}
+
+	return criteria, nil
+}
+
+// matchContext holds the values Match criteria are evaluated against.
+type matchContext struct {
+	host         string
+	originalHost string
+	user         string
+	localUser    string
+	tags         []string
+	// canonical is set after the host name was canonicalized
+	canonical bool
+}
+
+// matches reports whether all criteria of a Match block are fulfilled.
+// `exec` criteria are never run and thus never match, `canonical` only
+// matches after the host name was canonicalized.
+func (ctx matchContext) matches(criteria []MatchCriterion) bool {
+	for _, c := range criteria {
+		var ok bool
+		switch c.Keyword {
+		case "all", "final":
+			ok = true
+		case "canonical":
+			ok = ctx.canonical
+		case "host":
+			ok = matchPatternList(c.Value, ctx.host)
+		case "originalhost":
+			ok = matchPatternList(c.Value, ctx.originalHost)
+		case "user":
+			ok = matchPatternList(c.Value, ctx.user)
+		case "localuser":
+			ok = matchPatternList(c.Value, ctx.localUser)
+		case "tagged":
+			for _, tag := range ctx.tags {
+				if matchPatternList(c.Value, tag) {
+					ok = true
+					break
+				}
+			}
+		}
+
+		if ok == c.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesPattern reports whether name matches the comma separated pattern
+// list the way ssh matches Host lines and Match criteria: `*` matches any
+// sequence of characters, `?` exactly one, and a name matching a negated
+// `!pattern` entry never matches, so a list of only negated patterns never
+// matches either. Matching is case-sensitive.
+func MatchesPattern(patternList, name string) bool {
+	return matchPatternList(patternList, name)
+}
+
+// matchPatternList reports whether s matches the comma separated pattern
+// list.
+func matchPatternList(patterns string, s string) bool {
+	return matchHost(strings.Split(patterns, ","), s)
+}
+
+// matchHost reports whether s matches any of the patterns without matching
+// a negated `!pattern` entry. A negated match always wins, so a list made of
+// only negated patterns never matches.
+func matchHost(patterns []string, s string) bool {
+	matched := false
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			if matchPattern(p[1:], s) {
+				return false
+			}
+			continue
+		}
+
+		if matchPattern(p, s) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchPattern reports whether s matches pattern where `*` matches any
+// sequence of characters and `?` matches exactly one character. All other
+// characters, including regular expression metacharacters, match literally.
+func matchPattern(pattern string, s string) bool {
+	p, i := 0, 0
+	star, next := -1, 0
+
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			// remember the star and first try to match it as empty
+			star, next = p, i
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case star >= 0:
+			// backtrack and let the last star absorb one more character
+			next++
+			p, i = star+1, next
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// isPattern reports whether a Host entry is a pattern rather than an alias.
+func isPattern(host string) bool {
+	return strings.HasPrefix(host, "!") || strings.ContainsAny(host, "*?")
+}
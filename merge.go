@@ -0,0 +1,179 @@
+package sshconfig
+
+import (
+	"strings"
+)
+
+// MergeStrategy is how Merge combines blocks of different configs.
+type MergeStrategy int
+
+const (
+	// MergeAppend concatenates the configs. As ssh uses the first value
+	// obtained for a keyword, the blocks of earlier configs take precedence
+	// where they set the same keyword.
+	MergeAppend MergeStrategy = iota
+	// MergeReplace drops a block if an earlier config has a block with the
+	// same Host patterns or Match criteria.
+	MergeReplace
+	// MergeCombine merges blocks with the same Host patterns or Match
+	// criteria into the first of them. Values of earlier configs win,
+	// values of keywords which may be given multiple times are collected.
+	MergeCombine
+)
+
+// Merge combines configs, given from highest to lowest precedence, like a
+// user's config followed by a team config and a generated one. Blocks keep
+// the order of the configs they come from. The hosts of the configs are
+// shared with the result unless blocks are combined.
+func Merge(strategy MergeStrategy, configs ...[]*SSHHost) []*SSHHost {
+	var merged []*SSHHost
+	blocks := map[string]int{}
+
+	for _, hosts := range configs {
+		for _, h := range hosts {
+			key := blockKey(h)
+			i, ok := blocks[key]
+			switch {
+			case !ok || strategy == MergeAppend:
+				blocks[key] = len(merged)
+				merged = append(merged, h)
+			case strategy == MergeCombine:
+				merged[i] = combine(merged[i], h)
+			}
+		}
+	}
+
+	return merged
+}
+
+// blockKey returns the Host patterns or Match criteria of h as a string
+// identifying blocks which apply to the same hosts.
+func blockKey(h *SSHHost) string {
+	if h.Match != nil {
+		criteria := make([]string, len(h.Match))
+		for i, c := range h.Match {
+			criteria[i] = c.String()
+		}
+		return "Match " + strings.Join(criteria, " ")
+	}
+	return "Host " + strings.Join(h.Host, " ")
+}
+
+// combine returns a new block with the values of dst, and the values of src
+// for keywords dst doesn't set.
+func combine(dst, src *SSHHost) *SSHHost {
+	h := &SSHHost{}
+	mergeSSHHost(h, dst)
+	mergeSSHHost(h, src)
+	h.Host = dst.Host
+	h.Match = dst.Match
+	h.parent = dst.parent
+	h.depth = dst.depth
+	h.global = dst.global
+	return h
+}
+
+// MergeValues is how Lookup combines the values of matching blocks.
+type MergeValues int
+
+const (
+	// MergeKeepExisting keeps the first value obtained, like ssh does.
+	MergeKeepExisting MergeValues = iota
+	// MergeOverwrite replaces values by the ones of blocks applied later.
+	MergeOverwrite
+)
+
+// MergeLists is how Lookup combines lists of matching blocks.
+type MergeLists int
+
+const (
+	// MergeListsDefault appends lists of keywords which may be given
+	// multiple times, like IdentityFile, and merges other lists, like
+	// Ciphers, as values.
+	MergeListsDefault MergeLists = iota
+	// MergeListsAppend appends every list.
+	MergeListsAppend
+	// MergeListsReplace merges every list as a value, so a list of a single
+	// block is used.
+	MergeListsReplace
+)
+
+// MergeHook merges a field of src into dst, replacing the default merge of
+// the field.
+type MergeHook func(dst, src *SSHHost)
+
+type mergeOptions struct {
+	valueMode MergeValues
+	listMode  MergeLists
+	hooks     []fieldHook
+}
+
+type fieldHook struct {
+	field string
+	hook  MergeHook
+}
+
+// values returns how values are merged, o may be nil.
+func (o *mergeOptions) values() MergeValues {
+	if o == nil {
+		return MergeKeepExisting
+	}
+	return o.valueMode
+}
+
+// lists returns how lists are merged, o may be nil.
+func (o *mergeOptions) lists() MergeLists {
+	if o == nil {
+		return MergeListsDefault
+	}
+	return o.listMode
+}
+
+// hooked reports whether a hook replaces the merge of field.
+func (o *mergeOptions) hooked(field string) bool {
+	if o == nil {
+		return false
+	}
+	for _, h := range o.hooks {
+		if h.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeOption returns a LookupOption changing the merge options.
+func mergeOption(fn func(*mergeOptions)) LookupOption {
+	return func(o *lookupOptions) {
+		if o.merge == nil {
+			o.merge = &mergeOptions{}
+		}
+		fn(o.merge)
+	}
+}
+
+// WithMergeValues sets how Lookup combines values of the blocks matching an
+// alias. It defaults to MergeKeepExisting.
+func WithMergeValues(mode MergeValues) LookupOption {
+	return mergeOption(func(o *mergeOptions) {
+		o.valueMode = mode
+	})
+}
+
+// WithMergeLists sets how Lookup combines lists of the blocks matching an
+// alias. It defaults to MergeListsDefault.
+func WithMergeLists(mode MergeLists) LookupOption {
+	return mergeOption(func(o *mergeOptions) {
+		o.listMode = mode
+	})
+}
+
+// WithMergeHook makes Lookup call hook to merge the SSHHost field with the
+// given name, like "IdentityFiles", for every matching block instead of
+// merging it itself. The hook is called with the values resolved so far as
+// dst and the matching block as src.
+func WithMergeHook(field string, hook MergeHook) LookupOption {
+	return mergeOption(func(o *mergeOptions) {
+		o.hooks = append(o.hooks, fieldHook{field: field, hook: hook})
+	})
+}
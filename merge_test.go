@@ -0,0 +1,112 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	user, err := parse(`Host web
+  User alice
+  IdentityFile ~/.ssh/alice
+`, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	team, err := parse(`Host web
+  HostName web.example.com
+  User deploy
+  IdentityFile ~/.ssh/team
+
+Host *
+  ServerAliveInterval 30
+`, "/etc/team/ssh_config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	merged := Merge(MergeAppend, user, team)
+	if len(merged) != 3 || merged[0] != user[0] || merged[1] != team[0] || merged[2] != team[1] {
+		t.Errorf("unexpected appended hosts: %v", merged)
+	}
+	web := Lookup(merged, "web")
+	if web.User != "alice" || web.HostName != "web.example.com" {
+		t.Errorf("unexpected host: %+v", web)
+	}
+
+	merged = Merge(MergeReplace, user, team)
+	if len(merged) != 2 || merged[0] != user[0] || merged[1] != team[1] {
+		t.Errorf("unexpected replaced hosts: %v", merged)
+	}
+
+	merged = Merge(MergeCombine, user, team)
+	if len(merged) != 2 || merged[1] != team[1] {
+		t.Fatalf("unexpected combined hosts: %v", merged)
+	}
+	combined := merged[0]
+	if combined.User != "alice" || combined.HostName != "web.example.com" || combined.SourceFile != "~/.ssh/config" {
+		t.Errorf("unexpected combined host: %+v", combined)
+	}
+	if len(combined.IdentityFiles) != 2 || combined.IdentityFiles[0] != "~/.ssh/alice" {
+		t.Errorf("unexpected identity files: %v", combined.IdentityFiles)
+	}
+	if user[0].HostName != "" {
+		t.Errorf("merge changed its input")
+	}
+}
+
+func TestLookupMergeStrategies(t *testing.T) {
+	config := `Host web
+  User deploy
+  Ciphers aes128-ctr
+  IdentityFile ~/.ssh/web
+
+Host *
+  User nobody
+  Port 2222
+  Ciphers aes256-ctr
+  IdentityFile ~/.ssh/default
+  SetEnv TEAM=ops
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	web := Lookup(hosts, "web")
+	if web.User != "deploy" || web.Port != 2222 || len(web.Ciphers) != 1 || web.Ciphers[0] != "aes128-ctr" || len(web.IdentityFiles) != 2 {
+		t.Errorf("unexpected default merge: %+v", web)
+	}
+
+	web = Lookup(hosts, "web", WithMergeValues(MergeOverwrite))
+	if web.User != "nobody" || web.Port != 2222 || web.Ciphers[0] != "aes256-ctr" || len(web.IdentityFiles) != 2 {
+		t.Errorf("unexpected overwriting merge: %+v", web)
+	}
+
+	web = Lookup(hosts, "web", WithMergeLists(MergeListsAppend))
+	if len(web.Ciphers) != 2 || web.Ciphers[1] != "aes256-ctr" || len(web.IdentityFiles) != 2 {
+		t.Errorf("unexpected appending merge: %+v", web)
+	}
+
+	web = Lookup(hosts, "web", WithMergeLists(MergeListsReplace))
+	if len(web.IdentityFiles) != 1 || web.IdentityFiles[0] != "~/.ssh/web" {
+		t.Errorf("unexpected replacing merge: %+v", web)
+	}
+
+	web = Lookup(hosts, "web", WithMergeHook("SetEnv", func(dst, src *SSHHost) {
+		for k, v := range src.SetEnv {
+			if dst.SetEnv == nil {
+				dst.SetEnv = map[string]string{}
+			}
+			dst.SetEnv[k] = "hooked-" + v
+		}
+	}))
+	if web.SetEnv["TEAM"] != "hooked-ops" || web.User != "deploy" {
+		t.Errorf("unexpected hooked merge: %+v", web)
+	}
+
+	// the parsed hosts are left alone
+	if len(hosts[0].Ciphers) != 1 || len(hosts[0].IdentityFiles) != 1 {
+		t.Errorf("lookup changed the parsed hosts: %+v", hosts[0])
+	}
+}
@@ -0,0 +1,121 @@
+package sshconfig
+
+import (
+	"errors"
+	"strings"
+)
+
+// protocol1Ciphers maps the ciphers of the Cipher keyword to their
+// equivalent for Ciphers, des has none.
+var protocol1Ciphers = map[string]string{
+	"3des":     "3des-cbc",
+	"blowfish": "blowfish-cbc",
+}
+
+// Modernize returns src with deprecated keywords rewritten to their current
+// equivalent, like ChallengeResponseAuthentication to
+// KbdInteractiveAuthentication or Cipher to Ciphers, and the lines of
+// keywords OpenSSH ignores, like Protocol or UseRoaming, removed. Only
+// these directives are changed, everything else is kept as written.
+//
+// An error is returned if src can not be parsed.
+func Modernize(src []byte) ([]byte, error) {
+	content := string(src)
+
+	var edits []textEdit
+
+	lexer := lex(content)
+	defer lexer.drain()
+	for {
+		token := lexer.nextItem()
+		switch token.typ {
+		case itemEOF:
+			return applyEdits(content, edits), nil
+		case itemError:
+			return nil, newParseError(content, "", token, "", errors.New(token.val))
+		}
+
+		value := lexer.nextItem()
+		if value.typ != itemValue && value.typ != itemHostValue {
+			return nil, newParseError(content, "", value, token.val, valueError(token, value))
+		}
+		if token.typ != itemUnknown {
+			continue
+		}
+		replacement, ok := deprecatedKeywords[strings.ToLower(token.val)]
+		if !ok {
+			continue
+		}
+
+		keywordEnd := int(token.pos) + len(token.val)
+		if strings.EqualFold(token.val, "Cipher") {
+			ciphers, ok := modernCiphers(value.val)
+			if !ok {
+				replacement = ""
+			} else {
+				valueEnd := int(value.pos) + argsEnd(value.val, false)
+				edits = append(edits,
+					textEdit{int(token.pos), keywordEnd, replacement},
+					textEdit{int(value.pos), valueEnd, ciphers})
+				continue
+			}
+		}
+
+		if replacement != "" {
+			edits = append(edits, textEdit{int(token.pos), keywordEnd, replacement})
+			continue
+		}
+
+		// remove the whole line of a keyword without replacement
+		start := strings.LastIndexByte(content[:token.pos], '\n') + 1
+		end := len(content)
+		if i := strings.IndexByte(content[value.pos:], '\n'); i >= 0 {
+			end = int(value.pos) + i + 1
+		}
+		edits = append(edits, textEdit{start, end, ""})
+	}
+}
+
+// textEdit replaces content[start:end] by text.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// applyEdits returns content with edits applied, which are ordered by
+// position and don't overlap.
+func applyEdits(content string, edits []textEdit) []byte {
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.WriteString(content[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(content[last:])
+	return []byte(b.String())
+}
+
+// modernCiphers returns the value of Ciphers equivalent to the value of a
+// Cipher directive. It reports false if there is none.
+func modernCiphers(value string) (string, bool) {
+	args, err := splitArgs(value)
+	if err != nil || len(args) != 1 {
+		return "", false
+	}
+	cipher, ok := protocol1Ciphers[strings.ToLower(args[0])]
+	return cipher, ok
+}
+
+// Modernize rewrites deprecated keywords of the config, see Modernize.
+func (e *Editor) Modernize() error {
+	out, err := Modernize([]byte(e.content))
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.File = e.path
+		}
+		return err
+	}
+	return e.reset(string(out))
+}
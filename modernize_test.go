@@ -0,0 +1,57 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestModernize(t *testing.T) {
+	src := `# legacy defaults
+Protocol 2
+Host web
+  HostName web.example.com # primary
+  ChallengeResponseAuthentication=no
+  Cipher blowfish # fast
+  PubkeyAcceptedKeyTypes +ssh-rsa
+  UseRoaming no
+
+Host old
+  cipher des
+  KeepAlive yes`
+
+	expected := `# legacy defaults
+Host web
+  HostName web.example.com # primary
+  KbdInteractiveAuthentication=no
+  Ciphers blowfish-cbc # fast
+  PubkeyAcceptedAlgorithms +ssh-rsa
+
+Host old
+  TCPKeepAlive yes`
+
+	out, err := Modernize([]byte(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(out) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	var warnings []Warning
+	if _, err := parse(string(out), "~/.ssh/config", WithWarnings(func(w Warning) { warnings = append(warnings, w) })); err != nil {
+		t.Fatalf("unable to parse output: %s", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	e, err := NewEditor([]byte("Host web\r\n  KeepAlive yes\r\n"), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := e.Modernize(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(e.Bytes()) != "Host web\r\n  TCPKeepAlive yes\r\n" {
+		t.Errorf("unexpected content: %q", e.Bytes())
+	}
+}
@@ -0,0 +1,231 @@
+package sshconfig
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// Option configures the parser
+type Option func(*options)
+
+type options struct {
+	lenient      bool
+	strict       bool
+	deprecated   bool
+	expandRanges bool
+	skipIncludes bool
+	warn         func(Warning)
+	defaultPort  int
+	includeDepth int
+	depth        int
+	enclosing    *SSHHost
+	includeMode  IncludeMode
+	resolver     IncludeResolver
+	includeJobs  int
+	lastWins     bool
+	mergeDups    bool
+	cache        *IncludeCache
+	hooks        []DirectiveHook
+	logger       *slog.Logger
+	record       *parseRecord
+	// ignoreUnknown holds the IgnoreUnknown patterns read so far, it is
+	// shared with included files
+	ignoreUnknown *[]string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		defaultPort:  22,
+		includeDepth: 16,
+		resolver:     osResolver{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ignoreUnknown == nil {
+		o.ignoreUnknown = new([]string)
+	}
+	return o
+}
+
+// WithLenient makes the parser continue after recoverable errors such as an
+// invalid forward or a malformed port. All problems are returned joined by
+// errors.Join together with every host that could be parsed.
+func WithLenient() Option {
+	return func(o *options) {
+		o.lenient = true
+	}
+}
+
+// WithStrictMode turns every warning, like a deprecated or unsupported
+// keyword, into an error.
+func WithStrictMode() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithRejectDeprecated makes keywords removed from OpenSSH, like Protocol,
+// RSAAuthentication, UseRoaming or Cipher, parse errors, even if they are
+// covered by IgnoreUnknown. Other warnings are still only reported to
+// WithWarnings, see WithStrictMode to turn them into errors as well.
+func WithRejectDeprecated() Option {
+	return func(o *options) {
+		o.deprecated = true
+	}
+}
+
+// WithRangeExpansion expands numeric ranges and lists in the patterns of
+// Host lines, like web[01-20].prod, into the aliases they stand for, see
+// ExpandRange. This is an extension, ssh takes these patterns literally.
+func WithRangeExpansion() Option {
+	return func(o *options) {
+		o.expandRanges = true
+	}
+}
+
+// WithLastValueWins makes a keyword given more than once in the same block
+// take its last value. By default the first value wins like in OpenSSH.
+// Keywords which may be given multiple times, like IdentityFile, always
+// collect all values.
+func WithLastValueWins() Option {
+	return func(o *options) {
+		o.lastWins = true
+	}
+}
+
+// WithMergedDuplicates makes the parser combine Host blocks naming the same
+// aliases into the first of them, see MergeDuplicates. By default every
+// block is returned as written, duplicates included.
+func WithMergedDuplicates() Option {
+	return func(o *options) {
+		o.mergeDups = true
+	}
+}
+
+// WithDefaultPort sets the Port of hosts without a Port directive. It
+// defaults to 22, use 0 to leave the Port of these hosts unset. Either way
+// PortSet tells whether a host has a Port directive.
+func WithDefaultPort(port int) Option {
+	return func(o *options) {
+		o.defaultPort = port
+	}
+}
+
+// WithIncludeDepth sets how deep Include directives may be nested before
+// parsing fails. It defaults to 16 like in OpenSSH.
+func WithIncludeDepth(depth int) Option {
+	return func(o *options) {
+		o.includeDepth = depth
+	}
+}
+
+// WithIncludeConcurrency parses the files matched by an Include directive
+// with up to n files at a time. Hosts, warnings and errors are still reported
+// in file order. The include resolver must be safe for concurrent use, which
+// the default one is. Values below 2 parse included files one by one, as
+// are files included within a Host or Match block, which all add to it.
+func WithIncludeConcurrency(n int) Option {
+	return func(o *options) {
+		o.includeJobs = n
+	}
+}
+
+// ErrSkipDirective is returned by a DirectiveHook to drop a directive.
+var ErrSkipDirective = errors.New("skip directive")
+
+// DirectiveHook is called for a directive of a config before it is applied.
+// The hook may change the Value of d, which is then applied and recorded in
+// Directives instead of the value written in the config. Changes of the
+// other fields are ignored. Returning ErrSkipDirective drops the directive,
+// any other error is reported as parse error of the directive.
+type DirectiveHook func(d *Directive) error
+
+// WithDirectiveHook adds a hook called for every directive, including the
+// ones of included files, except for Host, Match and Include lines. Hooks
+// are called in the order they are added, so they can transform values for
+// each other, for example to redact secrets or collect metrics. With
+// WithIncludeConcurrency hooks are called concurrently for included files.
+func WithDirectiveHook(hook DirectiveHook) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hook)
+	}
+}
+
+// runHooks passes d to the directive hooks in order.
+func (o *options) runHooks(d *Directive) error {
+	for _, hook := range o.hooks {
+		if err := hook(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithoutIncludes makes the parser skip Include directives, so only the
+// given config is read.
+func WithoutIncludes() Option {
+	return func(o *options) {
+		o.skipIncludes = true
+	}
+}
+
+// WithLogger logs the steps of parsing to logger at debug level: the files
+// parsed, the files Include patterns expand to, unknown keywords and
+// directives without effect, to debug why a config is read the way it is.
+// See WithLookupLogger for the blocks applied by Lookup.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// debug logs msg with args if a logger is set.
+func (o *options) debug(msg string, args ...any) {
+	if o.logger != nil {
+		o.logger.Debug(msg, args...)
+	}
+}
+
+// IncludeMode selects how relative Include paths are resolved
+type IncludeMode int
+
+const (
+	// IncludeRelativeToFile resolves relative paths against the directory
+	// of the including file. This is the default.
+	IncludeRelativeToFile IncludeMode = iota
+	// IncludeUser resolves relative paths against ~/.ssh like ssh does for
+	// the user config.
+	IncludeUser
+	// IncludeSystem resolves relative paths against /etc/ssh, or
+	// %ProgramData%\ssh on Windows, like ssh does for the system config.
+	IncludeSystem
+)
+
+// WithIncludeMode sets how relative Include paths are resolved.
+func WithIncludeMode(mode IncludeMode) Option {
+	return func(o *options) {
+		o.includeMode = mode
+	}
+}
+
+// atDepth is used for included files to track the nesting depth.
+func atDepth(depth int) Option {
+	return func(o *options) {
+		o.depth = depth
+	}
+}
+
+// inBlock is used for files included from within a Host or Match block.
+func inBlock(host *SSHHost) Option {
+	return func(o *options) {
+		o.enclosing = host
+	}
+}
+
+// ignoring is used for included files to share the IgnoreUnknown patterns.
+func ignoring(patterns *[]string) Option {
+	return func(o *options) {
+		o.ignoreUnknown = patterns
+	}
+}
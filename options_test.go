@@ -0,0 +1,313 @@
+package sshconfig
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestWithDefaultPort(t *testing.T) {
+	config := `Host face
+  HostName facebook.com
+
+Host google
+  Port 2222`
+
+	hosts, err := parse(config, "~/.ssh/config", WithDefaultPort(0))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].Port != 0 || hosts[1].Port != 2222 {
+		t.Errorf("unexpected ports: %d, %d", hosts[0].Port, hosts[1].Port)
+	}
+}
+
+func TestWithStrictMode(t *testing.T) {
+	config := `Host face
+  HostName facebook.com
+  Protocol 2`
+
+	_, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expectedErr := "~/.ssh/config:3:3: Protocol is deprecated and ignored by OpenSSH"
+
+	_, err = parse(config, "~/.ssh/config", WithStrictMode())
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err)
+	}
+}
+
+func TestWithRejectDeprecated(t *testing.T) {
+	config := `IgnoreUnknown UseRoaming,XVendor
+Host face
+  HostName facebook.com
+  HostName facebook.org
+  XVendor yes
+  UseRoaming no
+  Cipher 3des`
+
+	_, err := parse(config, "~/.ssh/config", WithRejectDeprecated())
+	expectedErr := "~/.ssh/config:6:3: UseRoaming is deprecated and ignored by OpenSSH"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err)
+	}
+
+	hosts, err := parse(config, "~/.ssh/config", WithRejectDeprecated(), WithLenient())
+	expectedErr = expectedErr + "\n~/.ssh/config:7:3: Cipher is deprecated, use Ciphers instead"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err)
+	}
+	if len(hosts) != 2 || hosts[1].HostName != "facebook.com" {
+		t.Errorf("unexpected hosts: %+v", hosts)
+	}
+}
+
+func TestWithIncludeDepth(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	err := os.WriteFile(tmpdir+"/a.conf", []byte("Include a.conf\n"), 0644)
+	if err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	_, err = Parse(tmpdir + "/a.conf")
+	if err == nil || !strings.HasSuffix(err.Error(), "maximum include depth of 16 exceeded") {
+		t.Errorf("expected include depth error, got %#v", err)
+	}
+
+	err = os.WriteFile(tmpdir+"/b.conf", []byte("Include c.conf\n"), 0644)
+	if err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	err = os.WriteFile(tmpdir+"/c.conf", []byte("Host c\n"), 0644)
+	if err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	_, err = Parse(tmpdir+"/b.conf", WithIncludeDepth(0))
+	if err == nil || !strings.HasSuffix(err.Error(), "maximum include depth of 0 exceeded") {
+		t.Errorf("expected include depth error, got %#v", err)
+	}
+
+	hosts, err := Parse(tmpdir+"/b.conf", WithIncludeDepth(1))
+	if err != nil || len(hosts) != 1 {
+		t.Errorf("unexpected result: %v, %v", hosts, err)
+	}
+}
+
+func TestWithIncludeMode(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	homedir.DisableCache = true
+	homeEnv := "HOME"
+	if runtime.GOOS == "plan9" {
+		// On plan9, env vars are lowercase.
+		homeEnv = "home"
+	}
+	t.Setenv(homeEnv, tmpdir)
+
+	if err := os.MkdirAll(tmpdir+"/.ssh/conf.d", 0700); err != nil {
+		t.Fatalf("unable to create dir: %s", err.Error())
+	}
+
+	files := map[string]string{
+		"/.ssh/config":        "Include conf.d/*.conf\n",
+		"/.ssh/conf.d/a.conf": "Include b.conf\n",
+		"/.ssh/b.conf":        "Host b\n",
+		"/.ssh/conf.d/b.conf": "Host conf.d-b\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(tmpdir+name, []byte(content), 0600); err != nil {
+			t.Fatalf("unable to write file: %s", err.Error())
+		}
+	}
+
+	hosts, err := Parse(tmpdir + "/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if len(hosts) != 2 || hosts[0].Host[0] != "conf.d-b" {
+		t.Errorf("unexpected hosts: %+v", hosts)
+	}
+
+	hosts, err = Parse(tmpdir+"/.ssh/config", WithIncludeMode(IncludeUser))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if len(hosts) != 2 || hosts[0].Host[0] != "b" || hosts[1].Host[0] != "conf.d-b" {
+		t.Errorf("unexpected hosts: %+v", hosts)
+	}
+}
+
+func TestWithIncludeConcurrency(t *testing.T) {
+	resolver := mapResolver{}
+	for i := 0; i < 20; i++ {
+		resolver[fmt.Sprintf("/ssh/conf.d/%02d.conf", i)] = fmt.Sprintf("Host host%02d\n  User user%02d\n  Protocol 2\n", i, i)
+	}
+
+	var warnings []string
+	hosts, err := ParseString("Include conf.d/*.conf\n", "/ssh/config",
+		WithIncludeResolver(resolver),
+		WithIncludeConcurrency(4),
+		WithWarnings(func(w Warning) {
+			warnings = append(warnings, w.File)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if len(hosts) != 20 || len(warnings) != 20 {
+		t.Fatalf("expected 20 hosts and warnings, got %d and %d", len(hosts), len(warnings))
+	}
+
+	for i, h := range hosts {
+		if h.Host[0] != fmt.Sprintf("host%02d", i) {
+			t.Errorf("expected host%02d at %d, got %s", i, i, h.Host[0])
+		}
+		if warnings[i] != h.SourceFile {
+			t.Errorf("expected warning %d from %s, got %s", i, h.SourceFile, warnings[i])
+		}
+	}
+}
+
+func TestWithIncludeConcurrencyError(t *testing.T) {
+	resolver := mapResolver{
+		"/ssh/conf.d/a.conf": "Host a\n",
+		"/ssh/conf.d/b.conf": "Host b\n  Port abc\n",
+		"/ssh/conf.d/c.conf": "Host c\n  Port def\n",
+	}
+
+	_, err := ParseString("Include conf.d/*.conf\n", "/ssh/config",
+		WithIncludeResolver(resolver),
+		WithIncludeConcurrency(3),
+	)
+	if err == nil || !strings.HasPrefix(err.Error(), "/ssh/conf.d/b.conf:2:") {
+		t.Errorf("expected error from b.conf, got %v", err)
+	}
+}
+
+func TestWithIncludeConcurrencyInBlock(t *testing.T) {
+	resolver := mapResolver{}
+	for i := 0; i < 8; i++ {
+		resolver[fmt.Sprintf("/ssh/conf.d/%02d.conf", i)] = fmt.Sprintf("User user%02d\nPort %d\n", i, 2200+i)
+	}
+
+	for run := 0; run < 10; run++ {
+		hosts, err := ParseString("Host web\n  Include conf.d/*.conf\n", "/ssh/config",
+			WithIncludeResolver(resolver),
+			WithIncludeConcurrency(8),
+		)
+		if err != nil {
+			t.Fatalf("unable to parse config: %s", err.Error())
+		}
+		if len(hosts) != 1 || hosts[0].User != "user00" || hosts[0].Port != 2200 {
+			t.Fatalf("expected the values of the first file, got %+v", hosts)
+		}
+	}
+}
+
+func TestWithDirectiveHook(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  User deploy
+  SetEnv TOKEN=secret
+  LocalCommand echo connected
+`
+
+	var seen []string
+	count := func(d *Directive) error {
+		seen = append(seen, fmt.Sprintf("%s:%d %s", d.File, d.Line, d.Keyword))
+		return nil
+	}
+	redact := func(d *Directive) error {
+		if strings.EqualFold(d.Keyword, "SetEnv") {
+			d.Value = "TOKEN=redacted"
+		}
+		return nil
+	}
+	skip := func(d *Directive) error {
+		if strings.EqualFold(d.Keyword, "LocalCommand") {
+			return ErrSkipDirective
+		}
+		return nil
+	}
+
+	hosts, err := parse(config, "~/.ssh/config", WithDirectiveHook(count), WithDirectiveHook(redact), WithDirectiveHook(skip))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := "~/.ssh/config:2 HostName,~/.ssh/config:3 User,~/.ssh/config:4 SetEnv,~/.ssh/config:5 LocalCommand"
+	if strings.Join(seen, ",") != expected {
+		t.Errorf("expected hook calls %s, got %s", expected, strings.Join(seen, ","))
+	}
+
+	web := hosts[0]
+	if web.SetEnv["TOKEN"] != "redacted" {
+		t.Errorf("expected redacted SetEnv, got %v", web.SetEnv)
+	}
+	if web.LocalCommand != "" || len(web.Directives) != 3 || web.Directives[2].Value != "TOKEN=redacted" {
+		t.Errorf("unexpected directives: %v", web.Directives)
+	}
+
+	fail := func(d *Directive) error {
+		if d.Keyword == "User" {
+			return fmt.Errorf("users are managed centrally")
+		}
+		return nil
+	}
+	_, err = parse(config, "~/.ssh/config", WithDirectiveHook(fail))
+	if err == nil || err.Error() != "~/.ssh/config:3:3: users are managed centrally" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	resolver := mapResolver{"/ssh/web.conf": "Host web\n  User deploy\n  User root\n"}
+	config := `IgnoreUnknown XVendor*
+Include web.conf
+
+Host *
+  XVendorOption on
+`
+	hosts, err := ParseString(config, "/ssh/config", WithIncludeResolver(resolver), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	Lookup(hosts, "web", WithLookupLogger(logger))
+
+	expected := `level=DEBUG msg="parsing config" file=/ssh/config depth=0
+level=DEBUG msg="expanded include" file=/ssh/config pattern=web.conf files=[/ssh/web.conf]
+level=DEBUG msg="parsing config" file=/ssh/web.conf depth=1
+level=DEBUG msg="repeated keyword has no effect" file=/ssh/web.conf line=3 keyword=User
+level=DEBUG msg="unknown keyword" file=/ssh/config line=5 keyword=XVendorOption ignored=true
+level=DEBUG msg="applying block" alias=web block="Host web" file=/ssh/web.conf line=1
+level=DEBUG msg="applying block" alias=web block="Host *" file=/ssh/config line=1
+level=DEBUG msg="applying block" alias=web block="Host *" file=/ssh/config line=4
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,65 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Override returns a copy of the host with options applied the way ssh
+// applies -o options: they take precedence over the values of the host, and
+// values of keywords which may be given multiple times, like IdentityFile,
+// come before the ones of the host. Options are given as Keyword=Value or
+// "Keyword Value", for keywords given multiple times the first value is
+// used.
+func (h *SSHHost) Override(options ...string) (*SSHHost, error) {
+	o := &SSHHost{}
+	set := map[itemType]bool{}
+
+	for _, option := range options {
+		name, value, ok := splitOption(option)
+		if !ok {
+			return nil, fmt.Errorf("invalid option: %#v", option)
+		}
+
+		typ := keyword(name)
+		if set[typ] && typ != itemUnknown && !multiValued[typ] {
+			continue
+		}
+
+		if err := o.setValue(typ, name, value); err != nil {
+			return nil, fmt.Errorf("invalid option %#v: %w", option, err)
+		}
+		o.Directives = append(o.Directives, Directive{Keyword: name, Value: value})
+		set[typ] = true
+	}
+
+	port := o.Port
+	mergeSSHHost(o, h)
+	if set[itemPort] {
+		// a port of 22 is otherwise taken as unset by the merge
+		o.Port = port
+	}
+
+	o.Host = h.Host
+	o.Match = h.Match
+	o.parent = h.parent
+
+	return o, nil
+}
+
+// splitOption splits an option given as Keyword=Value or "Keyword Value".
+func splitOption(option string) (string, string, bool) {
+	option = strings.TrimSpace(option)
+	i := strings.IndexAny(option, " \t=")
+	if i <= 0 {
+		return "", "", false
+	}
+
+	keyword, value := option[:i], strings.TrimLeft(option[i:], " \t")
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	if value == "" {
+		return "", "", false
+	}
+
+	return keyword, value, true
+}
@@ -0,0 +1,44 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverride(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  User deploy
+  Port 2222
+  IdentityFile ~/.ssh/web
+  VisualHostKey yes`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h, err := hosts[0].Override("User=admin", "Port 22", "IdentityFile=~/.ssh/admin", "User=other", "VisualHostKey = no")
+	if err != nil {
+		t.Fatalf("unable to override: %s", err)
+	}
+
+	if h.User != "admin" || h.Port != 22 || h.HostName != "web.example.com" {
+		t.Errorf("unexpected host: %+v", h)
+	}
+	if !reflect.DeepEqual([]string{"~/.ssh/admin", "~/.ssh/web"}, h.IdentityFiles) {
+		t.Errorf("unexpected identity files: %v", h.IdentityFiles)
+	}
+	if !reflect.DeepEqual([]string{"no"}, h.GetAll("VisualHostKey")) {
+		t.Errorf("unexpected unknowns: %v", h.Unknowns)
+	}
+	if !reflect.DeepEqual([]string{"web"}, h.Host) || hosts[0].User != "deploy" {
+		t.Errorf("unexpected hosts: %v, %s", h.Host, hosts[0].User)
+	}
+
+	for _, option := range []string{"User", "=admin", "Port=abc", "Host=other"} {
+		if _, err := hosts[0].Override(option); err == nil {
+			t.Errorf("expected error for option %q", option)
+		}
+	}
+}
@@ -1,94 +1,380 @@
 package sshconfig
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"io/ioutil"
+	"iter"
+	"net"
+	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
-
-	"github.com/mitchellh/go-homedir"
+	"sync"
+	"time"
 )
 
 // SSHHost defines a single host entry in a ssh config
 type SSHHost struct {
-	Host              []string
-	HostName          string
-	User              string
-	Port              int
-	ProxyCommand      string
-	HostKeyAlgorithms string
-	IdentityFile      string
-	LocalForwards     []Forward
-	RemoteForwards    []Forward
-	DynamicForwards   []DynamicForward
-	Ciphers           []string
-	MACs              []string
-}
-
-// Forward defines a single port forward entry
+	Host                             []string              `json:"host,omitempty" yaml:"host,omitempty"`
+	HostName                         string                `json:"hostName,omitempty" yaml:"hostName,omitempty"`
+	User                             string                `json:"user,omitempty" yaml:"user,omitempty"`
+	Port                             int                   `json:"port,omitempty" yaml:"port,omitempty"`
+	PortSet                          bool                  `json:"portSet,omitempty" yaml:"portSet,omitempty"`
+	ProxyCommand                     string                `json:"proxyCommand,omitempty" yaml:"proxyCommand,omitempty"`
+	HostKeyAlgorithms                []string              `json:"hostKeyAlgorithms,omitempty" yaml:"hostKeyAlgorithms,omitempty"`
+	IdentityFile                     string                `json:"identityFile,omitempty" yaml:"identityFile,omitempty"`
+	IdentityFiles                    []string              `json:"identityFiles,omitempty" yaml:"identityFiles,omitempty"`
+	CertificateFiles                 []string              `json:"certificateFiles,omitempty" yaml:"certificateFiles,omitempty"`
+	LocalForwards                    []Forward             `json:"localForwards,omitempty" yaml:"localForwards,omitempty"`
+	RemoteForwards                   []Forward             `json:"remoteForwards,omitempty" yaml:"remoteForwards,omitempty"`
+	DynamicForwards                  []DynamicForward      `json:"dynamicForwards,omitempty" yaml:"dynamicForwards,omitempty"`
+	Ciphers                          []string              `json:"ciphers,omitempty" yaml:"ciphers,omitempty"`
+	MACs                             []string              `json:"macs,omitempty" yaml:"macs,omitempty"`
+	Tag                              string                `json:"tag,omitempty" yaml:"tag,omitempty"`
+	ProxyJump                        string                `json:"proxyJump,omitempty" yaml:"proxyJump,omitempty"`
+	ForwardAgent                     string                `json:"forwardAgent,omitempty" yaml:"forwardAgent,omitempty"`
+	ControlMaster                    ControlMaster         `json:"controlMaster,omitempty" yaml:"controlMaster,omitempty"`
+	ControlPath                      string                `json:"controlPath,omitempty" yaml:"controlPath,omitempty"`
+	ControlPersist                   string                `json:"controlPersist,omitempty" yaml:"controlPersist,omitempty"`
+	ServerAliveInterval              time.Duration         `json:"serverAliveInterval,omitempty" yaml:"serverAliveInterval,omitempty"`
+	ServerAliveCountMax              int                   `json:"serverAliveCountMax,omitempty" yaml:"serverAliveCountMax,omitempty"`
+	StrictHostKeyChecking            StrictHostKeyChecking `json:"strictHostKeyChecking,omitempty" yaml:"strictHostKeyChecking,omitempty"`
+	UserKnownHostsFiles              []string              `json:"userKnownHostsFiles,omitempty" yaml:"userKnownHostsFiles,omitempty"`
+	GlobalKnownHostsFiles            []string              `json:"globalKnownHostsFiles,omitempty" yaml:"globalKnownHostsFiles,omitempty"`
+	PreferredAuthentications         []string              `json:"preferredAuthentications,omitempty" yaml:"preferredAuthentications,omitempty"`
+	PubkeyAuthentication             string                `json:"pubkeyAuthentication,omitempty" yaml:"pubkeyAuthentication,omitempty"`
+	PasswordAuthentication           TriBool               `json:"passwordAuthentication,omitempty" yaml:"passwordAuthentication,omitempty"`
+	KbdInteractiveAuthentication     TriBool               `json:"kbdInteractiveAuthentication,omitempty" yaml:"kbdInteractiveAuthentication,omitempty"`
+	KexAlgorithms                    []string              `json:"kexAlgorithms,omitempty" yaml:"kexAlgorithms,omitempty"`
+	PubkeyAcceptedAlgorithms         []string              `json:"pubkeyAcceptedAlgorithms,omitempty" yaml:"pubkeyAcceptedAlgorithms,omitempty"`
+	SendEnv                          []string              `json:"sendEnv,omitempty" yaml:"sendEnv,omitempty"`
+	SetEnv                           map[string]string     `json:"setEnv,omitempty" yaml:"setEnv,omitempty"`
+	LocalCommand                     string                `json:"localCommand,omitempty" yaml:"localCommand,omitempty"`
+	PermitLocalCommand               TriBool               `json:"permitLocalCommand,omitempty" yaml:"permitLocalCommand,omitempty"`
+	RemoteCommand                    string                `json:"remoteCommand,omitempty" yaml:"remoteCommand,omitempty"`
+	RequestTTY                       RequestTTY            `json:"requestTTY,omitempty" yaml:"requestTTY,omitempty"`
+	SessionType                      SessionType           `json:"sessionType,omitempty" yaml:"sessionType,omitempty"`
+	ForwardX11                       TriBool               `json:"forwardX11,omitempty" yaml:"forwardX11,omitempty"`
+	ForwardX11Trusted                TriBool               `json:"forwardX11Trusted,omitempty" yaml:"forwardX11Trusted,omitempty"`
+	ForwardX11Timeout                time.Duration         `json:"forwardX11Timeout,omitempty" yaml:"forwardX11Timeout,omitempty"`
+	CanonicalizeHostname             string                `json:"canonicalizeHostname,omitempty" yaml:"canonicalizeHostname,omitempty"`
+	CanonicalDomains                 []string              `json:"canonicalDomains,omitempty" yaml:"canonicalDomains,omitempty"`
+	CanonicalizeMaxDots              int                   `json:"canonicalizeMaxDots,omitempty" yaml:"canonicalizeMaxDots,omitempty"`
+	CanonicalizeMaxDotsSet           bool                  `json:"canonicalizeMaxDotsSet,omitempty" yaml:"canonicalizeMaxDotsSet,omitempty"`
+	CanonicalizeFallbackLocal        TriBool               `json:"canonicalizeFallbackLocal,omitempty" yaml:"canonicalizeFallbackLocal,omitempty"`
+	CanonicalizePermittedCNAMEs      []string              `json:"canonicalizePermittedCNAMEs,omitempty" yaml:"canonicalizePermittedCNAMEs,omitempty"`
+	HashKnownHosts                   TriBool               `json:"hashKnownHosts,omitempty" yaml:"hashKnownHosts,omitempty"`
+	CheckHostIP                      TriBool               `json:"checkHostIP,omitempty" yaml:"checkHostIP,omitempty"`
+	VerifyHostKeyDNS                 string                `json:"verifyHostKeyDNS,omitempty" yaml:"verifyHostKeyDNS,omitempty"`
+	TCPKeepAlive                     TriBool               `json:"tcpKeepAlive,omitempty" yaml:"tcpKeepAlive,omitempty"`
+	Tunnel                           Tunnel                `json:"tunnel,omitempty" yaml:"tunnel,omitempty"`
+	TunnelDevice                     string                `json:"tunnelDevice,omitempty" yaml:"tunnelDevice,omitempty"`
+	GatewayPorts                     TriBool               `json:"gatewayPorts,omitempty" yaml:"gatewayPorts,omitempty"`
+	ExitOnForwardFailure             TriBool               `json:"exitOnForwardFailure,omitempty" yaml:"exitOnForwardFailure,omitempty"`
+	ClearAllForwardings              TriBool               `json:"clearAllForwardings,omitempty" yaml:"clearAllForwardings,omitempty"`
+	LogLevel                         string                `json:"logLevel,omitempty" yaml:"logLevel,omitempty"`
+	SyslogFacility                   string                `json:"syslogFacility,omitempty" yaml:"syslogFacility,omitempty"`
+	BatchMode                        TriBool               `json:"batchMode,omitempty" yaml:"batchMode,omitempty"`
+	NumberOfPasswordPrompts          int                   `json:"numberOfPasswordPrompts,omitempty" yaml:"numberOfPasswordPrompts,omitempty"`
+	EscapeChar                       string                `json:"escapeChar,omitempty" yaml:"escapeChar,omitempty"`
+	EnableEscapeCommandline          TriBool               `json:"enableEscapeCommandline,omitempty" yaml:"enableEscapeCommandline,omitempty"`
+	PKCS11Provider                   string                `json:"pkcs11Provider,omitempty" yaml:"pkcs11Provider,omitempty"`
+	SecurityKeyProvider              string                `json:"securityKeyProvider,omitempty" yaml:"securityKeyProvider,omitempty"`
+	GSSAPIAuthentication             TriBool               `json:"gssapiAuthentication,omitempty" yaml:"gssapiAuthentication,omitempty"`
+	GSSAPIDelegateCredentials        TriBool               `json:"gssapiDelegateCredentials,omitempty" yaml:"gssapiDelegateCredentials,omitempty"`
+	GSSAPIKeyExchange                TriBool               `json:"gssapiKeyExchange,omitempty" yaml:"gssapiKeyExchange,omitempty"`
+	GSSAPITrustDns                   TriBool               `json:"gssapiTrustDns,omitempty" yaml:"gssapiTrustDns,omitempty"`
+	GSSAPIClientIdentity             string                `json:"gssapiClientIdentity,omitempty" yaml:"gssapiClientIdentity,omitempty"`
+	GSSAPIServerIdentity             string                `json:"gssapiServerIdentity,omitempty" yaml:"gssapiServerIdentity,omitempty"`
+	RekeyLimit                       *RekeyLimit           `json:"rekeyLimit,omitempty" yaml:"rekeyLimit,omitempty"`
+	UpdateHostKeys                   UpdateHostKeys        `json:"updateHostKeys,omitempty" yaml:"updateHostKeys,omitempty"`
+	IgnoreUnknown                    []string              `json:"ignoreUnknown,omitempty" yaml:"ignoreUnknown,omitempty"`
+	PermitOpen                       []string              `json:"permitOpen,omitempty" yaml:"permitOpen,omitempty"`
+	PermitRemoteOpen                 []string              `json:"permitRemoteOpen,omitempty" yaml:"permitRemoteOpen,omitempty"`
+	IdentityAgent                    string                `json:"identityAgent,omitempty" yaml:"identityAgent,omitempty"`
+	ConnectTimeout                   time.Duration         `json:"connectTimeout,omitempty" yaml:"connectTimeout,omitempty"`
+	AddKeysToAgent                   AddKeysToAgent        `json:"addKeysToAgent,omitempty" yaml:"addKeysToAgent,omitempty"`
+	KnownHostsCommand                string                `json:"knownHostsCommand,omitempty" yaml:"knownHostsCommand,omitempty"`
+	FingerprintHash                  FingerprintHash       `json:"fingerprintHash,omitempty" yaml:"fingerprintHash,omitempty"`
+	RequiredRSASize                  int                   `json:"requiredRSASize,omitempty" yaml:"requiredRSASize,omitempty"`
+	ChannelTimeouts                  []ChannelTimeout      `json:"channelTimeouts,omitempty" yaml:"channelTimeouts,omitempty"`
+	ObscureKeystrokeTiming           string                `json:"obscureKeystrokeTiming,omitempty" yaml:"obscureKeystrokeTiming,omitempty"`
+	StdinNull                        TriBool               `json:"stdinNull,omitempty" yaml:"stdinNull,omitempty"`
+	ForkAfterAuthentication          TriBool               `json:"forkAfterAuthentication,omitempty" yaml:"forkAfterAuthentication,omitempty"`
+	ProxyUseFdpass                   TriBool               `json:"proxyUseFdpass,omitempty" yaml:"proxyUseFdpass,omitempty"`
+	StreamLocalBindMask              *os.FileMode          `json:"streamLocalBindMask,omitempty" yaml:"streamLocalBindMask,omitempty"`
+	StreamLocalBindUnlink            TriBool               `json:"streamLocalBindUnlink,omitempty" yaml:"streamLocalBindUnlink,omitempty"`
+	HostbasedAuthentication          TriBool               `json:"hostbasedAuthentication,omitempty" yaml:"hostbasedAuthentication,omitempty"`
+	HostbasedAcceptedAlgorithms      []string              `json:"hostbasedAcceptedAlgorithms,omitempty" yaml:"hostbasedAcceptedAlgorithms,omitempty"`
+	NoHostAuthenticationForLocalhost TriBool               `json:"noHostAuthenticationForLocalhost,omitempty" yaml:"noHostAuthenticationForLocalhost,omitempty"`
+	HostKeyAlias                     string                `json:"hostKeyAlias,omitempty" yaml:"hostKeyAlias,omitempty"`
+	Match                            []MatchCriterion      `json:"match,omitempty" yaml:"match,omitempty"`
+	Unknowns                         map[string][]string   `json:"unknowns,omitempty" yaml:"unknowns,omitempty"`
+	Comments                         []string              `json:"comments,omitempty" yaml:"comments,omitempty"`
+	Tags                             map[string]string     `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Section                          string                `json:"section,omitempty" yaml:"section,omitempty"`
+	Extensions                       map[string]any        `json:"-" yaml:"-"`
+	Directives                       []Directive           `json:"directives,omitempty" yaml:"directives,omitempty"`
+	SourceFile                       string                `json:"sourceFile,omitempty" yaml:"sourceFile,omitempty"`
+	SourceLine                       int                   `json:"sourceLine,omitempty" yaml:"sourceLine,omitempty"`
+
+	// parent is the block including the file this block was read from
+	parent *SSHHost
+	// depth is the include depth of the file this block was read from
+	depth int
+	// global is set for the implicit block holding the directives before
+	// the first Host or Match of a file
+	global bool
+}
+
+// IsGlobal reports whether h holds the directives found before the first
+// Host or Match of a file rather than a Host or Match block of its own.
+func (h *SSHHost) IsGlobal() bool {
+	return h.global
+}
+
+// IsPattern reports whether h is a Host block with patterns, like `Host *`
+// or `Host *.example.com !bastion`, rather than one naming aliases only.
+// Blocks holding the directives before the first Host or Match count as
+// `Host *`, Match blocks are not pattern blocks. Pattern blocks are returned
+// by the parser like any other block, Lookup merges them into the blocks of
+// the aliases they match.
+func (h *SSHHost) IsPattern() bool {
+	if h.global {
+		return true
+	}
+	if h.Match != nil {
+		return false
+	}
+	for _, alias := range h.Host {
+		if isPattern(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultStreamLocalBindMask is the StreamLocalBindMask used by ssh if none
+// is set, leaving sockets accessible to their owner only.
+const defaultStreamLocalBindMask os.FileMode = 0177
+
+// StreamLocalBindMode returns the permissions of the Unix domain sockets
+// created for socket forwards, as left by StreamLocalBindMask.
+func (h *SSHHost) StreamLocalBindMode() os.FileMode {
+	mask := defaultStreamLocalBindMask
+	if h.StreamLocalBindMask != nil {
+		mask = *h.StreamLocalBindMask
+	}
+	return 0777 &^ mask
+}
+
+// Directive defines a single keyword and its value as written in a config
+// file
+type Directive struct {
+	Keyword string `json:"keyword" yaml:"keyword"`
+	Value   string `json:"value" yaml:"value"`
+	File    string `json:"file,omitempty" yaml:"file,omitempty"`
+	Line    int    `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+// Forward defines a single port forward entry. Either side may be a Unix
+// domain socket instead of a host and port.
 type Forward struct {
-	InHost  string
-	InPort  int
-	OutHost string
-	OutPort int
+	InHost    string `yaml:"inHost,omitempty"`
+	InPort    int    `yaml:"inPort,omitempty"`
+	InSocket  string `yaml:"inSocket,omitempty"`
+	OutHost   string `yaml:"outHost,omitempty"`
+	OutPort   int    `yaml:"outPort,omitempty"`
+	OutSocket string `yaml:"outSocket,omitempty"`
 }
 
-// NewForward returns Forward object parsed from LocalForward or RemoteForward string
+// NewForward returns Forward object parsed from LocalForward or RemoteForward
+// string. Addresses are given as host:port, host/port or [host]:port, the
+// latter allowing IPv6 addresses, or as a path to a Unix domain socket.
 func NewForward(f string) (Forward, error) {
-	r := regexp.MustCompile(`((\S+):)?(\d+)\s+(\S+):(\d+)`)
-	m := r.FindStringSubmatch(f)
+	fields := strings.Fields(f)
+	if len(fields) != 2 {
+		return Forward{}, fmt.Errorf("Invalid forward: %#v", f)
+	}
 
-	if len(m) < 6 {
+	var forward Forward
+	var err error
+	forward.InHost, forward.InPort, forward.InSocket, err = parseForwardEndpoint(fields[0])
+	if err == errInvalidAddress {
 		return Forward{}, fmt.Errorf("Invalid forward: %#v", f)
 	}
+	if err != nil {
+		return Forward{}, err
+	}
 
-	InPort, err := strconv.Atoi(m[3])
+	forward.OutHost, forward.OutPort, forward.OutSocket, err = parseForwardEndpoint(fields[1])
+	if err == errInvalidAddress || (err == nil && forward.OutSocket == "" && forward.OutHost == "") {
+		return Forward{}, fmt.Errorf("Invalid forward: %#v", f)
+	}
 	if err != nil {
 		return Forward{}, err
 	}
 
-	OutPort, err := strconv.Atoi(m[5])
+	return forward, nil
+}
+
+// newRemoteForward returns the Forward parsed from a RemoteForward string,
+// which may only name the listen address for a reverse dynamic forward.
+func newRemoteForward(f string) (Forward, error) {
+	if len(strings.Fields(f)) != 1 {
+		return NewForward(f)
+	}
+
+	var forward Forward
+	var err error
+	forward.InHost, forward.InPort, forward.InSocket, err = parseForwardEndpoint(strings.TrimSpace(f))
+	if err == errInvalidAddress || (err == nil && forward.InSocket != "") {
+		return Forward{}, fmt.Errorf("Invalid forward: %#v", f)
+	}
 	if err != nil {
 		return Forward{}, err
 	}
+	return forward, nil
+}
 
-	return Forward{
-		InHost:  m[2],
-		InPort:  InPort,
-		OutHost: m[4],
-		OutPort: OutPort,
-	}, nil
+// IsDynamic reports whether f is a reverse dynamic forward given by a
+// RemoteForward without destination, for which ssh acts as a SOCKS proxy.
+func (f Forward) IsDynamic() bool {
+	return f.OutHost == "" && f.OutSocket == ""
+}
+
+// String returns the forward as written in a config file, the listen and
+// destination address separated by a space.
+func (f Forward) String() string {
+	if f.IsDynamic() {
+		return f.listenAddress()
+	}
+	return f.listenAddress() + " " + f.destination()
+}
+
+// Arg returns the forward in the colon separated form of the -L and -R
+// flags.
+func (f Forward) Arg() string {
+	if f.IsDynamic() {
+		return f.listenAddress()
+	}
+	return f.listenAddress() + ":" + f.destination()
+}
+
+func (f Forward) listenAddress() string {
+	switch {
+	case f.InSocket != "":
+		return f.InSocket
+	case f.InHost != "":
+		return net.JoinHostPort(f.InHost, strconv.Itoa(f.InPort))
+	}
+	return strconv.Itoa(f.InPort)
+}
+
+func (f Forward) destination() string {
+	if f.OutSocket != "" {
+		return f.OutSocket
+	}
+	return net.JoinHostPort(f.OutHost, strconv.Itoa(f.OutPort))
+}
+
+// errInvalidAddress is returned by parseForwardEndpoint for malformed
+// addresses.
+var errInvalidAddress = errors.New("invalid forward address")
+
+// parseForwardEndpoint parses one side of a forward. An address starting with
+// a slash, or containing one without being a host/port pair, is a socket.
+func parseForwardEndpoint(addr string) (host string, port int, socket string, err error) {
+	h, p, ok := splitForwardAddress(addr)
+	if strings.HasPrefix(addr, "/") || (!ok && strings.Contains(addr, "/")) {
+		return "", 0, addr, nil
+	}
+	if !ok {
+		return "", 0, "", errInvalidAddress
+	}
+
+	port, err = strconv.Atoi(p)
+	if err != nil {
+		return "", 0, "", err
+	}
+	return h, port, "", nil
 }
 
 // DynamicForward defines a single dynamic port forward entry
 type DynamicForward struct {
-	Host string
-	Port int
+	Host string `yaml:"host,omitempty"`
+	Port int    `yaml:"port"`
+}
+
+// String returns the dynamic forward as written in a config file, which is
+// also the form of the -D flag.
+func (f DynamicForward) String() string {
+	if f.Host != "" {
+		return net.JoinHostPort(f.Host, strconv.Itoa(f.Port))
+	}
+	return strconv.Itoa(f.Port)
 }
 
 // NewDynamicForward returns DForward object parsed from DynamicForward string
 func NewDynamicForward(f string) (DynamicForward, error) {
-	r := regexp.MustCompile(`((\S+):)?(\d+)`)
-	m := r.FindStringSubmatch(f)
-
-	if len(m) < 4 {
+	host, port, ok := splitForwardAddress(strings.TrimSpace(f))
+	if !ok {
 		return DynamicForward{}, fmt.Errorf("Invalid dynamic forward: %#v", f)
 	}
 
-	InPort, err := strconv.Atoi(m[3])
+	InPort, err := strconv.Atoi(port)
 	if err != nil {
 		return DynamicForward{}, err
 	}
 
 	return DynamicForward{
-		Host: m[2],
+		Host: host,
 		Port: InPort,
 	}, nil
 }
 
+// splitForwardAddress splits a forward address into host and port. The host
+// is optional and may be bracketed, the port must be numeric.
+func splitForwardAddress(addr string) (host, port string, ok bool) {
+	switch {
+	case strings.HasPrefix(addr, "["):
+		end := strings.Index(addr, "]")
+		if end < 0 || !strings.HasPrefix(addr[end+1:], ":") {
+			return "", "", false
+		}
+		host, port = addr[1:end], addr[end+2:]
+		if host == "" {
+			return "", "", false
+		}
+	case strings.Contains(addr, "/"):
+		i := strings.LastIndex(addr, "/")
+		host, port = addr[:i], addr[i+1:]
+	case strings.Contains(addr, ":"):
+		i := strings.LastIndex(addr, ":")
+		host, port = addr[:i], addr[i+1:]
+		if strings.Contains(host, ":") {
+			// unbracketed IPv6 addresses are ambiguous
+			return "", "", false
+		}
+	default:
+		port = addr
+	}
+
+	if port == "" {
+		return "", "", false
+	}
+	for _, r := range port {
+		if r < '0' || r > '9' {
+			return "", "", false
+		}
+	}
+	return host, port, true
+}
+
 // MustParse must parse the SSH config given by path or it will panic
 func MustParse(path string) []*SSHHost {
 	config, err := Parse(path)
@@ -110,188 +396,1107 @@ func ParseSSHConfig(path string) ([]*SSHHost, error) {
 	return Parse(path)
 }
 
+// Parser parses SSH configs and looks up hosts in them. It is implemented
+// by DefaultParser with the functions of this package, code depending on a
+// Parser can be tested with a fake returning fixed hosts instead of reading
+// files.
+type Parser interface {
+	Parse(path string, opts ...Option) ([]*SSHHost, error)
+	ParseFS(fsys fs.FS, path string, opts ...Option) ([]*SSHHost, error)
+	Lookup(hosts []*SSHHost, alias string, opts ...LookupOption) *SSHHost
+}
+
+// DefaultParser is the Parser calling Parse, ParseFS and Lookup.
+var DefaultParser Parser = packageParser{}
+
+// packageParser implements Parser with the package functions.
+type packageParser struct{}
+
+func (packageParser) Parse(path string, opts ...Option) ([]*SSHHost, error) {
+	return Parse(path, opts...)
+}
+
+func (packageParser) ParseFS(fsys fs.FS, path string, opts ...Option) ([]*SSHHost, error) {
+	return ParseFS(fsys, path, opts...)
+}
+
+func (packageParser) Lookup(hosts []*SSHHost, alias string, opts ...LookupOption) *SSHHost {
+	return Lookup(hosts, alias, opts...)
+}
+
 // Parse parses a SSH config given by path.
-func Parse(path string) ([]*SSHHost, error) {
+func Parse(path string, opts ...Option) ([]*SSHHost, error) {
 	// read config file
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return parse(string(content), path)
+	return parse(string(content), path, opts...)
+}
+
+// ParseSeq returns an iterator parsing the SSH config given by path lazily,
+// yielding every Host and Match block as soon as it has been read. Parsing
+// stops when the loop is left early. A failure is yielded as a nil host and
+// the error, in lenient mode after all blocks.
+func ParseSeq(path string, opts ...Option) iter.Seq2[*SSHHost, error] {
+	return func(yield func(*SSHHost, error) bool) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		err = parseFunc(string(content), path, opts, func(h *SSHHost) bool {
+			return yield(h, nil)
+		})
+		if err != nil && !errors.Is(err, errStopped) {
+			yield(nil, err)
+		}
+	}
+}
+
+// ErrHostNotFound is returned by ParseHost if no Host block matches the
+// alias.
+var ErrHostNotFound = errors.New("host not found")
+
+// ParseHost parses the SSH config given by path up to the first Host block
+// matching alias, other than `Host *`, and returns that block. Parsing, and
+// reading of included files, stops there, which makes it much faster than
+// Parse for large configs when a single block is needed. The block is
+// returned as written, use Lookup on a full parse for the effective
+// configuration of alias. ErrHostNotFound is returned if no block matches.
+func ParseHost(path string, alias string, opts ...Option) (*SSHHost, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var host *SSHHost
+	err = parseFunc(string(content), path, opts, func(h *SSHHost) bool {
+		if h.Match != nil || h.global || (len(h.Host) == 1 && h.Host[0] == "*") || !matchHost(h.Host, alias) {
+			return true
+		}
+		host = h
+		return false
+	})
+	if host != nil {
+		return host, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%s: %w", alias, ErrHostNotFound)
 }
 
-// ParseFS parses a SSH config given by path contained in fsys.
-func ParseFS(fsys fs.FS, path string) ([]*SSHHost, error) {
+// ParseFS parses a SSH config given by path contained in fsys. Included
+// files are read from fsys as well.
+func ParseFS(fsys fs.FS, path string, opts ...Option) ([]*SSHHost, error) {
 	// read config file
 	content, err := fs.ReadFile(fsys, path)
 	if err != nil {
 		return nil, err
 	}
 
-	return parse(string(content), path)
+	opts = append([]Option{WithIncludeResolver(FSResolver(fsys))}, opts...)
+	return parse(string(content), path, opts...)
+}
+
+// ParseReader parses a SSH config read from r. The path is used for error
+// messages and to resolve relative Include directives.
+func ParseReader(r io.Reader, path string, opts ...Option) ([]*SSHHost, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(string(content), path, opts...)
+}
+
+// ParseString parses the SSH config in content. The basePath is used for
+// error messages and to resolve relative Include directives.
+func ParseString(content string, basePath string, opts ...Option) ([]*SSHHost, error) {
+	return parse(content, basePath, opts...)
+}
+
+// ParseBytes parses the SSH config in content, see ParseString.
+func ParseBytes(content []byte, basePath string, opts ...Option) ([]*SSHHost, error) {
+	return parse(string(content), basePath, opts...)
 }
 
 // parses an openssh config file
-func parse(input string, path string) ([]*SSHHost, error) {
+func parse(input string, path string, opts ...Option) ([]*SSHHost, error) {
 	sshConfigs := []*SSHHost{}
+	err := parseFunc(input, path, opts, func(h *SSHHost) bool {
+		sshConfigs = append(sshConfigs, h)
+		return true
+	})
+	o := newOptions(opts)
+	if err != nil && !o.lenient {
+		return nil, err
+	}
+	if o.mergeDups {
+		sshConfigs = MergeDuplicates(sshConfigs)
+	}
+	return sshConfigs, err
+}
+
+// errStopped is returned by parseFunc when yield asked to stop parsing.
+var errStopped = errors.New("parsing stopped")
+
+// parseFunc parses an openssh config file and passes every Host and Match
+// block to yield in file order. A block including files is passed before the
+// blocks of the included files, so it may still get further directives
+// after it has been passed.
+func parseFunc(input string, path string, opts []Option, yield func(*SSHHost) bool) error {
+	return parseLexer(lexWithComments(input), input, path, opts, yield)
+}
+
+// parseLexer is parseFunc for the items of lexer, which lexes input.
+func parseLexer(lexer *lexer, input string, path string, opts []Option, yield func(*SSHHost) bool) error {
+	o := newOptions(opts)
+	o.debug("parsing config", "file", path, "depth", o.depth)
+	o.record.addFile(path)
+
 	var next item
-	var sshHost *SSHHost
-	var onlyIncludes bool = !strings.Contains(input, "Host ") && strings.Contains(input, "Include ");
+	// directives at the top of a file included from within a block belong
+	// to the including block
+	var sshHost *SSHHost = o.enclosing
+
+	// fail records err. Unless the parser is lenient err is returned to
+	// abort parsing.
+	var errs []error
+	fail := func(err error) error {
+		if !o.lenient {
+			return err
+		}
+		errs = append(errs, err)
+		return nil
+	}
 
-	lexer := lex(input)
+	// flush passes the current block to yield unless it was passed already
+	// or belongs to the including file.
+	var flushed *SSHHost
+	flush := func() error {
+		if sshHost != nil && sshHost != flushed && sshHost != o.enclosing {
+			flushed = sshHost
+			if !yield(sshHost) {
+				return errStopped
+			}
+		}
+		return nil
+	}
+
+	// comments holds the comment lines read since the last directive.
+	// Comments directly above a Host or Match line belong to its block, the
+	// others to the block they are in.
+	var comments []item
+	// section is the name of the section of the file blocks are in.
+	var section string
+	attach := func(items []item) {
+		if sshHost != nil && sshHost != o.enclosing {
+			for _, c := range items {
+				sshHost.addComment(commentText(c.val))
+			}
+		}
+		comments = nil
+	}
+
+	defer lexer.drain()
 Loop:
 	for {
 		token := lexer.nextItem()
+		if token.typ == itemComment {
+			comments = append(comments, token)
+			continue
+		}
 
 		if sshHost == nil {
 			if token.typ == itemEOF {
 				break Loop
 			}
-			if token.typ != itemHost && token.typ != itemInclude {
-				// File has no `Host` but has `Include`. Continue trying to parse it.
-				if  onlyIncludes {
-					continue Loop
-				}
-				return nil, fmt.Errorf("%s:%d: config variable before Host variable", path, token.pos)
+			if token.typ == itemValue {
+				continue Loop
+			}
+			if token.typ != itemHost && token.typ != itemMatch && token.typ != itemInclude {
+				// directives before the first Host apply to all hosts, like
+				// OpenSSH they are read as an implicit `Host *` block
+				sshHost = &SSHHost{Host: []string{"*"}, Port: o.defaultPort, SourceFile: path, SourceLine: token.line, depth: o.depth, global: true}
 			}
-		} else if token.typ == itemInclude {
-			return nil, fmt.Errorf("include not allowed in Host block")
 		}
 
 		switch token.typ {
 		case itemHost:
-			if sshHost != nil {
-				sshConfigs = append(sshConfigs, sshHost)
+			leading := leadingComments(comments, token.line)
+			rest, name := splitSection(input, comments[:len(comments)-len(leading)])
+			attach(rest)
+			if name != "" {
+				section = name
+			}
+			if err := flush(); err != nil {
+				return err
 			}
 
-			sshHost = &SSHHost{Host: []string{}, Port: 22}
+			sshHost = &SSHHost{Host: []string{}, Port: o.defaultPort, Section: section, SourceFile: path, SourceLine: token.line, depth: o.depth, parent: o.enclosing}
+			attach(leading)
 		case itemHostValue:
-			sshHost.Host = strings.Split(token.val, " ")
-		case itemHostName:
-			next = lexer.nextItem()
-			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+			// aliases may be quoted, like `Host "my server" backup`
+			aliases, err := splitArgs(token.val)
+			if err != nil {
+				if err := fail(newParseError(input, path, token, "Host", err)); err != nil {
+					return err
+				}
 			}
-			sshHost.HostName = next.val
-		case itemUser:
-			next = lexer.nextItem()
-			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+			if aliases == nil {
+				aliases = []string{}
+			}
+			if o.expandRanges {
+				aliases, err = expandAliases(aliases)
+				if err != nil {
+					if err := fail(newParseError(input, path, token, "Host", err)); err != nil {
+						return err
+					}
+				}
+			}
+			sshHost.Host = aliases
+		case itemMatch:
+			leading := leadingComments(comments, token.line)
+			rest, name := splitSection(input, comments[:len(comments)-len(leading)])
+			attach(rest)
+			if name != "" {
+				section = name
 			}
-			sshHost.User = next.val
-		case itemPort:
+			if err := flush(); err != nil {
+				return err
+			}
+			// a block with invalid criteria never matches
+			sshHost = &SSHHost{Host: []string{}, Port: o.defaultPort, Section: section, SourceFile: path, SourceLine: token.line, depth: o.depth, parent: o.enclosing}
+			attach(leading)
+
 			next = lexer.nextItem()
 			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+				return newParseError(input, path, next, token.val, valueError(token, next))
 			}
-			port, err := strconv.Atoi(next.val)
+			criteria, err := parseMatch(next.val)
 			if err != nil {
-				return nil, err
+				if err := fail(newParseError(input, path, next, token.val, err)); err != nil {
+					return err
+				}
+				continue Loop
 			}
-			sshHost.Port = port
-		case itemProxyCommand:
+			sshHost.Match = criteria
+		case itemInclude:
+			attach(comments)
 			next = lexer.nextItem()
 			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+				return newParseError(input, path, next, token.val, valueError(token, next))
 			}
-			sshHost.ProxyCommand = next.val
-		case itemHostKeyAlgorithms:
-			next = lexer.nextItem()
-			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+
+			if o.skipIncludes {
+				o.debug("skipping include", "file", path, "line", token.line, "patterns", next.val)
+				continue Loop
 			}
-			sshHost.HostKeyAlgorithms = next.val
-		case itemIdentityFile:
-			next = lexer.nextItem()
-			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+			if o.depth >= o.includeDepth {
+				if err := fail(newParseError(input, path, next, token.val, fmt.Errorf("maximum include depth of %d exceeded", o.includeDepth))); err != nil {
+					return err
+				}
+				continue Loop
 			}
-			sshHost.IdentityFile = next.val
-		case itemLocalForward:
-			next = lexer.nextItem()
-			f, err := NewForward(next.val)
-			if err != nil {
-				return nil, err
+
+			includeOpts := append(opts[:len(opts):len(opts)], atDepth(o.depth+1), ignoring(o.ignoreUnknown))
+			if sshHost != nil {
+				// the enclosing block precedes the blocks of the included
+				// files, which only apply when it matches
+				if err := flush(); err != nil {
+					return err
+				}
+				includeOpts = append(includeOpts, inBlock(sshHost))
 			}
-			sshHost.LocalForwards = append(sshHost.LocalForwards, f)
-		case itemRemoteForward:
-			next = lexer.nextItem()
-			f, err := NewForward(next.val)
-			if err != nil {
-				return nil, err
+
+			err := parseInclude(path, next.val, o, includeOpts, yield)
+			if errors.Is(err, errStopped) {
+				return err
 			}
-			sshHost.RemoteForwards = append(sshHost.RemoteForwards, f)
-		case itemDynamicForward:
-			next = lexer.nextItem()
-			f, err := NewDynamicForward(next.val)
 			if err != nil {
-				return nil, err
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					err = newParseError(input, path, next, token.val, err)
+				}
+				if err := fail(err); err != nil {
+					return err
+				}
 			}
-			sshHost.DynamicForwards = append(sshHost.DynamicForwards, f)
-		case itemInclude:
+		case itemError:
+			attach(comments)
+			err := newParseError(input, path, token, "", errors.New(token.val))
+			if !o.lenient {
+				return err
+			}
+			errs = append(errs, err)
+			if err := flush(); err != nil {
+				return err
+			}
+			break Loop
+		case itemEOF:
+			attach(comments)
+			if err := flush(); err != nil {
+				return err
+			}
+			break Loop
+		case itemValue:
+			// continue onwards
+		default:
+			attach(comments)
 			next = lexer.nextItem()
 			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+				return newParseError(input, path, next, token.val, valueError(token, next))
+			}
+			directive := Directive{
+				Keyword: token.val,
+				Value:   next.val,
+				File:    path,
+				Line:    token.line,
+			}
+			if err := o.runHooks(&directive); errors.Is(err, ErrSkipDirective) {
+				o.debug("directive skipped by hook", "file", path, "line", token.line, "keyword", token.val)
+				continue Loop
+			} else if err != nil {
+				if err := fail(newParseError(input, path, token, token.val, err)); err != nil {
+					return err
+				}
+				continue Loop
 			}
+			directive.Keyword, directive.File, directive.Line = token.val, path, token.line
+			if o.deprecated && token.typ == itemUnknown {
+				if msg := deprecationWarning(token.val); msg != "" {
+					if err := fail(newParseError(input, path, token, token.val, errors.New(msg))); err != nil {
+						return err
+					}
+					continue Loop
+				}
+			}
+			if (o.warn != nil || o.strict) && !(token.typ == itemUnknown && o.ignoresUnknown(token.val)) {
+				if msg := directiveWarning(sshHost, token.typ, token.val); msg != "" {
+					if o.strict {
+						if err := fail(newParseError(input, path, token, token.val, errors.New(msg))); err != nil {
+							return err
+						}
+						continue Loop
+					}
+					o.warn(Warning{File: path, Line: token.line, Directive: token.val, Msg: msg})
+				}
+			}
+			if token.typ == itemUnknown {
+				o.debug("unknown keyword", "file", path, "line", token.line, "keyword", token.val, "ignored", o.ignoresUnknown(token.val))
+			}
+			// a repeated keyword keeps its first value unless the last value
+			// wins, it is recorded as a directive either way
+			if !o.lastWins && duplicateOf(sshHost, token.typ, token.val) != nil {
+				o.debug("repeated keyword has no effect", "file", path, "line", token.line, "keyword", token.val)
+			} else {
+				if err := sshHost.setValue(token.typ, token.val, directive.Value); err != nil {
+					if err := fail(newParseError(input, path, next, token.val, err)); err != nil {
+						return err
+					}
+					continue Loop
+				}
+			}
+			if token.typ == itemIgnoreUnknown {
+				// like OpenSSH the patterns apply to the keywords following
+				// them, regardless of the block they are given in
+				*o.ignoreUnknown = append(*o.ignoreUnknown, strings.Split(strings.ToLower(directive.Value), ",")...)
+			}
+			sshHost.Directives = append(sshHost.Directives, directive)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-			includePath, err := parseIncludePath(path, next.val)
-			if err != nil {
-				return nil, err
+// leadingComments returns the comments at the end of comments which are on
+// the lines directly above line.
+func leadingComments(comments []item, line int) []item {
+	i := len(comments)
+	for i > 0 && comments[i-1].line == line-(len(comments)-i)-1 {
+		i--
+	}
+	return comments[i:]
+}
+
+// splitSection splits the comments read before a Host or Match line, but
+// not directly above it, into those of the previous block and those naming
+// a section. Section comments are the trailing comments starting a line,
+// inline comments of a block are usually indented. The section is named by
+// the first comment of the last run of them, without decoration like
+// "# --- Work ---", or "" if there is none.
+func splitSection(input string, comments []item) ([]item, string) {
+	i := len(comments)
+	for i > 0 && (comments[i-1].pos == 0 || input[comments[i-1].pos-1] == '\n') {
+		if _, ok := parseAnnotation(commentText(comments[i-1].val)); ok {
+			break
+		}
+		i--
+	}
+	headers := comments[i:]
+
+	j := len(headers)
+	for j > 1 && headers[j-2].line == headers[j-1].line-1 {
+		j--
+	}
+	for _, c := range headers[max(j-1, 0):] {
+		if name := strings.Trim(commentText(c.val), "-=#*~ \t"); name != "" {
+			return comments[:i], name
+		}
+	}
+	return comments[:i], ""
+}
+
+// commentText returns the text of a comment line without the leading #.
+func commentText(comment string) string {
+	return strings.TrimSpace(strings.TrimPrefix(comment, "#"))
+}
+
+// parseInclude parses all files matched by the whitespace separated include
+// patterns and passes their blocks to yield. In lenient mode all files are
+// parsed and the errors are returned joined.
+func parseInclude(currentPath string, patterns string, o *options, opts []Option, yield func(*SSHHost) bool) error {
+	// files included within a block add their directives to it, they are
+	// parsed one by one so the first value wins in file order
+	concurrent := o.includeJobs > 1 && newOptions(opts).enclosing == nil
+
+	var errs []error
+	for _, pattern := range strings.Fields(patterns) {
+		files, err := includeFiles(currentPath, pattern, o)
+		if err != nil {
+			if !o.lenient {
+				return err
 			}
+			errs = append(errs, err)
+		}
+		o.debug("expanded include", "file", currentPath, "pattern", pattern, "files", files)
+		o.record.addInclude(currentPath, pattern, files)
 
-			files, err := filepath.Glob(includePath)
-			if err != nil {
-				return nil, err
+		if concurrent && len(files) > 1 {
+			for _, r := range parseIncludeConcurrent(files, o, opts) {
+				if o.warn != nil {
+					for _, w := range r.warnings {
+						o.warn(w)
+					}
+				}
+
+				for _, h := range r.hosts {
+					if !yield(h) {
+						return errStopped
+					}
+				}
+
+				if r.err != nil {
+					if !o.lenient {
+						return r.err
+					}
+					errs = append(errs, r.err)
+				}
 			}
+			continue
+		}
 
-			if len(files) == 0 {
-				return nil, fmt.Errorf("no files found for include path %s", includePath)
+		for _, f := range files {
+			input, lexer, err := readInclude(f, o)
+			if err != nil {
+				if !o.lenient {
+					return err
+				}
+				errs = append(errs, err)
+				continue
 			}
 
-			for _, f := range files {
-				includeSshConfigs, err := Parse(f)
-				if err != nil {
-					return nil, err
+			err = parseLexer(lexer, input, f, opts, yield)
+			if err != nil {
+				if !o.lenient || errors.Is(err, errStopped) {
+					return err
 				}
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// includeResult holds the outcome of parsing a single included file
+type includeResult struct {
+	hosts    []*SSHHost
+	warnings []Warning
+	err      error
+	// ignored are the IgnoreUnknown patterns after parsing the file
+	ignored []string
+	// record holds the files read while parsing the file
+	record *parseRecord
+}
+
+// parseIncludeConcurrent parses files with at most o.includeJobs files at a
+// time. The results are returned in the order of files so they can be
+// reported as if the files were parsed one by one.
+func parseIncludeConcurrent(files []string, o *options, opts []Option) []includeResult {
+	results := make([]includeResult, len(files))
+	jobs := make(chan struct{}, o.includeJobs)
 
-				sshConfigs = append(sshConfigs, includeSshConfigs...)
+	var wg sync.WaitGroup
+	for i, f := range files {
+		wg.Add(1)
+		jobs <- struct{}{}
+		go func() {
+			defer func() {
+				<-jobs
+				wg.Done()
+			}()
+
+			r := &results[i]
+			input, lexer, err := readInclude(f, o)
+			if err != nil {
+				r.err = err
+				return
 			}
-		case itemCiphers:
-			next = lexer.nextItem()
-			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+
+			// the files are parsed at the same time, so each one only sees
+			// the IgnoreUnknown patterns read before the Include
+			r.ignored = slices.Clone(*o.ignoreUnknown)
+			fileOpts := append(opts[:len(opts):len(opts)], ignoring(&r.ignored))
+			if o.warn != nil {
+				fileOpts = append(fileOpts, WithWarnings(func(w Warning) {
+					r.warnings = append(r.warnings, w)
+				}))
 			}
-			sshHost.Ciphers = strings.Split(next.val, ",")
-		case itemMACs:
-			next = lexer.nextItem()
-			if next.typ != itemValue {
-				return nil, fmt.Errorf(next.val)
+			if o.record != nil {
+				r.record = &parseRecord{}
+				fileOpts = append(fileOpts, recording(r.record))
 			}
-			sshHost.MACs = strings.Split(next.val, ",")
-		case itemError:
-			return nil, fmt.Errorf("%s at pos %d", token.val, token.pos)
-		case itemEOF:
-			if sshHost != nil {
-				sshConfigs = append(sshConfigs, sshHost)
+
+			r.err = parseLexer(lexer, input, f, fileOpts, func(h *SSHHost) bool {
+				r.hosts = append(r.hosts, h)
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+
+	base := len(*o.ignoreUnknown)
+	for _, r := range results {
+		if len(r.ignored) > base {
+			*o.ignoreUnknown = append(*o.ignoreUnknown, r.ignored[base:]...)
+		}
+		o.record.merge(r.record)
+	}
+
+	return results
+}
+
+// includeFiles returns the files matched by a single include pattern in
+// lexical order. A matched directory is replaced by the files in it.
+func includeFiles(currentPath string, pattern string, o *options) ([]string, error) {
+	includePath, err := parseIncludePath(currentPath, pattern, o.includeMode)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := o.resolver.Glob(includePath)
+	if err != nil {
+		return nil, err
+	}
+	// resolvers may return matches in any order, ssh reads them sorted
+	slices.Sort(matches)
+
+	var files []string
+	for _, match := range matches {
+		dirFiles, ok, err := includeDir(match, o.resolver)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			files = append(files, match)
+			continue
+		}
+		files = append(files, dirFiles...)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files found for include path %s", includePath)
+	}
+
+	return files, nil
+}
+
+// includeDir returns the files in name, in lexical order, if it is a
+// directory. Subdirectories are skipped. Directories are only detected with
+// resolvers which can stat files, like the default one.
+func includeDir(name string, resolver IncludeResolver) ([]string, bool, error) {
+	r, ok := resolver.(statResolver)
+	if !ok {
+		return nil, false, nil
+	}
+	info, err := r.Stat(name)
+	if err != nil || !info.IsDir() {
+		// unreadable files are reported when they are read
+		return nil, false, nil
+	}
+
+	matches, err := resolver.Glob(filepath.Join(name, "*"))
+	if err != nil {
+		return nil, false, err
+	}
+	slices.Sort(matches)
+
+	var files []string
+	for _, match := range matches {
+		if info, err := r.Stat(match); err == nil && info.IsDir() {
+			continue
+		}
+		files = append(files, match)
+	}
+	return files, true, nil
+}
+
+// setValue applies the value of a single directive to the host. Keywords
+// which may be given multiple times accumulate their values.
+func (h *SSHHost) setValue(typ itemType, keyword string, value string) error {
+	// quoted arguments are unquoted, values of keywords taking a single
+	// argument may thus contain spaces
+	var args []string
+	if !commandKeywords[typ] {
+		var err error
+		if args, err = splitArgs(value); err != nil {
+			return err
+		}
+		value = strings.Join(args, " ")
+	}
+
+	switch typ {
+	case itemTag:
+		h.Tag = value
+	case itemHostName:
+		h.HostName = value
+	case itemUser:
+		h.User = value
+	case itemPort:
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		h.Port = port
+		h.PortSet = true
+	case itemProxyCommand:
+		h.ProxyCommand = value
+	case itemHostKeyAlgorithms:
+		h.HostKeyAlgorithms = strings.Split(value, ",")
+	case itemIdentityFile:
+		h.IdentityFile = value
+		h.IdentityFiles = append(h.IdentityFiles, value)
+	case itemCertificateFile:
+		h.CertificateFiles = append(h.CertificateFiles, value)
+	case itemLocalForward:
+		f, err := NewForward(value)
+		if err != nil {
+			return err
+		}
+		h.LocalForwards = append(h.LocalForwards, f)
+	case itemRemoteForward:
+		f, err := newRemoteForward(value)
+		if err != nil {
+			return err
+		}
+		h.RemoteForwards = append(h.RemoteForwards, f)
+	case itemDynamicForward:
+		f, err := NewDynamicForward(value)
+		if err != nil {
+			return err
+		}
+		h.DynamicForwards = append(h.DynamicForwards, f)
+	case itemCiphers:
+		h.Ciphers = strings.Split(value, ",")
+	case itemMACs:
+		h.MACs = strings.Split(value, ",")
+	case itemProxyJump:
+		if _, err := ParseProxyJump(value); err != nil {
+			return err
+		}
+		h.ProxyJump = value
+	case itemForwardAgent:
+		h.ForwardAgent = value
+	case itemControlMaster:
+		v, err := parseYesNoEnum("ControlMaster", value, ControlMasterYes, ControlMasterNo, ControlMasterAsk, ControlMasterAuto, ControlMasterAutoAsk)
+		if err != nil {
+			return err
+		}
+		h.ControlMaster = v
+	case itemControlPath:
+		h.ControlPath = value
+	case itemControlPersist:
+		if err := parseControlPersist(value); err != nil {
+			return err
+		}
+		h.ControlPersist = value
+	case itemServerAliveInterval:
+		d, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		h.ServerAliveInterval = d
+	case itemServerAliveCountMax:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		h.ServerAliveCountMax = n
+	case itemStrictHostKeyChecking:
+		v, err := parseYesNoEnum("StrictHostKeyChecking", value, StrictHostKeyCheckingYes, StrictHostKeyCheckingNo, StrictHostKeyCheckingOff, StrictHostKeyCheckingAsk, StrictHostKeyCheckingAcceptNew)
+		if err != nil {
+			return err
+		}
+		h.StrictHostKeyChecking = v
+	case itemUserKnownHostsFile:
+		h.UserKnownHostsFiles = args
+	case itemGlobalKnownHostsFile:
+		h.GlobalKnownHostsFiles = args
+	case itemPreferredAuthentications:
+		h.PreferredAuthentications = strings.Split(value, ",")
+	case itemPubkeyAuthentication:
+		h.PubkeyAuthentication = value
+	case itemPasswordAuthentication:
+		v, err := parseTriBool("PasswordAuthentication", value)
+		if err != nil {
+			return err
+		}
+		h.PasswordAuthentication = v
+	case itemKbdInteractiveAuthentication:
+		v, err := parseTriBool("KbdInteractiveAuthentication", value)
+		if err != nil {
+			return err
+		}
+		h.KbdInteractiveAuthentication = v
+	case itemKexAlgorithms:
+		h.KexAlgorithms = strings.Split(value, ",")
+	case itemPubkeyAcceptedAlgorithms:
+		h.PubkeyAcceptedAlgorithms = strings.Split(value, ",")
+	case itemSendEnv:
+		h.SendEnv = append(h.SendEnv, args...)
+	case itemSetEnv:
+		if h.SetEnv == nil {
+			h.SetEnv = map[string]string{}
+		}
+		for _, env := range args {
+			name, v, ok := strings.Cut(env, "=")
+			if !ok || name == "" {
+				return fmt.Errorf("invalid SetEnv: %#v", env)
 			}
-			break Loop
-		default:
-			// continue onwards
+			if _, ok := h.SetEnv[name]; !ok {
+				h.SetEnv[name] = v
+			}
+		}
+	case itemLocalCommand:
+		h.LocalCommand = value
+	case itemPermitLocalCommand:
+		v, err := parseTriBool("PermitLocalCommand", value)
+		if err != nil {
+			return err
+		}
+		h.PermitLocalCommand = v
+	case itemRemoteCommand:
+		h.RemoteCommand = value
+	case itemRequestTTY:
+		v, err := parseYesNoEnum("RequestTTY", value, RequestTTYYes, RequestTTYNo, RequestTTYForce, RequestTTYAuto)
+		if err != nil {
+			return err
+		}
+		h.RequestTTY = v
+	case itemSessionType:
+		v, err := parseEnum("SessionType", value, SessionTypeNone, SessionTypeSubsystem, SessionTypeDefault)
+		if err != nil {
+			return err
+		}
+		h.SessionType = v
+	case itemForwardX11:
+		v, err := parseTriBool("ForwardX11", value)
+		if err != nil {
+			return err
+		}
+		h.ForwardX11 = v
+	case itemForwardX11Trusted:
+		v, err := parseTriBool("ForwardX11Trusted", value)
+		if err != nil {
+			return err
+		}
+		h.ForwardX11Trusted = v
+	case itemForwardX11Timeout:
+		d, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		h.ForwardX11Timeout = d
+	case itemCanonicalizeHostname:
+		h.CanonicalizeHostname = value
+	case itemCanonicalDomains:
+		h.CanonicalDomains = args
+	case itemCanonicalizeMaxDots:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		h.CanonicalizeMaxDots = n
+		h.CanonicalizeMaxDotsSet = true
+	case itemCanonicalizeFallbackLocal:
+		v, err := parseTriBool("CanonicalizeFallbackLocal", value)
+		if err != nil {
+			return err
+		}
+		h.CanonicalizeFallbackLocal = v
+	case itemCanonicalizePermittedCNAMEs:
+		h.CanonicalizePermittedCNAMEs = args
+	case itemHashKnownHosts:
+		v, err := parseTriBool("HashKnownHosts", value)
+		if err != nil {
+			return err
+		}
+		h.HashKnownHosts = v
+	case itemCheckHostIP:
+		v, err := parseTriBool("CheckHostIP", value)
+		if err != nil {
+			return err
+		}
+		h.CheckHostIP = v
+	case itemVerifyHostKeyDNS:
+		h.VerifyHostKeyDNS = value
+	case itemTCPKeepAlive:
+		v, err := parseTriBool("TCPKeepAlive", value)
+		if err != nil {
+			return err
+		}
+		h.TCPKeepAlive = v
+	case itemTunnel:
+		v, err := parseYesNoEnum("Tunnel", value, TunnelYes, TunnelNo, TunnelPointToPoint, TunnelEthernet)
+		if err != nil {
+			return err
+		}
+		h.Tunnel = v
+	case itemTunnelDevice:
+		h.TunnelDevice = value
+	case itemGatewayPorts:
+		v, err := parseTriBool("GatewayPorts", value)
+		if err != nil {
+			return err
+		}
+		h.GatewayPorts = v
+	case itemExitOnForwardFailure:
+		v, err := parseTriBool("ExitOnForwardFailure", value)
+		if err != nil {
+			return err
+		}
+		h.ExitOnForwardFailure = v
+	case itemClearAllForwardings:
+		v, err := parseTriBool("ClearAllForwardings", value)
+		if err != nil {
+			return err
+		}
+		h.ClearAllForwardings = v
+	case itemLogLevel:
+		h.LogLevel = value
+	case itemSyslogFacility:
+		h.SyslogFacility = value
+	case itemBatchMode:
+		v, err := parseTriBool("BatchMode", value)
+		if err != nil {
+			return err
+		}
+		h.BatchMode = v
+	case itemNumberOfPasswordPrompts:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		h.NumberOfPasswordPrompts = n
+	case itemEscapeChar:
+		h.EscapeChar = value
+	case itemEnableEscapeCommandline:
+		v, err := parseTriBool("EnableEscapeCommandline", value)
+		if err != nil {
+			return err
+		}
+		h.EnableEscapeCommandline = v
+	case itemPKCS11Provider:
+		h.PKCS11Provider = value
+	case itemSecurityKeyProvider:
+		h.SecurityKeyProvider = value
+	case itemGSSAPIAuthentication:
+		v, err := parseTriBool("GSSAPIAuthentication", value)
+		if err != nil {
+			return err
+		}
+		h.GSSAPIAuthentication = v
+	case itemGSSAPIDelegateCredentials:
+		v, err := parseTriBool("GSSAPIDelegateCredentials", value)
+		if err != nil {
+			return err
+		}
+		h.GSSAPIDelegateCredentials = v
+	case itemGSSAPIKeyExchange:
+		v, err := parseTriBool("GSSAPIKeyExchange", value)
+		if err != nil {
+			return err
+		}
+		h.GSSAPIKeyExchange = v
+	case itemGSSAPITrustDns:
+		v, err := parseTriBool("GSSAPITrustDns", value)
+		if err != nil {
+			return err
+		}
+		h.GSSAPITrustDns = v
+	case itemGSSAPIClientIdentity:
+		h.GSSAPIClientIdentity = value
+	case itemGSSAPIServerIdentity:
+		h.GSSAPIServerIdentity = value
+	case itemRekeyLimit:
+		limit, err := ParseRekeyLimit(value)
+		if err != nil {
+			return err
+		}
+		h.RekeyLimit = limit
+	case itemUpdateHostKeys:
+		v, err := parseYesNoEnum("UpdateHostKeys", value, UpdateHostKeysYes, UpdateHostKeysNo, UpdateHostKeysAsk)
+		if err != nil {
+			return err
+		}
+		h.UpdateHostKeys = v
+	case itemIgnoreUnknown:
+		h.IgnoreUnknown = strings.Split(value, ",")
+	case itemPermitOpen:
+		h.PermitOpen = args
+	case itemPermitRemoteOpen:
+		h.PermitRemoteOpen = args
+	case itemIdentityAgent:
+		h.IdentityAgent = value
+	case itemConnectTimeout:
+		// none leaves the timeout to the system, ssh -G prints it when unset
+		if strings.EqualFold(value, "none") {
+			return nil
+		}
+		d, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		h.ConnectTimeout = d
+	case itemAddKeysToAgent:
+		v, err := parseAddKeysToAgent(value)
+		if err != nil {
+			return err
+		}
+		h.AddKeysToAgent = v
+	case itemKnownHostsCommand:
+		h.KnownHostsCommand = value
+	case itemFingerprintHash:
+		v, err := parseEnum("FingerprintHash", value, FingerprintHashMD5, FingerprintHashSHA256)
+		if err != nil {
+			return err
 		}
+		h.FingerprintHash = v
+	case itemRequiredRSASize:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid RequiredRSASize: %#v", value)
+		}
+		h.RequiredRSASize = n
+	case itemChannelTimeout:
+		timeouts, err := parseChannelTimeouts(args)
+		if err != nil {
+			return err
+		}
+		h.ChannelTimeouts = timeouts
+	case itemObscureKeystrokeTiming:
+		if err := parseObscureKeystrokeTiming(value); err != nil {
+			return err
+		}
+		h.ObscureKeystrokeTiming = value
+	case itemStdinNull:
+		v, err := parseTriBool("StdinNull", value)
+		if err != nil {
+			return err
+		}
+		h.StdinNull = v
+	case itemForkAfterAuthentication:
+		v, err := parseTriBool("ForkAfterAuthentication", value)
+		if err != nil {
+			return err
+		}
+		h.ForkAfterAuthentication = v
+	case itemProxyUseFdpass:
+		v, err := parseTriBool("ProxyUseFdpass", value)
+		if err != nil {
+			return err
+		}
+		h.ProxyUseFdpass = v
+	case itemStreamLocalBindMask:
+		mask, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || mask > 0777 {
+			return fmt.Errorf("invalid StreamLocalBindMask: %#v", value)
+		}
+		m := os.FileMode(mask)
+		h.StreamLocalBindMask = &m
+	case itemStreamLocalBindUnlink:
+		v, err := parseTriBool("StreamLocalBindUnlink", value)
+		if err != nil {
+			return err
+		}
+		h.StreamLocalBindUnlink = v
+	case itemHostbasedAuthentication:
+		v, err := parseTriBool("HostbasedAuthentication", value)
+		if err != nil {
+			return err
+		}
+		h.HostbasedAuthentication = v
+	case itemHostbasedAcceptedAlgorithms:
+		h.HostbasedAcceptedAlgorithms = strings.Split(value, ",")
+	case itemNoHostAuthenticationForLocalhost:
+		v, err := parseTriBool("NoHostAuthenticationForLocalhost", value)
+		if err != nil {
+			return err
+		}
+		h.NoHostAuthenticationForLocalhost = v
+	case itemHostKeyAlias:
+		h.HostKeyAlias = value
+	case itemUnknown:
+		if err := h.setExtension(keyword, value); err != nil {
+			return err
+		}
+		if h.Unknowns == nil {
+			h.Unknowns = map[string][]string{}
+		}
+		h.Unknowns[keyword] = append(h.Unknowns[keyword], value)
+	default:
+		return fmt.Errorf("%s can not be set on a host", keyword)
 	}
-	return sshConfigs, nil
+	return nil
 }
 
-func parseIncludePath(currentPath string, includePath string) (string, error) {
+// valueError returns the error for a directive not followed by a value.
+func valueError(directive item, next item) error {
+	if next.typ == itemError {
+		return errors.New(next.val)
+	}
+	return fmt.Errorf("missing value for %s", directive.val)
+}
+
+func parseIncludePath(currentPath string, includePath string, mode IncludeMode) (string, error) {
 	if strings.HasPrefix(includePath, "~") {
-		expandedPath, err := homedir.Expand(includePath)
+		expandedPath, err := expandHome(includePath)
 		if err != nil {
 			return "", err
 		}
 
 		return expandedPath, nil
-	} else if !strings.HasPrefix(includePath, "/") {
+	} else if !strings.HasPrefix(includePath, "/") && !filepath.IsAbs(includePath) {
+		switch mode {
+		case IncludeUser:
+			return expandHome(filepath.Join("~/.ssh", includePath))
+		case IncludeSystem:
+			return filepath.Join(systemDir, includePath), nil
+		}
 		return filepath.Join(filepath.Dir(currentPath), includePath), nil
 	}
 
@@ -2,14 +2,19 @@ package sshconfig
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"reflect"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/mitchellh/go-homedir"
 )
@@ -128,16 +133,21 @@ Host other
 			HostName:          "google.se",
 			User:              "goog",
 			Port:              2222,
-			HostKeyAlgorithms: "ssh-dss",
+			PortSet:           true,
+			HostKeyAlgorithms: []string{"ssh-dss"},
 			ProxyCommand:      "ssh -q pluto nc saturn 22",
 			IdentityFile:      "~/.ssh/company",
+			IdentityFiles:     []string{"~/.ssh/company"},
+			Unknowns:          map[string][]string{"IdentityOnly": {"yes"}},
+			Comments:          []string{"comment"},
 		},
 		{
 			Host:              []string{"face"},
 			User:              "mark",
 			Port:              22,
+			PortSet:           true,
 			HostName:          "facebook.com",
-			HostKeyAlgorithms: "",
+			HostKeyAlgorithms: nil,
 			ProxyCommand:      "",
 			IdentityFile:      "",
 		},
@@ -145,6 +155,7 @@ Host other
 			Host:     []string{"other"},
 			User:     "root",
 			Port:     22,
+			PortSet:  true,
 			HostName: "example.org",
 			Ciphers:  []string{"3des-cbc", "blowfish-cbc", "cast128-cbc"},
 			MACs:     []string{"hmac-sha1", "hmac-sha1-96"},
@@ -310,9 +321,13 @@ Host face
 			HostName:          "google.se",
 			User:              "goog",
 			Port:              2222,
-			HostKeyAlgorithms: "ssh-dss",
+			PortSet:           true,
+			HostKeyAlgorithms: []string{"ssh-dss"},
 			ProxyCommand:      "ssh -q pluto nc saturn 22",
 			IdentityFile:      "~/.ssh/company",
+			IdentityFiles:     []string{"~/.ssh/company"},
+			Unknowns:          map[string][]string{"IdentityOnly": {"yes"}},
+			Comments:          []string{"comment"},
 			LocalForwards: []Forward{
 				{
 					InHost:  "",
@@ -326,8 +341,9 @@ Host face
 			Host:              []string{"face"},
 			User:              "mark",
 			Port:              22,
+			PortSet:           true,
 			HostName:          "facebook.com",
-			HostKeyAlgorithms: "",
+			HostKeyAlgorithms: nil,
 			ProxyCommand:      "",
 			IdentityFile:      "",
 			LocalForwards: []Forward{
@@ -363,7 +379,7 @@ func TestLocalForwardInvalid1(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "Invalid forward: \"2222 totalylegitserver 22\""
+	expectedErr := "~/.ssh/config:5:16: Invalid forward: \"2222 totalylegitserver 22\""
 
 	actual, err := parse(config, "~/.ssh/config")
 	if err == nil || err.Error() != expectedErr {
@@ -382,7 +398,7 @@ func TestLocalForwardInvalid2(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "strconv.Atoi: parsing \"9223372036854775808\": value out of range"
+	expectedErr := "~/.ssh/config:5:16: strconv.Atoi: parsing \"9223372036854775808\": value out of range"
 
 	actual, err := parse(config, "~/.ssh/config")
 	if err == nil || err.Error() != expectedErr {
@@ -401,7 +417,7 @@ func TestLocalForwardInvalid3(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "strconv.Atoi: parsing \"9223372036854775808\": value out of range"
+	expectedErr := "~/.ssh/config:5:16: strconv.Atoi: parsing \"9223372036854775808\": value out of range"
 
 	actual, err := parse(config, "~/.ssh/config")
 	if err == nil || err.Error() != expectedErr {
@@ -436,9 +452,13 @@ Host face
 			HostName:          "google.se",
 			User:              "goog",
 			Port:              2222,
-			HostKeyAlgorithms: "ssh-dss",
+			PortSet:           true,
+			HostKeyAlgorithms: []string{"ssh-dss"},
 			ProxyCommand:      "ssh -q pluto nc saturn 22",
 			IdentityFile:      "~/.ssh/company",
+			IdentityFiles:     []string{"~/.ssh/company"},
+			Unknowns:          map[string][]string{"IdentityOnly": {"yes"}},
+			Comments:          []string{"comment"},
 			RemoteForwards: []Forward{
 				{
 					InHost:  "",
@@ -452,8 +472,9 @@ Host face
 			Host:              []string{"face"},
 			User:              "mark",
 			Port:              22,
+			PortSet:           true,
 			HostName:          "facebook.com",
-			HostKeyAlgorithms: "",
+			HostKeyAlgorithms: nil,
 			ProxyCommand:      "",
 			IdentityFile:      "",
 			RemoteForwards: []Forward{
@@ -489,7 +510,7 @@ func TestRemoteForwardInvalid1(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "Invalid forward: \"abc totalylegitserver:22\""
+	expectedErr := "~/.ssh/config:5:16: Invalid forward: \"abc totalylegitserver:22\""
 
 	actual, err := parse(config, "~/.ssh/config")
 	if err == nil || err.Error() != expectedErr {
@@ -524,9 +545,13 @@ Host face
 			HostName:          "google.se",
 			User:              "goog",
 			Port:              2222,
-			HostKeyAlgorithms: "ssh-dss",
+			PortSet:           true,
+			HostKeyAlgorithms: []string{"ssh-dss"},
 			ProxyCommand:      "ssh -q pluto nc saturn 22",
 			IdentityFile:      "~/.ssh/company",
+			IdentityFiles:     []string{"~/.ssh/company"},
+			Unknowns:          map[string][]string{"IdentityOnly": {"yes"}},
+			Comments:          []string{"comment"},
 			DynamicForwards: []DynamicForward{
 				{
 					Host: "",
@@ -538,8 +563,9 @@ Host face
 			Host:              []string{"face"},
 			User:              "mark",
 			Port:              22,
+			PortSet:           true,
 			HostName:          "facebook.com",
-			HostKeyAlgorithms: "",
+			HostKeyAlgorithms: nil,
 			ProxyCommand:      "",
 			IdentityFile:      "",
 			DynamicForwards: []DynamicForward{
@@ -571,7 +597,7 @@ func TestDynamicForward1(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "Invalid dynamic forward: \"abc\""
+	expectedErr := "~/.ssh/config:5:18: Invalid dynamic forward: \"abc\""
 
 	actual, err := parse(config, "~/.ssh/config")
 	if err == nil || err.Error() != expectedErr {
@@ -590,7 +616,7 @@ func TestDynamicForward2(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "strconv.Atoi: parsing \"9223372036854775808\": value out of range"
+	expectedErr := "~/.ssh/config:5:18: strconv.Atoi: parsing \"9223372036854775808\": value out of range"
 
 	actual, err := parse(config, "~/.ssh/config")
 	if err == nil || err.Error() != expectedErr {
@@ -622,6 +648,11 @@ func toMap(t *testing.T, a *SSHHost) map[string]interface{} {
 		t.Errorf("unmarshaling expected %s", err)
 	}
 
+	// position metadata is covered by dedicated tests
+	delete(aMap, "directives")
+	delete(aMap, "sourceFile")
+	delete(aMap, "sourceLine")
+
 	return aMap
 }
 
@@ -638,7 +669,7 @@ func TestParse(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "strconv.Atoi: parsing \"9223372036854775808\": value out of range"
+	expectedErr := "/tmp/example:5:17: strconv.Atoi: parsing \"9223372036854775808\": value out of range"
 
 	if err == nil || err.Error() != expectedErr {
 		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err.Error())
@@ -665,7 +696,7 @@ func TestParseFS(t *testing.T) {
 
 	var expected []*SSHHost
 
-	expectedErr := "strconv.Atoi: parsing \"9223372036854775808\": value out of range"
+	expectedErr := "config:5:17: strconv.Atoi: parsing \"9223372036854775808\": value out of range"
 
 	if err == nil || err.Error() != expectedErr {
 		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err.Error())
@@ -730,9 +761,1728 @@ func TestHostlessFile(t *testing.T) {
 		t.Errorf("unable to write to file: %s", err.Error())
 	}
 
-	_, err = parse(config, tmpdir + "/config")
+	_, err = parse(config, tmpdir+"/config")
+
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+}
+
+func TestMultipleIdentityFiles(t *testing.T) {
+	config := `Host google
+  HostName google.se
+  IdentityFile ~/.ssh/id_ed25519
+  IdentityFile ~/.ssh/id_rsa`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []string{"~/.ssh/id_ed25519", "~/.ssh/id_rsa"}
+	if ok := reflect.DeepEqual(expected, hosts[0].IdentityFiles); !ok {
+		t.Errorf("unexpected identity files: %v", hosts[0].IdentityFiles)
+	}
+}
+
+func TestCertificateFiles(t *testing.T) {
+	config := `Host google
+  HostName google.se
+  IdentityFile ~/.ssh/id_ed25519
+  CertificateFile ~/.ssh/id_ed25519-cert.pub
+  CertificateFile ~/.ssh/ca/google-cert.pub`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []string{"~/.ssh/id_ed25519-cert.pub", "~/.ssh/ca/google-cert.pub"}
+	if ok := reflect.DeepEqual(expected, hosts[0].CertificateFiles); !ok {
+		t.Errorf("unexpected certificate files: %v", hosts[0].CertificateFiles)
+	}
+}
+
+func TestUnknownKeywords(t *testing.T) {
+	config := `Host google
+  HostName google.se
+  VisualHostKey yes
+  XAuthLocation /usr/bin/xauth
+  XAuthLocation /opt/X11/bin/xauth`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	expected := map[string][]string{
+		"VisualHostKey": {"yes"},
+		"XAuthLocation": {"/usr/bin/xauth", "/opt/X11/bin/xauth"},
+	}
+	if ok := reflect.DeepEqual(expected, hosts[0].Unknowns); !ok {
+		t.Errorf("unexpected unknowns: %v", hosts[0].Unknowns)
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	config := `# comment
+Host google
+  HostName google.se
+  Port 2222
+
+  # comment
+  VisualHostKey yes
+Host face
+  User mark`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []Directive{
+		{Keyword: "HostName", Value: "google.se", File: "~/.ssh/config", Line: 3},
+		{Keyword: "Port", Value: "2222", File: "~/.ssh/config", Line: 4},
+		{Keyword: "VisualHostKey", Value: "yes", File: "~/.ssh/config", Line: 7},
+	}
+	if ok := reflect.DeepEqual(expected, hosts[0].Directives); !ok {
+		t.Errorf("unexpected directives: %+v", hosts[0].Directives)
+	}
+
+	expected = []Directive{
+		{Keyword: "User", Value: "mark", File: "~/.ssh/config", Line: 9},
+	}
+	if ok := reflect.DeepEqual(expected, hosts[1].Directives); !ok {
+		t.Errorf("unexpected directives: %+v", hosts[1].Directives)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	config := `Host face
+  HostName facebook.com
+  Port abc`
+
+	_, err := parse(config, "~/.ssh/config")
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError, got %#v", err)
+	}
+
+	if parseErr.File != "~/.ssh/config" || parseErr.Line != 3 || parseErr.Column != 8 || parseErr.Directive != "Port" {
+		t.Errorf("unexpected error location: %+v", parseErr)
+	}
+
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected error to wrap strconv.ErrSyntax: %#v", parseErr.Err)
+	}
+}
+
+func TestLenient(t *testing.T) {
+	config := `User nobody
+
+Host face
+  HostName facebook.com
+  Port abc
+  LocalForward 2222 totalylegitserver 22
+  User mark
+
+Match foo bar
+  User nobody
+
+Host google
+  HostName google.se
+  DynamicForward 8080`
+
+	expected := []*SSHHost{
+		{
+			Host: []string{"*"},
+			User: "nobody",
+			Port: 22,
+		},
+		{
+			Host:     []string{"face"},
+			HostName: "facebook.com",
+			User:     "mark",
+			Port:     22,
+		},
+		{
+			Host: []string{},
+			User: "nobody",
+			Port: 22,
+		},
+		{
+			Host:     []string{"google"},
+			HostName: "google.se",
+			Port:     22,
+			DynamicForwards: []DynamicForward{
+				{Port: 8080},
+			},
+		},
+	}
+
+	actual, err := parse(config, "~/.ssh/config", WithLenient())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	expectedErr := `~/.ssh/config:5:8: strconv.Atoi: parsing "abc": invalid syntax
+~/.ssh/config:6:16: Invalid forward: "2222 totalylegitserver 22"
+~/.ssh/config:9:7: unsupported Match criteria foo`
+	if err.Error() != expectedErr {
+		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err.Error())
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 5 {
+		t.Errorf("expected joined ParseError, got %#v", err)
+	}
+
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d hosts, got %d", len(expected), len(actual))
+	}
+	compare(t, expected, actual)
+
+	_, err = parse(config, "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:5:8: strconv.Atoi: parsing "abc": invalid syntax` {
+		t.Errorf("expected strict parse to fail on first error, got %#v", err)
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	config := `Host face
+  HostName facebook.com
+  User mark`
+
+	expected := []*SSHHost{
+		{
+			Host:     []string{"face"},
+			HostName: "facebook.com",
+			User:     "mark",
+			Port:     22,
+		},
+	}
+
+	actual, err := ParseReader(strings.NewReader(config), "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	compare(t, expected, actual)
+}
+
+func TestParseString(t *testing.T) {
+	config := `Host face
+  HostName facebook.com
+  User mark`
+
+	expected := []*SSHHost{
+		{
+			Host:     []string{"face"},
+			HostName: "facebook.com",
+			User:     "mark",
+			Port:     22,
+		},
+	}
+
+	actual, err := ParseString(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+	compare(t, expected, actual)
 
+	actual, err = ParseBytes([]byte(config), "~/.ssh/config")
 	if err != nil {
 		t.Errorf("unable to parse config: %s", err.Error())
 	}
+	compare(t, expected, actual)
+}
+
+func TestIncludeInHostBlock(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  Include web.conf
+  Port 2222
+
+Host *
+  User nobody`
+
+	configWeb := `User deploy
+IdentityFile ~/.ssh/web
+
+Host db
+  User dbadmin`
+
+	tmpdir := t.TempDir()
+
+	err := os.WriteFile(tmpdir+"/web.conf", []byte(configWeb), 0644)
+	if err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	hosts, err := parse(config, tmpdir+"/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []*SSHHost{
+		{
+			Host:          []string{"web"},
+			HostName:      "web.example.com",
+			User:          "deploy",
+			Port:          2222,
+			PortSet:       true,
+			IdentityFile:  "~/.ssh/web",
+			IdentityFiles: []string{"~/.ssh/web"},
+		},
+		{
+			Host: []string{"db"},
+			User: "dbadmin",
+			Port: 22,
+		},
+		{
+			Host: []string{"*"},
+			User: "nobody",
+			Port: 22,
+		},
+	}
+	if len(hosts) != len(expected) {
+		t.Fatalf("expected %d hosts, got %d", len(expected), len(hosts))
+	}
+	compare(t, expected, hosts)
+
+	if hosts[0].Directives[1].File != tmpdir+"/web.conf" {
+		t.Errorf("unexpected directive file: %s", hosts[0].Directives[1].File)
+	}
+
+	// the db block is only active when the including web block is
+	if h := Lookup(hosts, "db"); h.User != "nobody" {
+		t.Errorf("expected user nobody for db, got %s", h.User)
+	}
+	if h := Lookup(hosts, "web"); h.User != "deploy" || h.Port != 2222 {
+		t.Errorf("unexpected host: %+v", h)
+	}
+}
+
+func TestIncludeMultiplePatterns(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	if err := os.MkdirAll(tmpdir+"/conf.d", 0700); err != nil {
+		t.Fatalf("unable to create dir: %s", err.Error())
+	}
+
+	files := map[string]string{
+		"/conf.d/a.conf": "Host a\n",
+		"/conf.d/b.conf": "Host b\n",
+		"/extra.conf":    "Host extra\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(tmpdir+name, []byte(content), 0600); err != nil {
+			t.Fatalf("unable to write file: %s", err.Error())
+		}
+	}
+
+	hosts, err := parse("Include conf.d/*   extra.conf\n", tmpdir+"/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	var aliases []string
+	for _, h := range hosts {
+		aliases = append(aliases, h.Host...)
+	}
+	if !reflect.DeepEqual([]string{"a", "b", "extra"}, aliases) {
+		t.Errorf("unexpected hosts: %v", aliases)
+	}
+}
+
+func TestSourcePosition(t *testing.T) {
+	configA := `# team hosts
+Include b.conf
+
+Host face
+  HostName facebook.com`
+	configB := `
+Host google
+  HostName google.se`
+
+	tmpdir := t.TempDir()
+
+	err := os.WriteFile(tmpdir+"/b.conf", []byte(configB), 0644)
+	if err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	hosts, err := parse(configA, tmpdir+"/a.conf")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].SourceFile != tmpdir+"/b.conf" || hosts[0].SourceLine != 2 {
+		t.Errorf("unexpected source of google: %s:%d", hosts[0].SourceFile, hosts[0].SourceLine)
+	}
+	if hosts[1].SourceFile != tmpdir+"/a.conf" || hosts[1].SourceLine != 4 {
+		t.Errorf("unexpected source of face: %s:%d", hosts[1].SourceFile, hosts[1].SourceLine)
+	}
+}
+
+func TestParseSeq(t *testing.T) {
+	config := `Host a
+  User mark
+Include b.conf
+Host d`
+
+	tmpdir := t.TempDir()
+
+	if err := os.WriteFile(tmpdir+"/a.conf", []byte(config), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if err := os.WriteFile(tmpdir+"/b.conf", []byte("Host b\nHost c\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	var aliases []string
+	for h, err := range ParseSeq(tmpdir + "/a.conf") {
+		if err != nil {
+			t.Fatalf("unable to parse config: %s", err.Error())
+		}
+		aliases = append(aliases, h.Host...)
+	}
+	if !reflect.DeepEqual([]string{"a", "b", "c", "d"}, aliases) {
+		t.Errorf("unexpected hosts: %v", aliases)
+	}
+
+	aliases = nil
+	for h, err := range ParseSeq(tmpdir + "/a.conf") {
+		if err != nil {
+			t.Fatalf("unable to parse config: %s", err.Error())
+		}
+		aliases = append(aliases, h.Host...)
+		if h.Host[0] == "b" {
+			break
+		}
+	}
+	if !reflect.DeepEqual([]string{"a", "b"}, aliases) {
+		t.Errorf("unexpected hosts: %v", aliases)
+	}
+
+	for h, err := range ParseSeq(tmpdir + "/missing.conf") {
+		if h != nil || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected not exist error, got %v, %v", h, err)
+		}
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	config := `Host *
+  User admin
+
+Host web*
+  User deploy
+Include b.conf
+Host invalid
+  Port nope`
+
+	tmpdir := t.TempDir()
+	if err := os.WriteFile(tmpdir+"/a.conf", []byte(config), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if err := os.WriteFile(tmpdir+"/b.conf", []byte("Host db\n  User postgres\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	h, err := ParseHost(tmpdir+"/a.conf", "web1")
+	if err != nil {
+		t.Fatalf("unable to parse host: %s", err.Error())
+	}
+	if h.Host[0] != "web*" || h.User != "deploy" {
+		t.Errorf("unexpected host: %v, %s", h.Host, h.User)
+	}
+
+	h, err = ParseHost(tmpdir+"/a.conf", "db")
+	if err != nil {
+		t.Fatalf("unable to parse host: %s", err.Error())
+	}
+	if h.Host[0] != "db" || h.User != "postgres" || h.SourceFile != tmpdir+"/b.conf" {
+		t.Errorf("unexpected host: %v, %s", h.Host, h.User)
+	}
+
+	if _, err := ParseHost(tmpdir+"/a.conf", "mail"); err == nil || errors.Is(err, ErrHostNotFound) {
+		t.Errorf("expected parse error of later block, got %v", err)
+	}
+
+	if err := os.WriteFile(tmpdir+"/a.conf", []byte("Host *\n  User admin\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if _, err := ParseHost(tmpdir+"/a.conf", "web"); !errors.Is(err, ErrHostNotFound) {
+		t.Errorf("expected ErrHostNotFound, got %v", err)
+	}
+}
+
+func TestNewForward(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected Forward
+	}{
+		{"8080 localhost:80", Forward{InPort: 8080, OutHost: "localhost", OutPort: 80}},
+		{"[::1]:8080 host:80", Forward{InHost: "::1", InPort: 8080, OutHost: "host", OutPort: 80}},
+		{"*:8080  [2001:db8::1]:80", Forward{InHost: "*", InPort: 8080, OutHost: "2001:db8::1", OutPort: 80}},
+		{"::1/8080 fe80::1/22", Forward{InHost: "::1", InPort: 8080, OutHost: "fe80::1", OutPort: 22}},
+		{"\t8080\thost:80 ", Forward{InPort: 8080, OutHost: "host", OutPort: 80}},
+	} {
+		f, err := NewForward(tc.value)
+		if err != nil {
+			t.Errorf("NewForward(%q): unexpected error: %s", tc.value, err)
+			continue
+		}
+		if f != tc.expected {
+			t.Errorf("NewForward(%q): expected %+v, got %+v", tc.value, tc.expected, f)
+		}
+	}
+
+	for _, value := range []string{"8080", "8080 host", "::1:8080 host:80", "[::1 host:80", "8080 :80", "8080 host:80 extra"} {
+		if _, err := NewForward(value); err == nil {
+			t.Errorf("NewForward(%q): expected error", value)
+		}
+	}
+}
+
+func TestNewDynamicForward(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected DynamicForward
+	}{
+		{"1080", DynamicForward{Port: 1080}},
+		{"localhost:1080", DynamicForward{Host: "localhost", Port: 1080}},
+		{"[::1]:1080", DynamicForward{Host: "::1", Port: 1080}},
+		{"*:1080", DynamicForward{Host: "*", Port: 1080}},
+	} {
+		f, err := NewDynamicForward(tc.value)
+		if err != nil {
+			t.Errorf("NewDynamicForward(%q): unexpected error: %s", tc.value, err)
+			continue
+		}
+		if f != tc.expected {
+			t.Errorf("NewDynamicForward(%q): expected %+v, got %+v", tc.value, tc.expected, f)
+		}
+	}
+}
+
+func TestForwardAgent(t *testing.T) {
+	config := `Host google
+  ForwardAgent yes
+
+Host face
+  ForwardAgent ~/.ssh/agent.sock
+
+Host *
+  ForwardAgent no`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	for i, expected := range []string{"yes", "~/.ssh/agent.sock", "no"} {
+		if hosts[i].ForwardAgent != expected {
+			t.Errorf("expected ForwardAgent %s, got %s", expected, hosts[i].ForwardAgent)
+		}
+	}
+}
+
+func TestControlKeywords(t *testing.T) {
+	config := `Host *
+  ControlMaster auto
+  ControlPath ~/.ssh/cm-%C
+  ControlPersist 10m`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.ControlMaster != "auto" || h.ControlPath != "~/.ssh/cm-%C" || h.ControlPersist != "10m" {
+		t.Errorf("unexpected control settings: %s, %s, %s", h.ControlMaster, h.ControlPath, h.ControlPersist)
+	}
+}
+
+func TestServerAlive(t *testing.T) {
+	config := `Host *
+  ServerAliveInterval 30
+  ServerAliveCountMax 5`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].ServerAliveInterval != 30*time.Second || hosts[0].ServerAliveCountMax != 5 {
+		t.Errorf("unexpected keepalive settings: %s, %d", hosts[0].ServerAliveInterval, hosts[0].ServerAliveCountMax)
+	}
+
+	_, err = parse("Host *\n  ServerAliveInterval often", "~/.ssh/config")
+	if err == nil {
+		t.Error("expected error for invalid ServerAliveInterval")
+	}
+}
+
+func TestKnownHostsKeywords(t *testing.T) {
+	config := `Host *
+  StrictHostKeyChecking accept-new
+  UserKnownHostsFile ~/.ssh/known_hosts ~/.ssh/known_hosts2
+  GlobalKnownHostsFile /etc/ssh/ssh_known_hosts`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.StrictHostKeyChecking != "accept-new" {
+		t.Errorf("unexpected StrictHostKeyChecking: %s", h.StrictHostKeyChecking)
+	}
+	if !reflect.DeepEqual([]string{"~/.ssh/known_hosts", "~/.ssh/known_hosts2"}, h.UserKnownHostsFiles) {
+		t.Errorf("unexpected user known hosts files: %v", h.UserKnownHostsFiles)
+	}
+	if !reflect.DeepEqual([]string{"/etc/ssh/ssh_known_hosts"}, h.GlobalKnownHostsFiles) {
+		t.Errorf("unexpected global known hosts files: %v", h.GlobalKnownHostsFiles)
+	}
+
+	expected := []string{"~/.ssh/known_hosts ~/.ssh/known_hosts2"}
+	if values := h.GetAll("UserKnownHostsFile"); !reflect.DeepEqual(expected, values) {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+func TestAuthenticationKeywords(t *testing.T) {
+	config := `Host *
+  PreferredAuthentications publickey,keyboard-interactive
+  PubkeyAuthentication yes
+  PasswordAuthentication no
+  KbdInteractiveAuthentication no`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if !reflect.DeepEqual([]string{"publickey", "keyboard-interactive"}, h.PreferredAuthentications) {
+		t.Errorf("unexpected preferred authentications: %v", h.PreferredAuthentications)
+	}
+	if h.PubkeyAuthentication != "yes" || h.PasswordAuthentication != "no" || h.KbdInteractiveAuthentication != "no" {
+		t.Errorf("unexpected authentication settings: %+v", h)
+	}
+}
+
+func TestAlgorithmKeywords(t *testing.T) {
+	config := `Host *
+  KexAlgorithms curve25519-sha256,diffie-hellman-group16-sha512
+  PubkeyAcceptedAlgorithms ssh-ed25519,rsa-sha2-512`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if !reflect.DeepEqual([]string{"curve25519-sha256", "diffie-hellman-group16-sha512"}, h.KexAlgorithms) {
+		t.Errorf("unexpected kex algorithms: %v", h.KexAlgorithms)
+	}
+	if !reflect.DeepEqual([]string{"ssh-ed25519", "rsa-sha2-512"}, h.PubkeyAcceptedAlgorithms) {
+		t.Errorf("unexpected pubkey accepted algorithms: %v", h.PubkeyAcceptedAlgorithms)
+	}
+}
+
+func TestEnvKeywords(t *testing.T) {
+	config := `Host web
+  SendEnv LANG LC_*
+  SendEnv TZ
+  SetEnv FOO=bar DEBUG=1
+  SetEnv FOO=baz
+
+Host *
+  SendEnv EDITOR
+  SetEnv DEBUG=0 TERM=xterm`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if !reflect.DeepEqual([]string{"LANG", "LC_*", "TZ"}, h.SendEnv) {
+		t.Errorf("unexpected SendEnv: %v", h.SendEnv)
+	}
+	if !reflect.DeepEqual(map[string]string{"FOO": "bar", "DEBUG": "1"}, h.SetEnv) {
+		t.Errorf("unexpected SetEnv: %v", h.SetEnv)
+	}
+
+	h = Lookup(hosts, "web")
+	if !reflect.DeepEqual([]string{"LANG", "LC_*", "TZ", "EDITOR"}, h.SendEnv) {
+		t.Errorf("unexpected merged SendEnv: %v", h.SendEnv)
+	}
+	if !reflect.DeepEqual(map[string]string{"FOO": "bar", "DEBUG": "1", "TERM": "xterm"}, h.SetEnv) {
+		t.Errorf("unexpected merged SetEnv: %v", h.SetEnv)
+	}
+
+	if _, err := parse("Host *\n  SetEnv FOO", "~/.ssh/config"); err == nil {
+		t.Error("expected error for SetEnv without value")
+	}
+}
+
+func TestCommandKeywords(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  PermitLocalCommand yes
+  LocalCommand echo connected to %h
+  RemoteCommand tmux new -A -s %r`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "web")
+	if h.PermitLocalCommand != "yes" {
+		t.Errorf("unexpected PermitLocalCommand: %s", h.PermitLocalCommand)
+	}
+	if cmd := Expand(h, "LocalCommand"); cmd != "echo connected to web.example.com" {
+		t.Errorf("unexpected LocalCommand: %s", cmd)
+	}
+	if h.RemoteCommand != "tmux new -A -s %r" {
+		t.Errorf("unexpected RemoteCommand: %s", h.RemoteCommand)
+	}
+}
+
+func TestSessionKeywords(t *testing.T) {
+	config := `Host web
+  RequestTTY Force
+  SessionType default
+
+Host sftp
+  RequestTTY no
+  SessionType subsystem`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].RequestTTY != RequestTTYForce || hosts[0].SessionType != SessionTypeDefault {
+		t.Errorf("unexpected session settings: %s, %s", hosts[0].RequestTTY, hosts[0].SessionType)
+	}
+	if hosts[1].RequestTTY != RequestTTYNo || hosts[1].SessionType != SessionTypeSubsystem {
+		t.Errorf("unexpected session settings: %s, %s", hosts[1].RequestTTY, hosts[1].SessionType)
+	}
+
+	expectedErr := "~/.ssh/config:2:14: invalid RequestTTY value: \"maybe\""
+	_, err = parse("Host web\n  RequestTTY maybe", "~/.ssh/config")
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Did not get expected error: %#v, got %#v", expectedErr, err)
+	}
+}
+
+func TestForwardX11(t *testing.T) {
+	config := `Host desktop
+  ForwardX11 yes
+  ForwardX11Trusted no
+  ForwardX11Timeout 20m`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.ForwardX11 != "yes" || h.ForwardX11Trusted != "no" || h.ForwardX11Timeout != 20*time.Minute {
+		t.Errorf("unexpected X11 settings: %s, %s, %s", h.ForwardX11, h.ForwardX11Trusted, h.ForwardX11Timeout)
+	}
+}
+
+func TestHostKeyKeywords(t *testing.T) {
+	config := `Host *
+  HashKnownHosts yes
+  CheckHostIP no
+  VerifyHostKeyDNS ask`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.HashKnownHosts != "yes" || h.CheckHostIP != "no" || h.VerifyHostKeyDNS != "ask" {
+		t.Errorf("unexpected host key settings: %s, %s, %s", h.HashKnownHosts, h.CheckHostIP, h.VerifyHostKeyDNS)
+	}
+}
+
+func TestTCPKeepAlive(t *testing.T) {
+	hosts, err := parse("Host *\n  TCPKeepAlive no", "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].TCPKeepAlive != "no" {
+		t.Errorf("unexpected TCPKeepAlive: %s", hosts[0].TCPKeepAlive)
+	}
+}
+
+func TestTunnel(t *testing.T) {
+	config := `Host vpn
+  Tunnel ethernet
+  TunnelDevice 0:any`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].Tunnel != "ethernet" || hosts[0].TunnelDevice != "0:any" {
+		t.Errorf("unexpected tunnel settings: %s, %s", hosts[0].Tunnel, hosts[0].TunnelDevice)
+	}
+}
+
+func TestForwardingPolicy(t *testing.T) {
+	config := `Host *
+  GatewayPorts yes
+  ExitOnForwardFailure yes
+  ClearAllForwardings no`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.GatewayPorts != "yes" {
+		t.Errorf("unexpected GatewayPorts: %s", h.GatewayPorts)
+	}
+	if h.ExitOnForwardFailure != "yes" {
+		t.Errorf("unexpected ExitOnForwardFailure: %s", h.ExitOnForwardFailure)
+	}
+	if h.ClearAllForwardings != "no" {
+		t.Errorf("unexpected ClearAllForwardings: %s", h.ClearAllForwardings)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	config := `Host *
+  LogLevel VERBOSE
+  SyslogFacility AUTH`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.LogLevel != "VERBOSE" {
+		t.Errorf("unexpected LogLevel: %s", h.LogLevel)
+	}
+	if h.SyslogFacility != "AUTH" {
+		t.Errorf("unexpected SyslogFacility: %s", h.SyslogFacility)
+	}
+}
+
+func TestBatchMode(t *testing.T) {
+	config := `Host ci-*
+  BatchMode yes
+  NumberOfPasswordPrompts 1`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].BatchMode != "yes" || hosts[0].NumberOfPasswordPrompts != 1 {
+		t.Errorf("unexpected batch settings: %s, %d", hosts[0].BatchMode, hosts[0].NumberOfPasswordPrompts)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	config := `Host *
+  EscapeChar none
+  EnableEscapeCommandline yes`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.EscapeChar != "none" {
+		t.Errorf("unexpected EscapeChar: %s", h.EscapeChar)
+	}
+	if h.EnableEscapeCommandline != "yes" {
+		t.Errorf("unexpected EnableEscapeCommandline: %s", h.EnableEscapeCommandline)
+	}
+}
+
+func TestSecurityProviders(t *testing.T) {
+	config := `Host *
+  PKCS11Provider /usr/lib/opensc-pkcs11.so
+  SecurityKeyProvider internal`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.PKCS11Provider != "/usr/lib/opensc-pkcs11.so" {
+		t.Errorf("unexpected PKCS11Provider: %s", h.PKCS11Provider)
+	}
+	if h.SecurityKeyProvider != "internal" {
+		t.Errorf("unexpected SecurityKeyProvider: %s", h.SecurityKeyProvider)
+	}
+}
+
+func TestGSSAPI(t *testing.T) {
+	config := `Host *
+  GSSAPIAuthentication yes
+  GSSAPIDelegateCredentials no
+  GSSAPIKeyExchange yes
+  GSSAPITrustDns no
+  GSSAPIClientIdentity alice@EXAMPLE.COM
+  GSSAPIServerIdentity host/web.example.com`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.GSSAPIAuthentication != "yes" {
+		t.Errorf("unexpected GSSAPIAuthentication: %s", h.GSSAPIAuthentication)
+	}
+	if h.GSSAPIDelegateCredentials != "no" {
+		t.Errorf("unexpected GSSAPIDelegateCredentials: %s", h.GSSAPIDelegateCredentials)
+	}
+	if h.GSSAPIKeyExchange != "yes" {
+		t.Errorf("unexpected GSSAPIKeyExchange: %s", h.GSSAPIKeyExchange)
+	}
+	if h.GSSAPITrustDns != "no" {
+		t.Errorf("unexpected GSSAPITrustDns: %s", h.GSSAPITrustDns)
+	}
+	if h.GSSAPIClientIdentity != "alice@EXAMPLE.COM" {
+		t.Errorf("unexpected GSSAPIClientIdentity: %s", h.GSSAPIClientIdentity)
+	}
+	if h.GSSAPIServerIdentity != "host/web.example.com" {
+		t.Errorf("unexpected GSSAPIServerIdentity: %s", h.GSSAPIServerIdentity)
+	}
+}
+
+func TestRekeyLimit(t *testing.T) {
+	config := `Host web
+  RekeyLimit 1G 1h
+
+Host db
+  RekeyLimit default 30m
+
+Host *
+  RekeyLimit 512K`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []RekeyLimit{
+		{Bytes: 1 << 30, Interval: time.Hour},
+		{Interval: 30 * time.Minute},
+		{Bytes: 512 << 10},
+	}
+	for i, h := range hosts {
+		if h.RekeyLimit == nil || *h.RekeyLimit != expected[i] {
+			t.Errorf("unexpected RekeyLimit for %v: %v", h.Host, h.RekeyLimit)
+		}
+	}
+
+	if v, _ := hosts[1].Get("RekeyLimit"); v != "default 30m" {
+		t.Errorf("unexpected RekeyLimit value: %s", v)
+	}
+
+	_, err = parse("Host *\n  RekeyLimit 1X", "~/.ssh/config")
+	if err == nil {
+		t.Errorf("expected error for invalid RekeyLimit")
+	}
+}
+
+func TestUpdateHostKeys(t *testing.T) {
+	config := `Host web
+  UpdateHostKeys ask
+
+Host *
+  UpdateHostKeys Yes`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Errorf("unable to parse config: %s", err.Error())
+	}
+
+	if hosts[0].UpdateHostKeys != UpdateHostKeysAsk {
+		t.Errorf("unexpected UpdateHostKeys: %s", hosts[0].UpdateHostKeys)
+	}
+	if hosts[1].UpdateHostKeys != UpdateHostKeysYes {
+		t.Errorf("unexpected UpdateHostKeys: %s", hosts[1].UpdateHostKeys)
+	}
+
+	_, err = parse("Host *\n  UpdateHostKeys maybe", "~/.ssh/config")
+	if err == nil {
+		t.Errorf("expected error for invalid UpdateHostKeys")
+	}
+}
+
+func TestGlobalDefaults(t *testing.T) {
+	config := `VisualHostKey yes
+ServerAliveInterval 30
+
+Host web
+  HostName web.example.com
+  ServerAliveInterval 10`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(hosts))
+	}
+
+	global := hosts[0]
+	if !global.IsGlobal() || hosts[1].IsGlobal() {
+		t.Errorf("expected only the first block to be global")
+	}
+	if !reflect.DeepEqual(global.Host, []string{"*"}) || global.SourceLine != 1 {
+		t.Errorf("unexpected global block: %v at line %d", global.Host, global.SourceLine)
+	}
+	if global.ServerAliveInterval != 30*time.Second {
+		t.Errorf("unexpected ServerAliveInterval: %s", global.ServerAliveInterval)
+	}
+	if v, _ := global.Get("VisualHostKey"); v != "yes" {
+		t.Errorf("unexpected VisualHostKey: %s", v)
+	}
+
+	h := Lookup(hosts, "web")
+	if h.HostName != "web.example.com" || h.ServerAliveInterval != 10*time.Second {
+		t.Errorf("unexpected host: %s %s", h.HostName, h.ServerAliveInterval)
+	}
+
+	// with OpenSSH precedence the top level directives come first and win
+	h = Lookup(hosts, "web", WithOpenSSHPrecedence())
+	if h.ServerAliveInterval != 30*time.Second {
+		t.Errorf("unexpected ServerAliveInterval: %s", h.ServerAliveInterval)
+	}
+}
+
+func TestKeywordEquals(t *testing.T) {
+	config := "Host=web\n  Port=2222\n  User = deploy\n  HostName\t=\tweb.example.com\n  ProxyCommand=ssh -W %h:%p bastion\n"
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []*SSHHost{
+		{
+			Host:         []string{"web"},
+			HostName:     "web.example.com",
+			User:         "deploy",
+			Port:         2222,
+			PortSet:      true,
+			ProxyCommand: "ssh -W %h:%p bastion",
+		},
+	}
+	compare(t, expected, hosts)
+}
+
+func TestCaseInsensitiveKeywords(t *testing.T) {
+	config := `HOST web
+  hostname web.example.com
+  PORT 2222
+  proxycommand ssh -W %h:%p bastion
+  identityFILE ~/.ssh/web
+  usekeychain yes
+
+match user root
+  User admin`
+
+	_, err := parse(config, "~/.ssh/config", WithStrictMode())
+	if err == nil || !strings.Contains(err.Error(), "unsupported keyword usekeychain") {
+		t.Errorf("expected only usekeychain to be unknown, got %v", err)
+	}
+
+	hosts, err := parse(config, "~/.ssh/config", WithLenient())
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []*SSHHost{
+		{
+			Host:          []string{"web"},
+			HostName:      "web.example.com",
+			Port:          2222,
+			PortSet:       true,
+			ProxyCommand:  "ssh -W %h:%p bastion",
+			IdentityFile:  "~/.ssh/web",
+			IdentityFiles: []string{"~/.ssh/web"},
+			Unknowns:      map[string][]string{"usekeychain": {"yes"}},
+		},
+		{
+			Host:  []string{},
+			User:  "admin",
+			Port:  22,
+			Match: []MatchCriterion{{Keyword: "user", Value: "root"}},
+		},
+	}
+	if len(hosts) != len(expected) {
+		t.Fatalf("expected %d hosts, got %d", len(expected), len(hosts))
+	}
+	compare(t, expected, hosts)
+
+	if v, _ := hosts[0].Get("UseKeychain"); v != "yes" {
+		t.Errorf("unexpected UseKeychain: %s", v)
+	}
+	if args := hosts[0].Args(); !slices.Contains(args, "-p") {
+		t.Errorf("expected canonical arguments, got %v", args)
+	}
+}
+
+func TestQuotedValues(t *testing.T) {
+	config := `Host web
+  IdentityFile "~/keys/my key"
+  UserKnownHostsFile "~/.ssh/known hosts" ~/.ssh/known_hosts2
+  SetEnv GREETING="hello world"
+  ProxyCommand sh -c "connect proxy %h %p"`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if !reflect.DeepEqual(h.IdentityFiles, []string{"~/keys/my key"}) {
+		t.Errorf("unexpected IdentityFiles: %#v", h.IdentityFiles)
+	}
+	if !reflect.DeepEqual(h.UserKnownHostsFiles, []string{"~/.ssh/known hosts", "~/.ssh/known_hosts2"}) {
+		t.Errorf("unexpected UserKnownHostsFiles: %#v", h.UserKnownHostsFiles)
+	}
+	if h.SetEnv["GREETING"] != "hello world" {
+		t.Errorf("unexpected SetEnv: %#v", h.SetEnv)
+	}
+	// commands are passed to the shell, which handles the quotes
+	if h.ProxyCommand != `sh -c "connect proxy %h %p"` {
+		t.Errorf("unexpected ProxyCommand: %s", h.ProxyCommand)
+	}
+	if h.Directives[0].Value != `"~/keys/my key"` {
+		t.Errorf("expected raw directive value, got %s", h.Directives[0].Value)
+	}
+
+	_, err = parse("Host web\n  User \"deploy", "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:2:8: invalid quotes: "\"deploy"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTrailingComments(t *testing.T) {
+	config := `Host web
+  Port 2222 # production bastion
+  IdentityFile "~/keys/#1" # the first key
+  User deploy#ops
+  ProxyCommand ssh -W %h:%p bastion # passed to the shell
+
+Match host web # comment
+  Compression yes`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.Port != 2222 {
+		t.Errorf("unexpected Port: %d", h.Port)
+	}
+	if h.IdentityFile != "~/keys/#1" {
+		t.Errorf("unexpected IdentityFile: %s", h.IdentityFile)
+	}
+	if h.User != "deploy#ops" {
+		t.Errorf("unexpected User: %s", h.User)
+	}
+	if h.ProxyCommand != "ssh -W %h:%p bastion # passed to the shell" {
+		t.Errorf("unexpected ProxyCommand: %s", h.ProxyCommand)
+	}
+	if !reflect.DeepEqual(hosts[1].Match, []MatchCriterion{{Keyword: "host", Value: "web"}}) {
+		t.Errorf("unexpected Match: %#v", hosts[1].Match)
+	}
+}
+
+func TestQuotedHostAliases(t *testing.T) {
+	config := "Host \"my server\" backup  web\t \n  User deploy\n\nHost 'team db' # comment\n  User postgres\n"
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(hosts[0].Host, []string{"my server", "backup", "web"}) {
+		t.Errorf("unexpected aliases: %#v", hosts[0].Host)
+	}
+	if !reflect.DeepEqual(hosts[1].Host, []string{"team db"}) {
+		t.Errorf("unexpected aliases: %#v", hosts[1].Host)
+	}
+
+	if h := Lookup(hosts, "my server"); h.User != "deploy" {
+		t.Errorf("unexpected User: %s", h.User)
+	}
+
+	_, err = parse("Host \"my server\n  User deploy\n", "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:1:6: invalid quotes: "\"my server"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRepeatedKeywords(t *testing.T) {
+	config := `Host web
+  User deploy
+  IdentityFile ~/.ssh/a
+  User root
+  IdentityFile ~/.ssh/b
+  Port 2222
+  Port 22`
+
+	var warnings []string
+	hosts, err := parse(config, "~/.ssh/config", WithWarnings(func(w Warning) {
+		warnings = append(warnings, w.String())
+	}))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.User != "deploy" || h.Port != 2222 {
+		t.Errorf("expected first values to win, got %s %d", h.User, h.Port)
+	}
+	if !reflect.DeepEqual(h.IdentityFiles, []string{"~/.ssh/a", "~/.ssh/b"}) {
+		t.Errorf("unexpected IdentityFiles: %v", h.IdentityFiles)
+	}
+	if len(h.Directives) != 6 {
+		t.Errorf("expected all directives to be recorded, got %d", len(h.Directives))
+	}
+
+	expected := []string{
+		"~/.ssh/config:4: duplicate User, previously set on line 2",
+		"~/.ssh/config:7: duplicate Port, previously set on line 6",
+	}
+	if !reflect.DeepEqual(expected, warnings) {
+		t.Errorf("unexpected warnings:\n%#v", warnings)
+	}
+
+	hosts, err = parse(config, "~/.ssh/config", WithLastValueWins())
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h = hosts[0]
+	if h.User != "root" || h.Port != 22 {
+		t.Errorf("expected last values to win, got %s %d", h.User, h.Port)
+	}
+}
+
+func TestSocketForward(t *testing.T) {
+	config := `Host web
+  LocalForward /tmp/local.sock /var/run/remote.sock
+  LocalForward 8080 /run/app.sock
+  RemoteForward ./agent.sock localhost:22`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []Forward{
+		{InSocket: "/tmp/local.sock", OutSocket: "/var/run/remote.sock"},
+		{InPort: 8080, OutSocket: "/run/app.sock"},
+	}
+	if !reflect.DeepEqual(expected, hosts[0].LocalForwards) {
+		t.Errorf("unexpected LocalForwards: %+v", hosts[0].LocalForwards)
+	}
+
+	remote := []Forward{{InSocket: "./agent.sock", OutHost: "localhost", OutPort: 22}}
+	if !reflect.DeepEqual(remote, hosts[0].RemoteForwards) {
+		t.Errorf("unexpected RemoteForwards: %+v", hosts[0].RemoteForwards)
+	}
+
+	values := hosts[0].GetAll("LocalForward")
+	if !reflect.DeepEqual(values, []string{"/tmp/local.sock /var/run/remote.sock", "8080 /run/app.sock"}) {
+		t.Errorf("unexpected values: %#v", values)
+	}
+}
+
+func TestDynamicForwardBindAddress(t *testing.T) {
+	config := `Host web
+  DynamicForward [::1]:1080
+  DynamicForward *:1081
+  DynamicForward [::]:1082
+  DynamicForward ::1/1083`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []DynamicForward{
+		{Host: "::1", Port: 1080},
+		{Host: "*", Port: 1081},
+		{Host: "::", Port: 1082},
+		{Host: "::1", Port: 1083},
+	}
+	if !reflect.DeepEqual(expected, hosts[0].DynamicForwards) {
+		t.Errorf("unexpected DynamicForwards: %+v", hosts[0].DynamicForwards)
+	}
+
+	values := hosts[0].GetAll("DynamicForward")
+	if !reflect.DeepEqual(values, []string{"[::1]:1080", "*:1081", "[::]:1082", "[::1]:1083"}) {
+		t.Errorf("unexpected values: %#v", values)
+	}
+
+	for _, value := range []string{"[::1]", "::1:1080", "*:", "[::1]1080"} {
+		if _, err := NewDynamicForward(value); err == nil {
+			t.Errorf("expected error for %#v", value)
+		}
+	}
+}
+
+func TestReverseDynamicForward(t *testing.T) {
+	config := `Host web
+  RemoteForward 8080
+  RemoteForward localhost:8081
+  RemoteForward 2222 localhost:22
+  LocalForward 8082`
+
+	hosts, err := parse(config, "~/.ssh/config", WithLenient())
+	if err == nil || err.Error() != `~/.ssh/config:5:16: Invalid forward: "8082"` {
+		t.Errorf("expected LocalForward without destination to fail, got %v", err)
+	}
+
+	expected := []Forward{
+		{InPort: 8080},
+		{InHost: "localhost", InPort: 8081},
+		{InPort: 2222, OutHost: "localhost", OutPort: 22},
+	}
+	forwards := hosts[0].RemoteForwards
+	if !reflect.DeepEqual(expected, forwards) {
+		t.Fatalf("unexpected RemoteForwards: %+v", forwards)
+	}
+	if !forwards[0].IsDynamic() || !forwards[1].IsDynamic() || forwards[2].IsDynamic() {
+		t.Errorf("unexpected dynamic forwards: %+v", forwards)
+	}
+
+	values := hosts[0].GetAll("RemoteForward")
+	if !reflect.DeepEqual(values, []string{"8080", "localhost:8081", "2222 localhost:22"}) {
+		t.Errorf("unexpected values: %#v", values)
+	}
+}
+
+func TestPermitOpen(t *testing.T) {
+	config := `Host bastion
+  PermitRemoteOpen localhost:8080 [::1]:443 *:22
+  PermitOpen db.internal:5432 any
+
+Host *
+  PermitRemoteOpen none`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(hosts[0].PermitRemoteOpen, []string{"localhost:8080", "[::1]:443", "*:22"}) {
+		t.Errorf("unexpected PermitRemoteOpen: %#v", hosts[0].PermitRemoteOpen)
+	}
+	if !reflect.DeepEqual(hosts[0].PermitOpen, []string{"db.internal:5432", "any"}) {
+		t.Errorf("unexpected PermitOpen: %#v", hosts[0].PermitOpen)
+	}
+	if !reflect.DeepEqual(hosts[1].PermitRemoteOpen, []string{"none"}) {
+		t.Errorf("unexpected PermitRemoteOpen: %#v", hosts[1].PermitRemoteOpen)
+	}
+	if v, _ := hosts[0].Get("PermitRemoteOpen"); v != "localhost:8080 [::1]:443 *:22" {
+		t.Errorf("unexpected PermitRemoteOpen value: %s", v)
+	}
+}
+
+func TestForwardString(t *testing.T) {
+	for _, tc := range []struct {
+		forward Forward
+		str     string
+		arg     string
+	}{
+		{Forward{InPort: 8080, OutHost: "localhost", OutPort: 80}, "8080 localhost:80", "8080:localhost:80"},
+		{Forward{InHost: "::1", InPort: 8080, OutHost: "fe80::1", OutPort: 80}, "[::1]:8080 [fe80::1]:80", "[::1]:8080:[fe80::1]:80"},
+		{Forward{InSocket: "/tmp/a.sock", OutSocket: "/run/b.sock"}, "/tmp/a.sock /run/b.sock", "/tmp/a.sock:/run/b.sock"},
+		{Forward{InHost: "*", InPort: 1080}, "*:1080", "*:1080"},
+	} {
+		if s := tc.forward.String(); s != tc.str {
+			t.Errorf("unexpected string for %+v: %s", tc.forward, s)
+		}
+		if s := tc.forward.Arg(); s != tc.arg {
+			t.Errorf("unexpected arg for %+v: %s", tc.forward, s)
+		}
+	}
+
+	if s := (DynamicForward{Host: "::1", Port: 1080}).String(); s != "[::1]:1080" {
+		t.Errorf("unexpected dynamic forward string: %s", s)
+	}
+	if s := (DynamicForward{Port: 1080}).String(); s != "1080" {
+		t.Errorf("unexpected dynamic forward string: %s", s)
+	}
+}
+
+func TestComments(t *testing.T) {
+	config := `# Global defaults
+User deploy
+
+  # unrelated note
+
+# Production web server,
+# managed by infra.
+Host web
+  HostName web.example.com
+  # inline note
+  Port 2222
+
+#Staging
+Match host staging
+  User ci
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := [][]string{
+		{"Global defaults", "unrelated note"},
+		{"Production web server,", "managed by infra.", "inline note"},
+		{"Staging"},
+	}
+	for i, h := range hosts {
+		if !reflect.DeepEqual(h.Comments, expected[i]) {
+			t.Errorf("unexpected Comments of block %d: %q", i, h.Comments)
+		}
+	}
+
+	out, err := Marshal(hosts[1:2])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasPrefix(string(out), "# Production web server,\n# managed by infra.\n# inline note\nHost web\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestPortSet(t *testing.T) {
+	config := `Host web
+  Port 22
+
+Host db
+  User postgres
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if !hosts[0].PortSet || hosts[1].PortSet || hosts[1].Port != 22 {
+		t.Errorf("unexpected ports: %d %t, %d %t", hosts[0].Port, hosts[0].PortSet, hosts[1].Port, hosts[1].PortSet)
+	}
+
+	out, err := Marshal(hosts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(out) != config {
+		t.Errorf("expected:\n%s\ngot:\n%s", config, out)
+	}
+
+	if h := Lookup(hosts, "web"); !h.PortSet {
+		t.Errorf("expected PortSet to be merged")
+	}
+
+	hosts, err = parse(config, "~/.ssh/config", WithDefaultPort(0))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if hosts[1].Port != 0 || hosts[1].PortSet {
+		t.Errorf("expected unset port, got %d", hosts[1].Port)
+	}
+}
+
+func TestTriBool(t *testing.T) {
+	config := `Host web
+  BatchMode no
+  TCPKeepAlive True
+
+Host *
+  BatchMode yes
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "web")
+	if h.BatchMode != TriBoolNo || h.BatchMode.Bool(true) {
+		t.Errorf("expected explicit no to win, got %q", h.BatchMode)
+	}
+	if h.TCPKeepAlive != TriBoolYes {
+		t.Errorf("unexpected TCPKeepAlive: %q", h.TCPKeepAlive)
+	}
+	if h.CheckHostIP.IsSet() || !h.CheckHostIP.Bool(true) {
+		t.Errorf("expected CheckHostIP to be unset, got %q", h.CheckHostIP)
+	}
+
+	_, err = parse("Host web\n  BatchMode maybe\n", "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:2:13: invalid BatchMode value: "maybe"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEnums(t *testing.T) {
+	config := `Host web
+  ControlMaster AutoAsk
+  StrictHostKeyChecking accept-new
+  Tunnel true
+  AddKeysToAgent confirm 1h
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := hosts[0]
+	if h.ControlMaster != ControlMasterAutoAsk || h.StrictHostKeyChecking != StrictHostKeyCheckingAcceptNew || h.Tunnel != TunnelYes {
+		t.Errorf("unexpected values: %q, %q, %q", h.ControlMaster, h.StrictHostKeyChecking, h.Tunnel)
+	}
+	if h.AddKeysToAgent != "confirm 1h" {
+		t.Errorf("unexpected AddKeysToAgent: %q", h.AddKeysToAgent)
+	}
+
+	// ssh accepts true and false for every keyword taking yes or no
+	for line, expected := range map[string]string{
+		"RequestTTY true":            "yes",
+		"RequestTTY FALSE":           "no",
+		"UpdateHostKeys true":        "yes",
+		"UpdateHostKeys false":       "no",
+		"AddKeysToAgent True":        "yes",
+		"AddKeysToAgent false":       "no",
+		"ControlMaster false":        "no",
+		"StrictHostKeyChecking true": "yes",
+	} {
+		hosts, err := parse("Host web\n  "+line+"\n", "~/.ssh/config")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", line, err)
+			continue
+		}
+		keyword := strings.Fields(line)[0]
+		if v, _ := hosts[0].Get(keyword); v != expected {
+			t.Errorf("%s: expected %s, got %s", line, expected, v)
+		}
+	}
+
+	for _, line := range []string{
+		"ControlMaster sometimes",
+		"StrictHostKeyChecking maybe",
+		"Tunnel layer2",
+		"AddKeysToAgent later",
+		"AddKeysToAgent ask 1h",
+	} {
+		if _, err := parse("Host web\n  "+line+"\n", "~/.ssh/config"); err == nil {
+			t.Errorf("expected error for %s", line)
+		}
+	}
+
+	_, err = parse("Host web\n  Tunnel layer2\n", "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:2:10: invalid Tunnel value: "layer2"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestIsPattern(t *testing.T) {
+	config := `User admin
+
+Host web db
+Host *.example.com
+Host web !bastion
+Match host web
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []bool{true, false, true, true, false}
+	if len(hosts) != len(expected) {
+		t.Fatalf("expected %d hosts, got %d", len(expected), len(hosts))
+	}
+	for i, h := range hosts {
+		if h.IsPattern() != expected[i] {
+			t.Errorf("host %d: expected IsPattern %t", i, expected[i])
+		}
+	}
+}
+
+func TestProcessKeywords(t *testing.T) {
+	config := `Host tunnel
+  StdinNull yes
+  ForkAfterAuthentication yes
+  SessionType none
+
+Host *
+  ProxyUseFdpass yes
+  ForkAfterAuthentication no
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "tunnel")
+	if h.StdinNull != TriBoolYes || h.ForkAfterAuthentication != TriBoolYes || h.ProxyUseFdpass != TriBoolYes {
+		t.Errorf("unexpected values: %q, %q, %q", h.StdinNull, h.ForkAfterAuthentication, h.ProxyUseFdpass)
+	}
+	if v, _ := h.Get("StdinNull"); v != "yes" {
+		t.Errorf("unexpected StdinNull: %s", v)
+	}
+
+	other := Lookup(hosts, "web")
+	if other.StdinNull != TriBoolUnset || other.ForkAfterAuthentication != TriBoolNo {
+		t.Errorf("unexpected values: %q, %q", other.StdinNull, other.ForkAfterAuthentication)
+	}
+
+	if _, err := parse("Host web\n  StdinNull maybe\n", "~/.ssh/config"); err == nil {
+		t.Errorf("expected error for StdinNull maybe")
+	}
+}
+
+func TestStreamLocalBind(t *testing.T) {
+	config := `Host web
+  RemoteForward /run/user/agent.sock /tmp/agent.sock
+  StreamLocalBindMask 0117
+  StreamLocalBindUnlink yes
+
+Host db
+  StreamLocalBindMask 0
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	web := Lookup(hosts, "web")
+	if web.StreamLocalBindMask == nil || *web.StreamLocalBindMask != 0117 || web.StreamLocalBindUnlink != TriBoolYes {
+		t.Errorf("unexpected values: %v, %q", web.StreamLocalBindMask, web.StreamLocalBindUnlink)
+	}
+	if mode := web.StreamLocalBindMode(); mode != 0660 {
+		t.Errorf("unexpected mode: %s", mode)
+	}
+	if v, _ := web.Get("StreamLocalBindMask"); v != "0117" {
+		t.Errorf("unexpected StreamLocalBindMask: %s", v)
+	}
+
+	db := Lookup(hosts, "db")
+	if db.StreamLocalBindMask == nil || *db.StreamLocalBindMask != 0 {
+		t.Errorf("expected a mask of 0, got %v", db.StreamLocalBindMask)
+	}
+	if mode := db.StreamLocalBindMode(); mode != 0777 {
+		t.Errorf("unexpected mode: %s", mode)
+	}
+	if mode := Lookup(hosts, "cache").StreamLocalBindMode(); mode != 0600 {
+		t.Errorf("unexpected default mode: %s", mode)
+	}
+
+	for _, line := range []string{"StreamLocalBindMask 0888", "StreamLocalBindMask 01777", "StreamLocalBindUnlink maybe"} {
+		if _, err := parse("Host web\n  "+line+"\n", "~/.ssh/config"); err == nil {
+			t.Errorf("expected error for %s", line)
+		}
+	}
+}
+
+func TestHostbasedKeywords(t *testing.T) {
+	config := `Host *.corp
+  HostbasedAuthentication yes
+  HostbasedAcceptedAlgorithms +ssh-rsa,ssh-ed25519
+
+Host *
+  NoHostAuthenticationForLocalhost yes
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	h := Lookup(hosts, "build.corp")
+	if h.HostbasedAuthentication != TriBoolYes || h.NoHostAuthenticationForLocalhost != TriBoolYes {
+		t.Errorf("unexpected values: %q, %q", h.HostbasedAuthentication, h.NoHostAuthenticationForLocalhost)
+	}
+	modifier, algorithms := ParseAlgorithms(h.HostbasedAcceptedAlgorithms)
+	if modifier != AlgorithmsAppend || len(algorithms) != 2 || algorithms[0] != "ssh-rsa" {
+		t.Errorf("unexpected algorithms: %v, %v", modifier, algorithms)
+	}
+	if v, _ := h.Get("HostbasedAcceptedAlgorithms"); v != "+ssh-rsa,ssh-ed25519" {
+		t.Errorf("unexpected HostbasedAcceptedAlgorithms: %s", v)
+	}
+
+	findings := Audit([]*SSHHost{h}, nil)
+	if len(findings) != 1 || findings[0].Severity != SeverityWarning {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
+
+// fakeParser is a Parser returning fixed hosts, like downstream tests would
+// use.
+type fakeParser struct {
+	hosts []*SSHHost
+}
+
+func (p fakeParser) Parse(string, ...Option) ([]*SSHHost, error) {
+	return p.hosts, nil
+}
+
+func (p fakeParser) ParseFS(fs.FS, string, ...Option) ([]*SSHHost, error) {
+	return p.hosts, nil
+}
+
+func (p fakeParser) Lookup(hosts []*SSHHost, alias string, opts ...LookupOption) *SSHHost {
+	return Lookup(hosts, alias, opts...)
+}
+
+func TestParserInterface(t *testing.T) {
+	hostName := func(p Parser, alias string) (string, error) {
+		hosts, err := p.Parse("/nonexistent/config")
+		if err != nil {
+			return "", err
+		}
+		return p.Lookup(hosts, alias).HostName, nil
+	}
+
+	fake := fakeParser{hosts: []*SSHHost{{Host: []string{"web"}, HostName: "web.example.com"}}}
+	if name, err := hostName(fake, "web"); err != nil || name != "web.example.com" {
+		t.Errorf("unexpected host name: %s, %v", name, err)
+	}
+	if _, err := hostName(DefaultParser, "web"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"ssh/config": &fstest.MapFile{Data: []byte("Host web\n  HostName web.internal\n")},
+	}
+	hosts, err := DefaultParser.ParseFS(fsys, "ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if h := DefaultParser.Lookup(hosts, "web"); h.HostName != "web.internal" {
+		t.Errorf("unexpected host: %+v", h)
+	}
 }
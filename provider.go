@@ -0,0 +1,76 @@
+package sshconfig
+
+import (
+	"errors"
+	"strings"
+)
+
+// Provider presents the effective configuration of every alias of a config
+// as nested keys, the alias followed by the lowercased keyword, so
+// applications using a configuration framework can read ssh hosts through
+// it. It implements the Provider interface of koanf:
+//
+//	k.Load(sshconfig.NewProvider(hosts), nil)
+//	k.String("web.hostname")
+//
+// For viper the keys are merged with v.MergeConfigMap of the result of
+// Read. Aliases containing the delimiter of the framework, like dots in
+// host names, have to be accessed by the nested map of the alias instead.
+type Provider struct {
+	hosts []*SSHHost
+	opts  []LookupOption
+}
+
+// errReadBytes is returned by Provider.ReadBytes, the config is provided
+// as a map only.
+var errReadBytes = errors.New("sshconfig provider does not support ReadBytes")
+
+// NewProvider returns a Provider for the aliases named in a Host line of
+// hosts, resolved by Lookup with opts.
+func NewProvider(hosts []*SSHHost, opts ...LookupOption) *Provider {
+	return &Provider{hosts: hosts, opts: opts}
+}
+
+// Provider returns a Provider for the aliases of the config, see
+// NewProvider.
+func (c *Config) Provider(opts ...LookupOption) *Provider {
+	return NewProvider(c.hosts, opts...)
+}
+
+// Read returns a map of each alias to the keywords set for it. Keywords
+// which may be given multiple times, like IdentityFile, map to a list of
+// values, other keywords to their value as written in a config file.
+func (p *Provider) Read() (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	for _, alias := range NewConfig(p.hosts).Aliases() {
+		config[alias] = providerValues(Lookup(p.hosts, alias, p.opts...))
+	}
+	return config, nil
+}
+
+// ReadBytes is not supported, the config is provided by Read.
+func (p *Provider) ReadBytes() ([]byte, error) {
+	return nil, errReadBytes
+}
+
+// providerValues returns the keywords set for h with their values.
+func providerValues(h *SSHHost) map[string]interface{} {
+	values := map[string]interface{}{}
+	set := func(keyword string, all []string, list bool) {
+		switch {
+		case len(all) == 0:
+		case list || len(all) > 1:
+			values[strings.ToLower(keyword)] = all
+		default:
+			values[strings.ToLower(keyword)] = all[0]
+		}
+	}
+
+	for _, keyword := range keywords {
+		set(keyword, h.GetAll(keyword), multiValued[variables[strings.ToLower(keyword)]])
+	}
+	for keyword, all := range h.Unknowns {
+		set(keyword, all, false)
+	}
+	return values
+}
@@ -0,0 +1,72 @@
+package sshconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProvider(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  IdentityFile ~/.ssh/web
+  Ciphers aes128-ctr,aes256-ctr
+  VisualHostKey yes
+
+Host web db
+  User deploy
+  LocalForward 8080 localhost:80
+  LocalForward 8443 localhost:443
+
+Host *
+  IdentityFile ~/.ssh/id_ed25519
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	provider := NewConfig(hosts).Provider()
+	values, err := provider.Read()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expected 2 aliases, got %v", values)
+	}
+
+	web, ok := values["web"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a map for web, got %T", values["web"])
+	}
+	for keyword, expected := range map[string]string{
+		"hostname":      "web.example.com",
+		"user":          "deploy",
+		"ciphers":       "aes128-ctr,aes256-ctr",
+		"visualhostkey": "yes",
+	} {
+		if v, _ := web[keyword].(string); v != expected {
+			t.Errorf("%s: expected %s, got %v", keyword, expected, web[keyword])
+		}
+	}
+	if files, _ := web["identityfile"].([]string); len(files) != 2 || files[0] != "~/.ssh/web" {
+		t.Errorf("unexpected identity files: %v", web["identityfile"])
+	}
+	if _, ok := web["port"]; !ok {
+		t.Errorf("expected port to be set: %v", web)
+	}
+
+	db := values["db"].(map[string]interface{})
+	if files, _ := db["identityfile"].([]string); len(files) != 1 {
+		t.Errorf("expected identity files as list, got %v", db["identityfile"])
+	}
+	if forwards, _ := db["localforward"].([]string); len(forwards) != 2 {
+		t.Errorf("unexpected forwards: %v", db["localforward"])
+	}
+	if db["user"] != "deploy" {
+		t.Errorf("unexpected user for db: %v", db)
+	}
+
+	if _, err := provider.ReadBytes(); !errors.Is(err, errReadBytes) {
+		t.Errorf("expected ReadBytes to fail, got %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrShellRequired is returned by ProxyCommandArgs for commands using shell
+// features like pipes, redirections or variables, which have to be run by a
+// shell.
+var ErrShellRequired = errors.New("ProxyCommand requires a shell")
+
+// ProxyCommandArgs returns the ProxyCommand of the host split into argv the
+// way a POSIX shell would split it, with the percent tokens of each
+// argument expanded, so it can be run with os/exec without a shell. A
+// leading exec is dropped. It returns nil if no ProxyCommand is set.
+//
+// Host is expected to be a resolved host as returned by Lookup, see Expand.
+func (h *SSHHost) ProxyCommandArgs() ([]string, error) {
+	if h.ProxyCommand == "" || strings.EqualFold(h.ProxyCommand, "none") {
+		return nil, nil
+	}
+
+	args, err := shellSplit(h.ProxyCommand)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && args[0] == "exec" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty ProxyCommand: %#v", h.ProxyCommand)
+	}
+
+	for i, arg := range args {
+		args[i] = expandTokens(arg, h)
+	}
+	return args, nil
+}
+
+// shellSplit splits s into words like a POSIX shell: single quotes keep
+// everything literally, double quotes keep everything but backslash escapes
+// of ", \, $ and `, and an unquoted backslash escapes the next character.
+// Unquoted shell operators and expansions give ErrShellRequired.
+func shellSplit(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			inWord = true
+			if i+1 < len(s) {
+				i++
+				if s[i] != '\n' {
+					word.WriteByte(s[i])
+				}
+			}
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in %#v", s)
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				switch {
+				case s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0:
+					i++
+					word.WriteByte(s[i])
+				case s[i] == '$' || s[i] == '`':
+					return nil, ErrShellRequired
+				default:
+					word.WriteByte(s[i])
+				}
+			}
+			if i == len(s) {
+				return nil, fmt.Errorf("unterminated quote in %#v", s)
+			}
+		case strings.IndexByte("|&;<>()$`*?[", c) >= 0, !inWord && (c == '#' || c == '~'):
+			// globs, comments and home directories are handled by the
+			// shell as well
+			return nil, ErrShellRequired
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// ProxyCommandJump returns the jump hosts of a ProxyCommand which only runs
+// ssh to forward the connection through another host, like
+//
+//	ssh -W %h:%p bastion
+//	ssh -q -p 2222 admin@bastion nc %h %p
+//
+// and can thus be replaced by a ProxyJump of the returned hops. The ssh
+// options -J, -l, -p and -W are understood, as are options which don't
+// change the connection like -q. It reports false for other commands,
+// including ones with options ProxyJump can't express like -i or -o.
+func ProxyCommandJump(command string) ([]JumpHop, bool) {
+	args, err := shellSplit(command)
+	if err != nil {
+		return nil, false
+	}
+	if len(args) > 0 && args[0] == "exec" {
+		args = args[1:]
+	}
+	if len(args) == 0 || (args[0] != "ssh" && !strings.HasSuffix(args[0], "/ssh")) {
+		return nil, false
+	}
+
+	var hops []JumpHop
+	var dest, user, port, forward string
+	var remote []string
+	options := true
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case !options || arg == "-" || !strings.HasPrefix(arg, "-"):
+			if dest == "" {
+				dest = arg
+			} else {
+				remote = append(remote, arg)
+				options = false
+			}
+			continue
+		case arg == "--":
+			options = false
+			continue
+		}
+
+	Flags:
+		for j := 1; j < len(arg); j++ {
+			flag := arg[j]
+			if strings.IndexByte("qTxCv46N", flag) >= 0 {
+				continue
+			}
+			if strings.IndexByte("JlpW", flag) < 0 {
+				return nil, false
+			}
+
+			value := arg[j+1:]
+			if value == "" {
+				if i+1 == len(args) {
+					return nil, false
+				}
+				i++
+				value = args[i]
+			}
+			switch flag {
+			case 'J':
+				jumps, err := ParseProxyJump(value)
+				if err != nil {
+					return nil, false
+				}
+				hops = append(hops, jumps...)
+			case 'l':
+				user = value
+			case 'p':
+				port = value
+			case 'W':
+				forward = value
+			}
+			break Flags
+		}
+	}
+
+	// the connection has to be forwarded to the target host and port,
+	// either by ssh itself or by netcat on the jump host
+	switch {
+	case forward != "":
+		if forward != "%h:%p" || len(remote) > 0 {
+			return nil, false
+		}
+	case len(remote) != 3 || (remote[0] != "nc" && remote[0] != "ncat" && remote[0] != "netcat") ||
+		remote[1] != "%h" || remote[2] != "%p":
+		return nil, false
+	}
+
+	hop, err := ParseJumpHop(dest)
+	if err != nil || dest == "" {
+		return nil, false
+	}
+	if hop.User == "" {
+		hop.User = user
+	}
+	if port != "" && hop.Port == 0 {
+		hop.Port, err = strconv.Atoi(port)
+		if err != nil || hop.Port <= 0 || hop.Port > 65535 {
+			return nil, false
+		}
+	}
+	return append(hops, hop), true
+}
+
+// ConvertProxyCommands returns src with each ProxyCommand recognized by
+// ProxyCommandJump replaced by the equivalent ProxyJump. Blocks which
+// already set ProxyJump are left as they are. Everything but the converted
+// directives is kept as written.
+//
+// An error is returned if src can not be parsed.
+func ConvertProxyCommands(src []byte) ([]byte, error) {
+	content := string(src)
+
+	var edits, block []textEdit
+	hasJump := false
+	endBlock := func() {
+		if !hasJump {
+			edits = append(edits, block...)
+		}
+		block, hasJump = nil, false
+	}
+
+	lexer := lex(content)
+	defer lexer.drain()
+	for {
+		token := lexer.nextItem()
+		switch token.typ {
+		case itemEOF:
+			endBlock()
+			return applyEdits(content, edits), nil
+		case itemError:
+			return nil, newParseError(content, "", token, "", errors.New(token.val))
+		case itemHost, itemMatch:
+			endBlock()
+		}
+
+		value := lexer.nextItem()
+		if value.typ != itemValue && value.typ != itemHostValue {
+			return nil, newParseError(content, "", value, token.val, valueError(token, value))
+		}
+
+		switch token.typ {
+		case itemProxyJump:
+			hasJump = true
+		case itemProxyCommand:
+			hops, ok := ProxyCommandJump(strings.TrimRight(value.val, " \t\r"))
+			if !ok {
+				continue
+			}
+			specs := make([]string, len(hops))
+			for i, hop := range hops {
+				specs[i] = hop.String()
+			}
+			block = append(block,
+				textEdit{int(token.pos), int(token.pos) + len(token.val), "ProxyJump"},
+				textEdit{int(value.pos), int(value.pos) + argsEnd(value.val, true), strings.Join(specs, ",")})
+		}
+	}
+}
+
+// ConvertProxyCommands replaces ProxyCommands running ssh by ProxyJump, see
+// ConvertProxyCommands.
+func (e *Editor) ConvertProxyCommands() error {
+	out, err := ConvertProxyCommands([]byte(e.content))
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.File = e.path
+		}
+		return err
+	}
+	return e.reset(string(out))
+}
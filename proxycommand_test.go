@@ -0,0 +1,112 @@
+package sshconfig
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProxyCommandArgs(t *testing.T) {
+	for command, expected := range map[string][]string{
+		"ssh -W %h:%p bastion":                         {"ssh", "-W", "web.example.com:2222", "bastion"},
+		"exec nc -X 5 -x proxy:1080 %h %p":             {"nc", "-X", "5", "-x", "proxy:1080", "web.example.com", "2222"},
+		`/opt/my\ proxy --name "%n" 'literal %%'`:      {"/opt/my proxy", "--name", "web", "literal %"},
+		`connect "say \"hi\"" ''`:                      {"connect", `say "hi"`, ""},
+		"corkscrew proxy 8080 %h %p ~/.corkscrew-auth": nil,
+		"ssh bastion nc %h %p | tee log":               nil,
+		"nc $PROXY %h %p":                              nil,
+		`nc "$PROXY" %h %p`:                            nil,
+	} {
+		h := &SSHHost{Host: []string{"web"}, HostName: "web.example.com", Port: 2222, ProxyCommand: command}
+		args, err := h.ProxyCommandArgs()
+		if expected == nil {
+			if !errors.Is(err, ErrShellRequired) {
+				t.Errorf("expected ErrShellRequired for %#v, got %v, %v", command, args, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unable to split %#v: %s", command, err.Error())
+			continue
+		}
+		if !slices.Equal(args, expected) {
+			t.Errorf("unexpected args for %#v: %#v", command, args)
+		}
+	}
+
+	for _, command := range []string{`nc "unterminated`, "nc 'unterminated", "exec"} {
+		h := &SSHHost{ProxyCommand: command}
+		if _, err := h.ProxyCommandArgs(); err == nil || errors.Is(err, ErrShellRequired) {
+			t.Errorf("expected syntax error for %#v, got %v", command, err)
+		}
+	}
+
+	for _, command := range []string{"", "none"} {
+		args, err := (&SSHHost{ProxyCommand: command}).ProxyCommandArgs()
+		if args != nil || err != nil {
+			t.Errorf("unexpected result for %#v: %v, %v", command, args, err)
+		}
+	}
+}
+
+func TestProxyCommandJump(t *testing.T) {
+	for _, tc := range []struct {
+		command  string
+		expected string
+	}{
+		{"ssh -W %h:%p bastion", "bastion"},
+		{"exec /usr/bin/ssh -qW %h:%p -l admin -p 2222 bastion", "admin@bastion:2222"},
+		{"ssh deploy@bastion -W %h:%p", "deploy@bastion"},
+		{"ssh -J edge -W %h:%p inner", "edge,inner"},
+		{"ssh -q bastion nc %h %p", "bastion"},
+		{"ssh bastion -- netcat %h %p", "bastion"},
+		{"ssh -i ~/.ssh/jump -W %h:%p bastion", ""},
+		{"ssh -o User=admin -W %h:%p bastion", ""},
+		{"ssh -W %h:22 bastion", ""},
+		{"ssh bastion nc %h %p | tee log", ""},
+		{"nc -X 5 -x proxy:1080 %h %p", ""},
+		{"ssh -W %h:%p", ""},
+	} {
+		hops, ok := ProxyCommandJump(tc.command)
+		var specs []string
+		for _, hop := range hops {
+			specs = append(specs, hop.String())
+		}
+		if got := strings.Join(specs, ","); got != tc.expected || ok != (tc.expected != "") {
+			t.Errorf("ProxyCommandJump(%q): expected %q, got %q, %t", tc.command, tc.expected, got, ok)
+		}
+	}
+}
+
+func TestConvertProxyCommands(t *testing.T) {
+	src := `Host web
+  ProxyCommand ssh -W %h:%p bastion
+
+Host db
+  ProxyCommand ssh -W %h:%p bastion
+  ProxyJump gateway
+
+Host legacy
+  ProxyCommand=ssh -q admin@bastion nc %h %p
+  ProxyCommand /usr/local/bin/connect %h %p
+`
+	expected := `Host web
+  ProxyJump bastion
+
+Host db
+  ProxyCommand ssh -W %h:%p bastion
+  ProxyJump gateway
+
+Host legacy
+  ProxyJump=admin@bastion
+  ProxyCommand /usr/local/bin/connect %h %p
+`
+	out, err := ConvertProxyCommands([]byte(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(out) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
@@ -0,0 +1,267 @@
+package sshconfig
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// puttySessionsKey is the registry key PuTTY stores its saved sessions in.
+const puttySessionsKey = `\Software\SimonTatham\PuTTY\Sessions\`
+
+// ImportPuTTYReg converts the PuTTY sessions of a registry export, as
+// written by `reg export HKCU\Software\SimonTatham\PuTTY\Sessions`, into
+// Host blocks, see ImportPuTTYSession. Exports in UTF-16, the default of
+// regedit, and UTF-8 are accepted. The Default Settings session and
+// sessions using other protocols than SSH are skipped.
+func ImportPuTTYReg(r io.Reader) ([]*SSHHost, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := decodeReg(data)
+
+	var hosts []*SSHHost
+	var name string
+	var values map[string]string
+	flush := func() error {
+		if values == nil {
+			return nil
+		}
+		h, err := puttyHost(name, values)
+		if err != nil {
+			return err
+		}
+		if h != nil {
+			hosts = append(hosts, h)
+		}
+		values = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "[") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			key := strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+			if i := strings.Index(key, puttySessionsKey); i >= 0 && !strings.HasPrefix(key, "-") {
+				name = key[i+len(puttySessionsKey):]
+				if !strings.Contains(name, `\`) {
+					values = map[string]string{}
+				}
+			}
+			continue
+		}
+		if values == nil || !strings.HasPrefix(text, `"`) {
+			continue
+		}
+
+		key, value, err := parseRegValue(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values[key] = value
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// ImportPuTTYSession converts a PuTTY session file, as stored in
+// ~/.putty/sessions by PuTTY on Unix, into a Host block. Name is the session
+// name, which may be %-escaped like the file name. Nil is returned for
+// sessions using other protocols than SSH.
+//
+// HostName, PortNumber, UserName, PublicKeyFile, AgentFwd, X11Forward,
+// TCPKeepalives, PingIntervalSecs and PortForwardings are converted, as are
+// local proxy commands and SSH proxies as ProxyCommand and ProxyJump. Spaces
+// of the session name are replaced by - to give a valid alias. PuTTY keys in
+// .ppk files must be converted with puttygen before ssh can use them.
+func ImportPuTTYSession(name string, r io.Reader) (*SSHHost, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimRight(scanner.Text(), "\r"), "=")
+		if ok {
+			values[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return puttyHost(name, values)
+}
+
+// puttyHost converts the values of a PuTTY session to a Host block, or
+// returns nil if the session is not an SSH session.
+func puttyHost(name string, values map[string]string) (*SSHHost, error) {
+	alias, err := url.PathUnescape(name)
+	if err != nil {
+		alias = name
+	}
+	if alias == "Default Settings" {
+		return nil, nil
+	}
+	if protocol := values["Protocol"]; protocol != "" && protocol != "ssh" {
+		return nil, nil
+	}
+	alias = strings.Join(strings.FieldsFunc(alias, unicode.IsSpace), "-")
+
+	b := NewHostBuilder(alias)
+	hostName, user := values["HostName"], values["UserName"]
+	if u, host, ok := strings.Cut(hostName, "@"); ok {
+		hostName = host
+		if user == "" {
+			user = u
+		}
+	}
+	if hostName != "" {
+		b.HostName(hostName)
+	}
+	if user != "" {
+		b.User(user)
+	}
+	if port, err := strconv.Atoi(values["PortNumber"]); err == nil && port != 22 && port != 0 {
+		b.Port(port)
+	}
+	if key := values["PublicKeyFile"]; key != "" {
+		b.IdentityFile(key)
+	}
+
+	for _, flag := range []struct{ key, keyword string }{
+		{"AgentFwd", "ForwardAgent"},
+		{"X11Forward", "ForwardX11"},
+		{"TCPKeepalives", "TCPKeepAlive"},
+	} {
+		if values[flag.key] == "1" {
+			b.Set(flag.keyword, "yes")
+		}
+	}
+	if interval := values["PingIntervalSecs"]; interval != "" && interval != "0" {
+		b.Set("ServerAliveInterval", interval)
+	}
+
+	switch values["ProxyMethod"] {
+	case "5":
+		if command := values["ProxyTelnetCommand"]; command != "" {
+			command = strings.NewReplacer("%host", "%h", "%port", "%p").Replace(command)
+			b.Set("ProxyCommand", command)
+		}
+	case "6":
+		if jump := values["ProxyHost"]; jump != "" {
+			if user := values["ProxyUsername"]; user != "" {
+				jump = user + "@" + jump
+			}
+			if port := values["ProxyPort"]; port != "" && port != "22" {
+				jump += ":" + port
+			}
+			b.ProxyJump(jump)
+		}
+	}
+
+	for _, f := range strings.Split(values["PortForwardings"], ",") {
+		// entries look like L8080=localhost:80, 4R9000=localhost:90 or D1080,
+		// with an optional address family before the direction
+		f = strings.TrimLeft(f, "46")
+		if f == "" {
+			continue
+		}
+		listen, dest, _ := strings.Cut(f[1:], "=")
+		switch f[0] {
+		case 'L':
+			b.Set("LocalForward", listen+" "+dest)
+		case 'R':
+			b.Set("RemoteForward", listen+" "+dest)
+		case 'D':
+			b.Set("DynamicForward", listen)
+		}
+	}
+
+	h, err := b.Build()
+	if err != nil {
+		return nil, fmt.Errorf("PuTTY session %s: %w", name, err)
+	}
+	return h, nil
+}
+
+// parseRegValue parses a value line of a registry export like
+// "HostName"="example.com" or "PortNumber"=dword:00000016. Dword values are
+// returned in decimal.
+func parseRegValue(line string) (string, string, error) {
+	key, rest, err := regString(line)
+	if err != nil {
+		return "", "", err
+	}
+	rest, ok := strings.CutPrefix(rest, "=")
+	if !ok {
+		return "", "", fmt.Errorf("invalid registry value: %s", line)
+	}
+
+	switch {
+	case strings.HasPrefix(rest, `"`):
+		value, _, err := regString(rest)
+		return key, value, err
+	case strings.HasPrefix(rest, "dword:"):
+		n, err := strconv.ParseUint(strings.TrimPrefix(rest, "dword:"), 16, 32)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid dword value: %s", line)
+		}
+		return key, strconv.FormatUint(n, 10), nil
+	}
+	// other types, like hex values, are not used by SSH sessions
+	return key, "", nil
+}
+
+// regString parses the quoted string at the start of s, where \\ and \" are
+// escapes, and returns it unquoted together with the rest of s.
+func regString(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string: %s", s)
+}
+
+// decodeReg returns the content of a registry export, which is UTF-16 with
+// a byte order mark if written by regedit.
+func decodeReg(data []byte) string {
+	var bigEndian bool
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		bigEndian = true
+	default:
+		return string(bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf}))
+	}
+
+	data = data[2:]
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,93 @@
+package sshconfig
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestImportPuTTYReg(t *testing.T) {
+	export := `Windows Registry Editor Version 5.00
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions]
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\Default%20Settings]
+"HostName"=""
+"PortNumber"=dword:00000016
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\my%20web%20server]
+"HostName"="deploy@web.example.com"
+"PortNumber"=dword:000008ae
+"Protocol"="ssh"
+"PublicKeyFile"="C:\\Users\\me\\.ssh\\web.ppk"
+"AgentFwd"=dword:00000001
+"X11Forward"=dword:00000000
+"PingIntervalSecs"=dword:0000001e
+"PortForwardings"="L8080=localhost:80,4R127.0.0.1:9000=localhost:9000,D1080"
+"ProxyMethod"=dword:00000006
+"ProxyHost"="bastion.example.com"
+"ProxyUsername"="jump"
+"ProxyPort"=dword:00000016
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\router]
+"HostName"="192.168.1.1"
+"Protocol"="telnet"
+`
+	// regedit exports UTF-16 with a byte order mark
+	data := []byte{0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(strings.ReplaceAll(export, "\n", "\r\n"))) {
+		data = append(data, byte(u), byte(u>>8))
+	}
+
+	hosts, err := ImportPuTTYReg(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(hosts) != 1 {
+		t.Fatalf("expected 1 host, got %d", len(hosts))
+	}
+
+	config, err := Marshal(hosts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `Host my-web-server
+  HostName web.example.com
+  User deploy
+  Port 2222
+  IdentityFile C:\Users\me\.ssh\web.ppk
+  LocalForward 8080 localhost:80
+  RemoteForward 127.0.0.1:9000 localhost:9000
+  DynamicForward 1080
+  ProxyJump jump@bastion.example.com
+  ForwardAgent yes
+  ServerAliveInterval 30
+`
+	if string(config) != expected {
+		t.Errorf("expected config:\n%s\ngot:\n%s", expected, config)
+	}
+	if hosts[0].IdentityFile != `C:\Users\me\.ssh\web.ppk` {
+		t.Errorf("unexpected IdentityFile: %s", hosts[0].IdentityFile)
+	}
+}
+
+func TestImportPuTTYSession(t *testing.T) {
+	session := "HostName=db.example.com\nUserName=postgres\nPortNumber=22\nProtocol=ssh\n" +
+		"ProxyMethod=5\nProxyTelnetCommand=nc -X connect -x proxy:3128 %host %port\n"
+
+	h, err := ImportPuTTYSession("db", strings.NewReader(session))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if h.HostName != "db.example.com" || h.User != "postgres" || h.Port != 22 {
+		t.Errorf("unexpected host: %+v", h)
+	}
+	if h.ProxyCommand != "nc -X connect -x proxy:3128 %h %p" {
+		t.Errorf("unexpected ProxyCommand: %s", h.ProxyCommand)
+	}
+
+	h, err = ImportPuTTYSession("serial", strings.NewReader("Protocol=serial\n"))
+	if err != nil || h != nil {
+		t.Errorf("expected serial session to be skipped, got %v, %v", h, err)
+	}
+}
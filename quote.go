@@ -0,0 +1,112 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commandKeywords take the rest of the line as is, it is passed to the shell
+// which handles any quoting.
+var commandKeywords = map[itemType]bool{
+	itemProxyCommand:      true,
+	itemLocalCommand:      true,
+	itemRemoteCommand:     true,
+	itemKnownHostsCommand: true,
+}
+
+// multiArgKeywords take several arguments on one line, their values are
+// already rendered with each argument quoted where needed.
+var multiArgKeywords = map[itemType]bool{
+	itemLocalForward:                true,
+	itemRemoteForward:               true,
+	itemRekeyLimit:                  true,
+	itemUserKnownHostsFile:          true,
+	itemGlobalKnownHostsFile:        true,
+	itemCanonicalDomains:            true,
+	itemCanonicalizePermittedCNAMEs: true,
+	itemPermitOpen:                  true,
+	itemPermitRemoteOpen:            true,
+	itemChannelTimeout:              true,
+}
+
+// splitArgs splits value into whitespace separated arguments following the
+// ssh_config quoting rules. Arguments may be quoted in double or single
+// quotes, and `\"`, `\'`, `\\` and, outside of quotes, `\ ` are unescaped.
+// Other backslashes are kept as they are. An argument starting with `#`
+// starts a comment running to the end of the value.
+func splitArgs(value string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+
+	i := 0
+	for {
+		for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+			i++
+		}
+		if i == len(value) || value[i] == '#' {
+			return args, nil
+		}
+
+		arg.Reset()
+		var quote byte
+	Arg:
+		for ; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '\\' && i+1 < len(value) && isEscaped(value[i+1], quote):
+				i++
+				arg.WriteByte(value[i])
+			case quote == 0 && (c == ' ' || c == '\t'):
+				break Arg
+			case quote == 0 && (c == '"' || c == '\''):
+				quote = c
+			case quote != 0 && c == quote:
+				quote = 0
+			default:
+				arg.WriteByte(c)
+			}
+		}
+
+		if quote != 0 {
+			return nil, fmt.Errorf("invalid quotes: %#v", value)
+		}
+		args = append(args, arg.String())
+	}
+}
+
+// isEscaped reports whether c is unescaped when following a backslash.
+func isEscaped(c byte, quote byte) bool {
+	return c == '"' || c == '\'' || c == '\\' || (quote == 0 && c == ' ')
+}
+
+// quoteArg returns s quoted such that splitArgs reads it back as a single
+// argument. Arguments which need no quoting are returned as they are.
+func quoteArg(s string) string {
+	needsQuotes := s == "" || s[0] == '#'
+	for i := 0; i < len(s) && !needsQuotes; i++ {
+		switch s[i] {
+		case ' ', '\t', '"', '\'':
+			needsQuotes = true
+		case '\\':
+			needsQuotes = i+1 < len(s) && isEscaped(s[i+1], 0)
+		}
+	}
+	if !needsQuotes {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			b.WriteString(`\"`)
+		case s[i] == '\\' && (i+1 == len(s) || isEscaped(s[i+1], '"')):
+			b.WriteString(`\\`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected []string
+	}{
+		{"", nil},
+		{"  a\tb  ", []string{"a", "b"}},
+		{`"~/keys/my key"`, []string{"~/keys/my key"}},
+		{`'single quoted' "double quoted"`, []string{"single quoted", "double quoted"}},
+		{`FOO="bar baz" QUX=1`, []string{"FOO=bar baz", "QUX=1"}},
+		{`"it's" 'say "hi"'`, []string{"it's", `say "hi"`}},
+		{`my\ key \"quoted\" back\\slash`, []string{"my key", `"quoted"`, `back\slash`}},
+		{`C:\Users\me\.ssh\id_ed25519`, []string{`C:\Users\me\.ssh\id_ed25519`}},
+		{`""`, []string{""}},
+		{"2222 # production bastion", []string{"2222"}},
+		{"# only a comment", nil},
+		{`"# not a comment" a#b`, []string{"# not a comment", "a#b"}},
+	} {
+		args, err := splitArgs(tc.value)
+		if err != nil {
+			t.Errorf("unable to split %#v: %s", tc.value, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(tc.expected, args) {
+			t.Errorf("unexpected args for %#v: %#v", tc.value, args)
+		}
+	}
+
+	for _, value := range []string{`"unterminated`, `it's`, `a "b`} {
+		if _, err := splitArgs(value); err == nil {
+			t.Errorf("expected error for %#v", value)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	for _, value := range []string{"plain", "", "my key", `say "hi"`, "it's", `C:\Users\me`, `back\\slash`, `trailing\`, "#hash", `a\"b`} {
+		args, err := splitArgs(quoteArg(value))
+		if err != nil || len(args) != 1 || args[0] != value {
+			t.Errorf("unexpected round trip of %#v via %s: %#v %v", value, quoteArg(value), args, err)
+		}
+	}
+
+	if q := quoteArg(`C:\Users\me`); q != `C:\Users\me` {
+		t.Errorf("expected backslashes to be kept unquoted, got %s", q)
+	}
+}
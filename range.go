@@ -0,0 +1,188 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxRangeExpansion limits the number of names a pattern may expand to.
+const maxRangeExpansion = 100000
+
+// ExpandRange expands the numeric ranges and lists of pattern into the
+// names they stand for, in order. A range like [01-20] gives the numbers
+// from 01 to 20, padded with zeros to the width of the first one if it
+// starts with 0. A list like {a,b} gives each of its items. Several ranges
+// and lists give every combination, so web[1-2].{eu,us} expands to
+// web1.eu, web1.us, web2.eu and web2.us. Brackets which don't hold a
+// numeric range are kept as they are. A pattern without ranges expands to
+// itself.
+func ExpandRange(pattern string) ([]string, error) {
+	names := []string{""}
+	rest := pattern
+	for rest != "" {
+		i := strings.IndexAny(rest, "[{")
+		if i < 0 {
+			break
+		}
+
+		var items []string
+		var n int
+		var err error
+		if rest[i] == '[' {
+			items, n, err = numericRange(rest[i:])
+		} else {
+			items, n, err = braceList(rest[i:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid range in %s: %w", pattern, err)
+		}
+		if items == nil {
+			// not a range, keep the bracket
+			names = appendToAll(names, []string{rest[:i+1]})
+			rest = rest[i+1:]
+			continue
+		}
+
+		if len(names)*len(items) > maxRangeExpansion {
+			return nil, fmt.Errorf("range %s expands to more than %d names", pattern, maxRangeExpansion)
+		}
+		names = appendToAll(names, []string{rest[:i]})
+		names = appendToAll(names, items)
+		rest = rest[i+n:]
+	}
+	return appendToAll(names, []string{rest}), nil
+}
+
+// ExpandHosts returns hosts with every host whose aliases hold ranges, see
+// ExpandRange, replaced by a copy for each alias it expands to, which makes
+// it easy to generate the entries of a large fleet from a single template
+// host. If the HostName of such a host expands to as many names as its
+// aliases, each copy gets the HostName matching its alias, so
+//
+//	Host web[01-20].prod
+//	  HostName 10.0.1.[1-20]
+//
+// gives web01.prod with HostName 10.0.1.1 up to web20.prod with HostName
+// 10.0.1.20. Negated aliases are kept on every copy. Hosts without ranges
+// are returned as they are.
+func ExpandHosts(hosts []*SSHHost) ([]*SSHHost, error) {
+	var expanded []*SSHHost
+	for _, h := range hosts {
+		var names, negated []string
+		for _, alias := range h.Host {
+			if strings.HasPrefix(alias, "!") {
+				negated = append(negated, alias)
+				continue
+			}
+			n, err := ExpandRange(alias)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, n...)
+		}
+		if len(names)+len(negated) == len(h.Host) {
+			expanded = append(expanded, h)
+			continue
+		}
+
+		hostNames, err := ExpandRange(h.HostName)
+		if err != nil {
+			return nil, err
+		}
+		for i, name := range names {
+			c := h.Clone()
+			c.Host = append([]string{name}, negated...)
+			if len(hostNames) == len(names) {
+				c.HostName = hostNames[i]
+			}
+			expanded = append(expanded, c)
+		}
+	}
+	return expanded, nil
+}
+
+// expandAliases expands the ranges of each alias, see ExpandRange.
+func expandAliases(aliases []string) ([]string, error) {
+	var expanded []string
+	for _, alias := range aliases {
+		names, err := ExpandRange(alias)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, names...)
+	}
+	return expanded, nil
+}
+
+// appendToAll returns every name followed by every suffix.
+func appendToAll(names, suffixes []string) []string {
+	result := make([]string, 0, len(names)*len(suffixes))
+	for _, name := range names {
+		for _, suffix := range suffixes {
+			result = append(result, name+suffix)
+		}
+	}
+	return result
+}
+
+// numericRange parses the range [from-to] at the start of s and returns
+// its numbers and the length of the range. It returns no numbers if s
+// doesn't start with a numeric range.
+func numericRange(s string) ([]string, int, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return nil, 0, nil
+	}
+	from, to, ok := strings.Cut(s[1:end], "-")
+	if !ok || !isDigits(from) || !isDigits(to) {
+		return nil, 0, nil
+	}
+
+	first, err := strconv.Atoi(from)
+	if err != nil {
+		return nil, 0, err
+	}
+	last, err := strconv.Atoi(to)
+	if err != nil {
+		return nil, 0, err
+	}
+	if last < first {
+		return nil, 0, fmt.Errorf("%s ends before it starts", s[:end+1])
+	}
+	if last-first >= maxRangeExpansion {
+		return nil, 0, fmt.Errorf("%s has more than %d numbers", s[:end+1], maxRangeExpansion)
+	}
+
+	width := 0
+	if len(from) > 1 && from[0] == '0' {
+		width = len(from)
+	}
+	numbers := make([]string, 0, last-first+1)
+	for n := first; n <= last; n++ {
+		numbers = append(numbers, fmt.Sprintf("%0*d", width, n))
+	}
+	return numbers, end + 1, nil
+}
+
+// braceList parses the list {a,b} at the start of s and returns its items
+// and the length of the list.
+func braceList(s string) ([]string, int, error) {
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return nil, 0, fmt.Errorf("unterminated list %s", s)
+	}
+	return strings.Split(s[1:end], ","), end + 1, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
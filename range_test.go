@@ -0,0 +1,99 @@
+package sshconfig
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandRange(t *testing.T) {
+	for _, tc := range []struct {
+		pattern  string
+		expected []string
+	}{
+		{"web", []string{"web"}},
+		{"web[1-3]", []string{"web1", "web2", "web3"}},
+		{"web[08-10].prod", []string{"web08.prod", "web09.prod", "web10.prod"}},
+		{"db[1-2].{eu,us}", []string{"db1.eu", "db1.us", "db2.eu", "db2.us"}},
+		{"!web[1-2]", []string{"!web1", "!web2"}},
+		{"web[ab]", []string{"web[ab]"}},
+		{"[::1]", []string{"[::1]"}},
+	} {
+		names, err := ExpandRange(tc.pattern)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.pattern, err)
+			continue
+		}
+		if !slices.Equal(names, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.pattern, tc.expected, names)
+		}
+	}
+
+	for _, pattern := range []string{"web[3-1]", "web{a,b", "web[0-99999][0-99999]"} {
+		if _, err := ExpandRange(pattern); err == nil {
+			t.Errorf("%s: expected error", pattern)
+		}
+	}
+}
+
+func TestWithRangeExpansion(t *testing.T) {
+	config := `Host web[01-03].prod !web02.prod
+  User deploy
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if !slices.Equal(hosts[0].Host, []string{"web[01-03].prod", "!web02.prod"}) {
+		t.Errorf("expected ranges to be kept by default, got %v", hosts[0].Host)
+	}
+
+	hosts, err = parse(config, "~/.ssh/config", WithRangeExpansion())
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	expected := []string{"web01.prod", "web02.prod", "web03.prod", "!web02.prod"}
+	if !slices.Equal(hosts[0].Host, expected) {
+		t.Errorf("expected %v, got %v", expected, hosts[0].Host)
+	}
+	if Lookup(hosts, "web03.prod").User != "deploy" || Lookup(hosts, "web02.prod").User != "" {
+		t.Errorf("unexpected lookup results")
+	}
+
+	_, err = parse("Host web[3-1]\n", "~/.ssh/config", WithRangeExpansion())
+	if err == nil || err.Error() != "~/.ssh/config:1:6: invalid range in web[3-1]: [3-1] ends before it starts" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExpandHosts(t *testing.T) {
+	config := `Host web[1-2] !web9
+  HostName 10.0.0.[11-12]
+  User deploy
+
+Host db
+  HostName db.example.com
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	expanded, err := ExpandHosts(hosts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(expanded) != 3 {
+		t.Fatalf("expected 3 hosts, got %d", len(expanded))
+	}
+	for i, name := range []string{"web1", "web2"} {
+		h := expanded[i]
+		if !slices.Equal(h.Host, []string{name, "!web9"}) || h.HostName != "10.0.0.1"+string(rune('1'+i)) || h.User != "deploy" {
+			t.Errorf("unexpected host %d: %v %s %s", i, h.Host, h.HostName, h.User)
+		}
+	}
+	if expanded[2] != hosts[1] {
+		t.Errorf("expected host without ranges to be kept")
+	}
+	if hosts[0].HostName != "10.0.0.[11-12]" {
+		t.Errorf("expected input hosts to be unchanged")
+	}
+}
@@ -0,0 +1,91 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KeywordHandler parses the value of a keyword registered with
+// RegisterKeyword, as written in a config file with quotes removed.
+type KeywordHandler func(value string) (any, error)
+
+type registration struct {
+	name    string
+	handler KeywordHandler
+}
+
+var registry struct {
+	sync.RWMutex
+	keywords map[string]registration
+}
+
+// RegisterKeyword teaches the parser a keyword OpenSSH doesn't know, like a
+// vendor specific or experimental one, usually from an init function.
+// Keywords are matched case-insensitively.
+//
+// The value returned by handler for the first directive of the keyword in
+// a block is stored in the Extensions of the host under name. The values as
+// written are still stored in Unknowns, so they can be written back. An
+// error of handler is reported as parse error of the directive, and the
+// keyword is not reported as unsupported by warnings or WithStrictMode.
+// Handler may be called concurrently.
+//
+// RegisterKeyword panics if handler is nil, or name is already registered
+// or known to the parser.
+func RegisterKeyword(name string, handler KeywordHandler) {
+	if handler == nil {
+		panic("sshconfig: RegisterKeyword handler is nil")
+	}
+	key := strings.ToLower(name)
+	if _, ok := variables[key]; ok {
+		panic(fmt.Sprintf("sshconfig: RegisterKeyword of known keyword %s", name))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.keywords[key]; ok {
+		panic(fmt.Sprintf("sshconfig: RegisterKeyword called twice for %s", name))
+	}
+	if registry.keywords == nil {
+		registry.keywords = map[string]registration{}
+	}
+	registry.keywords[key] = registration{name: name, handler: handler}
+}
+
+// unregisterKeyword removes a keyword registered with RegisterKeyword.
+func unregisterKeyword(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.keywords, strings.ToLower(name))
+}
+
+// registeredKeyword returns the registered name and handler of keyword.
+func registeredKeyword(keyword string) (string, KeywordHandler, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	r, ok := registry.keywords[strings.ToLower(keyword)]
+	return r.name, r.handler, ok
+}
+
+// setExtension stores the value of a registered keyword in Extensions unless
+// it is already set. Other keywords are ignored.
+func (h *SSHHost) setExtension(keyword string, value string) error {
+	name, handler, ok := registeredKeyword(keyword)
+	if !ok {
+		return nil
+	}
+	if _, set := h.Extensions[name]; set {
+		return nil
+	}
+
+	v, err := handler(value)
+	if err != nil {
+		return err
+	}
+	if h.Extensions == nil {
+		h.Extensions = map[string]any{}
+	}
+	h.Extensions[name] = v
+	return nil
+}
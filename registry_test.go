@@ -0,0 +1,57 @@
+package sshconfig
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRegisterKeyword(t *testing.T) {
+	RegisterKeyword("XVendorTimeout", func(value string) (any, error) {
+		return strconv.Atoi(value)
+	})
+	t.Cleanup(func() { unregisterKeyword("XVendorTimeout") })
+
+	config := `Host web
+  HostName web.example.com
+  xvendortimeout 30
+  XVendorTimeout 60
+
+Host *
+  XVendorTimeout 10
+`
+	hosts, err := parse(config, "~/.ssh/config", WithStrictMode())
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	if v := hosts[0].Extensions["XVendorTimeout"]; v != 30 {
+		t.Errorf("expected first value 30, got %v", v)
+	}
+	if values := hosts[0].Unknowns["XVendorTimeout"]; len(values) != 1 || values[0] != "60" {
+		t.Errorf("expected values to be kept in Unknowns, got %v", hosts[0].Unknowns)
+	}
+	if v := Lookup(hosts, "db").Extensions["XVendorTimeout"]; v != 10 {
+		t.Errorf("expected lookup value 10, got %v", v)
+	}
+
+	web := hosts[0].Clone()
+	if err := web.Set("XVendorTimeout", "5"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v := web.Extensions["XVendorTimeout"]; v != 5 {
+		t.Errorf("expected Set value 5, got %v", v)
+	}
+
+	_, err = parse("Host web\n  XVendorTimeout soon\n", "~/.ssh/config")
+	if err == nil || err.Error() != `~/.ssh/config:2:18: strconv.Atoi: parsing "soon": invalid syntax` {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic registering a known keyword")
+			}
+		}()
+		RegisterKeyword("hostname", func(value string) (any, error) { return value, nil })
+	}()
+}
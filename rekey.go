@@ -0,0 +1,190 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RekeyLimit is the parsed value of the RekeyLimit keyword.
+type RekeyLimit struct {
+	// Bytes is the amount of data after which the session key is
+	// renegotiated, zero means the default of the cipher.
+	Bytes int64
+	// Interval is the time after which the session key is renegotiated,
+	// zero means no time based rekeying.
+	Interval time.Duration
+}
+
+// ParseRekeyLimit parses a RekeyLimit value like "1G 1h" or "default none".
+func ParseRekeyLimit(value string) (*RekeyLimit, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid RekeyLimit: %#v", value)
+	}
+
+	limit := &RekeyLimit{}
+	if fields[0] != "default" {
+		n, err := parseBytes(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid RekeyLimit: %#v", value)
+		}
+		limit.Bytes = n
+	}
+
+	if len(fields) == 2 && fields[1] != "none" {
+		d, err := parseTime(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid RekeyLimit: %#v", value)
+		}
+		limit.Interval = d
+	}
+
+	return limit, nil
+}
+
+// String returns the limit in the format used by ssh_config.
+func (r RekeyLimit) String() string {
+	size := "default"
+	if r.Bytes > 0 {
+		size = formatBytes(r.Bytes)
+	}
+	if r.Interval == 0 {
+		return size
+	}
+	return size + " " + formatTime(r.Interval)
+}
+
+// MarshalText encodes the limit as written in a config file, see String.
+func (r RekeyLimit) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a limit as written in a config file.
+func (r *RekeyLimit) UnmarshalText(text []byte) error {
+	limit, err := ParseRekeyLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*r = *limit
+	return nil
+}
+
+var byteUnits = []struct {
+	suffix byte
+	size   int64
+}{
+	{'G', 1 << 30},
+	{'M', 1 << 20},
+	{'K', 1 << 10},
+}
+
+// parseBytes parses a byte count with an optional K, M or G suffix.
+func parseBytes(value string) (int64, error) {
+	scale := int64(1)
+	if value != "" {
+		last := value[len(value)-1]
+		if last >= 'a' && last <= 'z' {
+			last -= 'a' - 'A'
+		}
+		for _, u := range byteUnits {
+			if last == u.suffix {
+				scale = u.size
+				value = value[:len(value)-1]
+				break
+			}
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 || n > (1<<62)/scale {
+		return 0, fmt.Errorf("byte count out of range: %#v", value)
+	}
+	return n * scale, nil
+}
+
+func formatBytes(n int64) string {
+	for _, u := range byteUnits {
+		if n%u.size == 0 {
+			return strconv.FormatInt(n/u.size, 10) + string(u.suffix)
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+var timeUnits = []struct {
+	suffix byte
+	size   time.Duration
+}{
+	{'w', 7 * 24 * time.Hour},
+	{'d', 24 * time.Hour},
+	{'h', time.Hour},
+	{'m', time.Minute},
+	{'s', time.Second},
+}
+
+// parseTime parses a time in the sshd_config format, a sequence of numbers
+// each followed by an optional unit, e.g. "1h30m". Numbers without a unit
+// are seconds.
+func parseTime(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, fmt.Errorf("invalid time: %#v", value)
+	}
+
+	var total time.Duration
+	rest := value
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid time: %#v", value)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time: %#v", value)
+		}
+		rest = rest[i:]
+
+		unit := time.Second
+		if rest != "" {
+			c := rest[0]
+			if c >= 'A' && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			found := false
+			for _, u := range timeUnits {
+				if c == u.suffix {
+					unit, found = u.size, true
+					break
+				}
+			}
+			if !found {
+				return 0, fmt.Errorf("invalid time: %#v", value)
+			}
+			rest = rest[1:]
+		}
+		total += time.Duration(n) * unit
+	}
+	return total, nil
+}
+
+func formatTime(d time.Duration) string {
+	var b strings.Builder
+	for _, u := range timeUnits {
+		if n := d / u.size; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10))
+			b.WriteByte(u.suffix)
+			d -= n * u.size
+		}
+	}
+	if b.Len() == 0 {
+		return "0"
+	}
+	return b.String()
+}
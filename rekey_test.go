@@ -0,0 +1,38 @@
+package sshconfig
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRekeyLimit(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected RekeyLimit
+		str      string
+	}{
+		{"default none", RekeyLimit{}, "default"},
+		{"1g", RekeyLimit{Bytes: 1 << 30}, "1G"},
+		{"1000 1h30m", RekeyLimit{Bytes: 1000, Interval: 90 * time.Minute}, "1000 1h30m"},
+		{"4M 90", RekeyLimit{Bytes: 4 << 20, Interval: 90 * time.Second}, "4M 1m30s"},
+		{"default 2w1d", RekeyLimit{Interval: 15 * 24 * time.Hour}, "default 2w1d"},
+	} {
+		limit, err := ParseRekeyLimit(tc.value)
+		if err != nil {
+			t.Errorf("unable to parse %#v: %s", tc.value, err.Error())
+			continue
+		}
+		if *limit != tc.expected {
+			t.Errorf("unexpected limit for %#v: %+v", tc.value, *limit)
+		}
+		if limit.String() != tc.str {
+			t.Errorf("unexpected string for %#v: %s", tc.value, limit.String())
+		}
+	}
+
+	for _, value := range []string{"", "0", "-1G", "1G 1y", "1G 1h 2h", "1.5G", "1G h"} {
+		if _, err := ParseRekeyLimit(value); err == nil {
+			t.Errorf("expected error for %#v", value)
+		}
+	}
+}
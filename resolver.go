@@ -0,0 +1,67 @@
+package sshconfig
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// IncludeResolver provides the files referenced by Include directives. It
+// allows serving included files from memory or remote config stores.
+// Resolvers which also have a method
+//
+//	Stat(name string) (fs.FileInfo, error)
+//
+// support including directories and caching with WithIncludeCache.
+type IncludeResolver interface {
+	// Glob returns the names of all files matching pattern, see
+	// filepath.Match for the pattern syntax.
+	Glob(pattern string) ([]string, error)
+	// ReadFile returns the content of the named file.
+	ReadFile(name string) ([]byte, error)
+}
+
+// WithIncludeResolver sets the resolver used for Include directives. By
+// default included files are read from the local filesystem, or from fsys
+// when using ParseFS.
+func WithIncludeResolver(r IncludeResolver) Option {
+	return func(o *options) {
+		o.resolver = r
+	}
+}
+
+// FSResolver returns an IncludeResolver serving files from fsys.
+func FSResolver(fsys fs.FS) IncludeResolver {
+	return fsResolver{fsys}
+}
+
+type fsResolver struct {
+	fsys fs.FS
+}
+
+func (r fsResolver) Glob(pattern string) ([]string, error) {
+	return fs.Glob(r.fsys, pattern)
+}
+
+func (r fsResolver) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(r.fsys, name)
+}
+
+func (r fsResolver) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.fsys, name)
+}
+
+// osResolver serves files from the local filesystem.
+type osResolver struct{}
+
+func (osResolver) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+func (osResolver) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osResolver) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
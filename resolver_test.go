@@ -0,0 +1,169 @@
+package sshconfig
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+// mapResolver serves included files from memory
+type mapResolver map[string]string
+
+func (r mapResolver) Glob(pattern string) ([]string, error) {
+	var names []string
+	for name := range r {
+		if ok, _ := path.Match(pattern, name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (r mapResolver) ReadFile(name string) ([]byte, error) {
+	content, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	return []byte(content), nil
+}
+
+func TestWithIncludeResolver(t *testing.T) {
+	resolver := mapResolver{
+		"/team/ssh/web.conf": "Host web\n  User deploy\n",
+	}
+
+	hosts, err := ParseString("Include web.conf\n", "/team/ssh/config", WithIncludeResolver(resolver))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if len(hosts) != 1 || hosts[0].User != "deploy" {
+		t.Errorf("unexpected hosts: %+v", hosts)
+	}
+}
+
+func TestParseFSInclude(t *testing.T) {
+	memfs := fstest.MapFS{
+		"ssh/config": &fstest.MapFile{
+			Data: []byte("Include conf.d/*.conf\n"),
+		},
+		"ssh/conf.d/a.conf": &fstest.MapFile{
+			Data: []byte("Host a\n"),
+		},
+		"ssh/conf.d/b.conf": &fstest.MapFile{
+			Data: []byte("Host b\n"),
+		},
+	}
+
+	hosts, err := ParseFS(memfs, "ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	var aliases []string
+	for _, h := range hosts {
+		aliases = append(aliases, h.Host...)
+	}
+	if !reflect.DeepEqual([]string{"a", "b"}, aliases) {
+		t.Errorf("unexpected hosts: %v", aliases)
+	}
+}
+
+// countingResolver counts the reads of every included file
+type countingResolver struct {
+	mapResolver
+	reads map[string]int
+}
+
+func (r *countingResolver) ReadFile(name string) ([]byte, error) {
+	r.reads[name]++
+	return r.mapResolver.ReadFile(name)
+}
+
+func TestIncludeReadOnce(t *testing.T) {
+	resolver := &countingResolver{
+		mapResolver: mapResolver{
+			"/team/ssh/hosts.conf":    "Host web\n  User deploy\nInclude defaults.conf\n",
+			"/team/ssh/defaults.conf": "Host *\n  Port 2222\n",
+		},
+		reads: map[string]int{},
+	}
+
+	config := "Include hosts.conf\nHost db\n  User postgres\n"
+	hosts, err := ParseString(config, "/team/ssh/config", WithIncludeResolver(resolver))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	if len(hosts) != 3 {
+		t.Errorf("expected 3 hosts, got %d", len(hosts))
+	}
+
+	for name, n := range resolver.reads {
+		if n != 1 {
+			t.Errorf("%s read %d times", name, n)
+		}
+	}
+	if len(resolver.reads) != 2 {
+		t.Errorf("unexpected reads: %v", resolver.reads)
+	}
+}
+
+// reversedResolver returns glob matches in reverse order
+type reversedResolver struct {
+	mapResolver
+}
+
+func (r reversedResolver) Glob(pattern string) ([]string, error) {
+	names, err := r.mapResolver.Glob(pattern)
+	slices.Reverse(names)
+	return names, err
+}
+
+func TestIncludeOrder(t *testing.T) {
+	resolver := reversedResolver{mapResolver{
+		"/team/ssh/conf.d/10-web.conf": "Host web\n",
+		"/team/ssh/conf.d/20-db.conf":  "Host db\n",
+		"/team/ssh/conf.d/A.conf":      "Host upper\n",
+	}}
+
+	hosts, err := ParseString("Include conf.d/*\n", "/team/ssh/config", WithIncludeResolver(resolver))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	var aliases []string
+	for _, h := range hosts {
+		aliases = append(aliases, h.Host...)
+	}
+	if !reflect.DeepEqual(aliases, []string{"web", "db", "upper"}) {
+		t.Errorf("unexpected order: %v", aliases)
+	}
+}
+
+func TestIncludeDirectory(t *testing.T) {
+	memfs := fstest.MapFS{
+		"ssh/config":            &fstest.MapFile{Data: []byte("Include conf.d\nHost last\n")},
+		"ssh/conf.d/b.conf":     &fstest.MapFile{Data: []byte("Host b\n")},
+		"ssh/conf.d/a.conf":     &fstest.MapFile{Data: []byte("Host a\n")},
+		"ssh/conf.d/sub/c.conf": &fstest.MapFile{Data: []byte("Host c\n")},
+	}
+
+	hosts, err := ParseFS(memfs, "ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	var aliases []string
+	for _, h := range hosts {
+		aliases = append(aliases, h.Host...)
+	}
+	if !reflect.DeepEqual(aliases, []string{"a", "b", "last"}) {
+		t.Errorf("unexpected hosts: %v", aliases)
+	}
+}
@@ -0,0 +1,97 @@
+package sshconfig
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// FindByHostName returns the aliases of hosts connecting to name, that is
+// whose effective HostName as found by Lookup is name. Names are compared
+// case-insensitively, ignoring a trailing dot. An alias without HostName
+// connects to itself.
+func FindByHostName(hosts []*SSHHost, name string) []string {
+	name = normalizeHostName(name)
+
+	var aliases []string
+	for _, alias := range NewConfig(hosts).Aliases() {
+		if normalizeHostName(hostNameOf(hosts, alias)) == name {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// FindByAddr returns the aliases of hosts connecting to addr, which is an
+// IP address or a CIDR prefix like 10.0.0.0/8. HostNames which are IP
+// addresses are compared directly, others are resolved using r. Without a
+// resolver, HostNames which are no IP address are skipped.
+func FindByAddr(ctx context.Context, hosts []*SSHHost, addr string, r HostResolver) ([]string, error) {
+	prefix, err := netip.ParsePrefix(addr)
+	if err != nil {
+		ip, ipErr := netip.ParseAddr(addr)
+		if ipErr != nil {
+			return nil, fmt.Errorf("invalid address or CIDR: %#v", addr)
+		}
+		prefix = netip.PrefixFrom(ip, ip.BitLen())
+	}
+	prefix = prefix.Masked()
+
+	var aliases []string
+	for _, alias := range NewConfig(hosts).Aliases() {
+		name := hostNameOf(hosts, alias)
+
+		var addrs []string
+		if _, err := netip.ParseAddr(name); err == nil {
+			addrs = []string{name}
+		} else if r != nil {
+			addrs, err = r.LookupHost(ctx, name)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				continue
+			}
+		}
+
+		for _, a := range addrs {
+			ip, err := netip.ParseAddr(a)
+			if err == nil && prefix.Contains(ip.Unmap().WithZone("")) {
+				aliases = append(aliases, alias)
+				break
+			}
+		}
+	}
+	return aliases, nil
+}
+
+// FindByHostName returns the aliases connecting to name, see FindByHostName.
+func (c *Config) FindByHostName(name string) []string {
+	return FindByHostName(c.hosts, name)
+}
+
+// FindByAddr returns the aliases connecting to addr, see FindByAddr.
+func (c *Config) FindByAddr(ctx context.Context, addr string, r HostResolver) ([]string, error) {
+	return FindByAddr(ctx, c.hosts, addr, r)
+}
+
+// hostNameOf returns the effective HostName of alias with its tokens
+// expanded.
+func hostNameOf(hosts []*SSHHost, alias string) string {
+	return expandHostName(Lookup(hosts, alias).HostName, alias)
+}
+
+// expandHostName expands the %h and %% tokens of a HostName, the only ones
+// allowed there.
+func expandHostName(name, alias string) string {
+	if !strings.Contains(name, "%") {
+		return name
+	}
+	return strings.NewReplacer("%h", alias, "%%", "%").Replace(name)
+}
+
+// normalizeHostName returns name lowercased without trailing dot.
+func normalizeHostName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
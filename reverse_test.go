@@ -0,0 +1,81 @@
+package sshconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const reverseConfig = `Host web web-alias
+  HostName Web.Example.com.
+
+Host db
+  HostName 10.1.2.3
+
+Host v6
+  HostName 2001:db8::5
+
+Host web.example.com
+
+Host jump
+  HostName %h.example.com
+
+Host *.example.com
+  User admin
+`
+
+func TestFindByHostName(t *testing.T) {
+	hosts, err := parse(reverseConfig, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	c := NewConfig(hosts)
+
+	if aliases := c.FindByHostName("web.example.com"); !reflect.DeepEqual(aliases, []string{"web", "web-alias", "web.example.com"}) {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+	if aliases := c.FindByHostName("jump.example.com"); !reflect.DeepEqual(aliases, []string{"jump"}) {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+	if aliases := c.FindByHostName("unknown"); aliases != nil {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+}
+
+func TestFindByAddr(t *testing.T) {
+	hosts, err := parse(reverseConfig, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	c := NewConfig(hosts)
+	ctx := context.Background()
+
+	for addr, expected := range map[string][]string{
+		"10.1.2.3":       {"db"},
+		"10.0.0.0/8":     {"db"},
+		"2001:db8::/32":  {"v6"},
+		"192.168.0.0/16": nil,
+	} {
+		aliases, err := c.FindByAddr(ctx, addr, nil)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %s", addr, err.Error())
+		}
+		if !reflect.DeepEqual(aliases, expected) {
+			t.Errorf("unexpected aliases for %s: %v", addr, aliases)
+		}
+	}
+
+	// names are resolved with a resolver
+	r := mapHostResolver{"web.example.com.": "", "jump.example.com": ""}
+	aliases, err := c.FindByAddr(ctx, "192.0.2.0/24", r)
+	if err != nil {
+		t.Fatalf("unable to find aliases: %s", err.Error())
+	}
+	if !reflect.DeepEqual(aliases, []string{"jump"}) {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+
+	if _, err := c.FindByAddr(ctx, "not an address", nil); err == nil {
+		t.Errorf("expected error for invalid address")
+	}
+}
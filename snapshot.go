@@ -0,0 +1,222 @@
+package sshconfig
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrStaleSnapshot is returned by ReadSnapshot if a file of the config
+// changed since the snapshot was written.
+var ErrStaleSnapshot = errors.New("snapshot is stale")
+
+// errNoStat is returned for resolvers without a Stat method.
+var errNoStat = errors.New("snapshots require an include resolver with a Stat method")
+
+// snapshotVersion is increased when the encoding of snapshots changes.
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version  int
+	Files    []snapshotFile
+	Includes []recordedInclude
+	Hosts    []snapshotHost
+}
+
+type snapshotFile struct {
+	Name    string
+	ModTime time.Time
+	Size    int64
+}
+
+type snapshotHost struct {
+	Host *SSHHost
+	// Parent is the index of the parent block plus one, zero for none.
+	Parent int
+	Depth  int
+	Global bool
+}
+
+// parseRecord collects the files read and the includes expanded while
+// parsing.
+type parseRecord struct {
+	mu       sync.Mutex
+	files    []string
+	includes []recordedInclude
+}
+
+// recordedInclude is an Include pattern of File and the files it expanded
+// to.
+type recordedInclude struct {
+	File    string
+	Pattern string
+	Matches []string
+}
+
+// recording is used to collect the files and includes of a parse in r.
+func recording(r *parseRecord) Option {
+	return func(o *options) {
+		o.record = r
+	}
+}
+
+func (r *parseRecord) addFile(name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files = append(r.files, name)
+}
+
+// merge adds the files and includes recorded by other.
+func (r *parseRecord) merge(other *parseRecord) {
+	if r == nil || other == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files = append(r.files, other.files...)
+	r.includes = append(r.includes, other.includes...)
+}
+
+func (r *parseRecord) addInclude(file, pattern string, matches []string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.includes = append(r.includes, recordedInclude{File: file, Pattern: pattern, Matches: matches})
+}
+
+// WriteSnapshot parses the SSH config given by path like Parse and writes
+// the hosts to w in a compact binary form, together with the modification
+// time and size of the config and every included file. ReadSnapshot loads
+// the hosts much faster than parsing large configs, for programs which
+// parse the config at every start. The parsed hosts are returned as well,
+// also if the snapshot could not be written.
+//
+// The include resolver must have a Stat method like the default one, see
+// IncludeResolver. Extensions are not written, ReadSnapshot sets them again
+// from the keywords registered at that time.
+func WriteSnapshot(w io.Writer, path string, opts ...Option) ([]*SSHHost, error) {
+	o := newOptions(opts)
+	r, ok := o.resolver.(statResolver)
+	if !ok {
+		return nil, errNoStat
+	}
+
+	record := &parseRecord{}
+	hosts, err := Parse(path, append(opts[:len(opts):len(opts)], recording(record))...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := snapshot{Version: snapshotVersion, Includes: record.includes}
+	for _, name := range record.files {
+		info, err := r.Stat(name)
+		if err != nil {
+			return hosts, err
+		}
+		s.Files = append(s.Files, snapshotFile{Name: name, ModTime: info.ModTime(), Size: info.Size()})
+	}
+
+	index := make(map[*SSHHost]int, len(hosts))
+	for i, h := range hosts {
+		index[h] = i + 1
+	}
+	for _, h := range hosts {
+		c := h.Clone()
+		c.Extensions = nil
+		s.Hosts = append(s.Hosts, snapshotHost{Host: c, Parent: index[h.parent], Depth: h.depth, Global: h.global})
+	}
+
+	return hosts, gob.NewEncoder(w).Encode(s)
+}
+
+// ReadSnapshot reads the hosts of a snapshot written by WriteSnapshot. It
+// returns ErrStaleSnapshot if a file of the config was modified, removed or
+// added to the files an Include directive matches since, in which case the
+// config has to be parsed again. Included files are resolved with opts,
+// which must be the options the snapshot was written with.
+func ReadSnapshot(r io.Reader, opts ...Option) ([]*SSHHost, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, ErrStaleSnapshot
+	}
+
+	o := newOptions(opts)
+	sr, ok := o.resolver.(statResolver)
+	if !ok {
+		return nil, errNoStat
+	}
+	for _, f := range s.Files {
+		info, err := sr.Stat(f.Name)
+		if err != nil || !info.ModTime().Equal(f.ModTime) || info.Size() != f.Size {
+			return nil, ErrStaleSnapshot
+		}
+	}
+	for _, inc := range s.Includes {
+		matches, err := includeFiles(inc.File, inc.Pattern, o)
+		if err != nil || !slices.Equal(matches, inc.Matches) {
+			return nil, ErrStaleSnapshot
+		}
+	}
+
+	hosts := make([]*SSHHost, len(s.Hosts))
+	for i, sh := range s.Hosts {
+		h := sh.Host
+		h.global, h.depth = sh.Global, sh.Depth
+		if sh.Parent > 0 {
+			h.parent = hosts[sh.Parent-1]
+		}
+		for keyword, values := range h.Unknowns {
+			if err := h.setExtension(keyword, values[0]); err != nil {
+				return nil, err
+			}
+		}
+		hosts[i] = h
+	}
+	return hosts, nil
+}
+
+// ParseCached parses the SSH config given by path like Parse, using the
+// snapshot in cacheFile if it is up to date, see ReadSnapshot. Otherwise the
+// config is parsed and a new snapshot is written to cacheFile. Failing to
+// write the snapshot is not an error, the config is parsed again next time.
+func ParseCached(path string, cacheFile string, opts ...Option) ([]*SSHHost, error) {
+	if f, err := os.Open(cacheFile); err == nil {
+		hosts, err := ReadSnapshot(f, opts...)
+		f.Close()
+		if err == nil {
+			return hosts, nil
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cacheFile), filepath.Base(cacheFile)+".*")
+	if err != nil {
+		return Parse(path, opts...)
+	}
+	defer os.Remove(tmp.Name())
+
+	hosts, err := WriteSnapshot(tmp, path, opts...)
+	closeErr := tmp.Close()
+	switch {
+	case errors.Is(err, errNoStat):
+		return Parse(path, opts...)
+	case hosts == nil:
+		return nil, err
+	case err == nil && closeErr == nil:
+		os.Rename(tmp.Name(), cacheFile)
+	}
+	return hosts, nil
+}
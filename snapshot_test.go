@@ -0,0 +1,102 @@
+package sshconfig
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatalf("unable to create dir: %s", err.Error())
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write file: %s", err.Error())
+		}
+	}
+	write("config", `User admin
+
+Host web
+  HostName web.example.com
+  Include conf.d/*.conf
+`)
+	write("conf.d/a.conf", "Host db\n  Port 2222\n  XVendor on\n")
+	config := filepath.Join(dir, "config")
+
+	var buf bytes.Buffer
+	hosts, err := WriteSnapshot(&buf, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	loaded, err := ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(loaded) != len(hosts) {
+		t.Fatalf("expected %d hosts, got %d", len(hosts), len(loaded))
+	}
+	for i := range hosts {
+		if !hosts[i].Equal(loaded[i]) || loaded[i].IsGlobal() != hosts[i].IsGlobal() {
+			t.Errorf("host %d differs: %+v", i, loaded[i])
+		}
+	}
+	if loaded[2].parent != loaded[1] {
+		t.Errorf("expected included block to keep its parent")
+	}
+	if Lookup(loaded, "db").Port != 22 || Lookup(loaded, "web").User != "admin" {
+		t.Errorf("unexpected lookup results")
+	}
+	if loaded[2].Extensions != nil {
+		t.Errorf("expected no extensions without registered keywords")
+	}
+
+	write("conf.d/b.conf", "Host mail\n")
+	if _, err := ReadSnapshot(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrStaleSnapshot) {
+		t.Errorf("expected stale snapshot after adding a file, got %v", err)
+	}
+	os.Remove(filepath.Join(dir, "conf.d/b.conf"))
+
+	write("conf.d/a.conf", "Host db\n  Port 2223\n")
+	if _, err := ReadSnapshot(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrStaleSnapshot) {
+		t.Errorf("expected stale snapshot after changing a file, got %v", err)
+	}
+}
+
+func TestParseCached(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	cache := filepath.Join(dir, "config.cache")
+	if err := os.WriteFile(config, []byte("Host web\n  User deploy\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		hosts, err := ParseCached(config, cache)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(hosts) != 1 || hosts[0].User != "deploy" {
+			t.Errorf("unexpected hosts: %+v", hosts)
+		}
+		if _, err := os.Stat(cache); err != nil {
+			t.Errorf("expected cache file: %s", err)
+		}
+	}
+
+	if err := os.WriteFile(config, []byte("Host web\n  User root\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	hosts, err := ParseCached(config, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if hosts[0].User != "root" {
+		t.Errorf("expected changed config to be parsed again, got %s", hosts[0].User)
+	}
+}
@@ -0,0 +1,57 @@
+package sshconfig
+
+import "sync"
+
+// Store holds a parsed SSH config which can be shared by multiple goroutines
+// and reloaded while in use, for example from a goroutine receiving from a
+// Watcher or a ticker.
+type Store struct {
+	path string
+	opts []Option
+
+	mu     sync.RWMutex
+	config *Config
+}
+
+// NewStore parses the SSH config given by path into a new Store.
+func NewStore(path string, opts ...Option) (*Store, error) {
+	s := &Store{path: path, opts: opts}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the current config. The returned config is not modified by
+// reloads, so it may be used after the store was reloaded.
+func (s *Store) Get() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// Lookup returns the effective configuration for alias in the current
+// config, see Lookup.
+func (s *Store) Lookup(alias string, opts ...LookupOption) *SSHHost {
+	return s.Get().Lookup(alias, opts...)
+}
+
+// Reload parses the config again and replaces the current one. If parsing
+// fails the current config is kept and the error returned.
+func (s *Store) Reload() error {
+	config, err := Load(s.path, s.opts...)
+	if err != nil {
+		return err
+	}
+
+	s.Set(config)
+	return nil
+}
+
+// Set replaces the current config, for example with the hosts received from
+// a Watcher.
+func (s *Store) Set(config *Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
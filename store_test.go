@@ -0,0 +1,60 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("Host web\n  User deploy\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("unable to load config: %s", err.Error())
+	}
+
+	old := s.Get()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if user := s.Lookup("web").User; user != "deploy" && user != "backup" {
+					t.Errorf("unexpected user %s", user)
+				}
+			}
+		}()
+	}
+
+	if err := os.WriteFile(path, []byte("Host web\n  User backup\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if err := s.Reload(); err != nil {
+		t.Errorf("unable to reload config: %s", err)
+	}
+	wg.Wait()
+
+	if user := s.Lookup("web").User; user != "backup" {
+		t.Errorf("expected user backup after reload, got %s", user)
+	}
+	if user := old.Lookup("web").User; user != "deploy" {
+		t.Errorf("expected previous config to be unchanged, got user %s", user)
+	}
+
+	if err := os.WriteFile(path, []byte("Host web\n  Port abc\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if err := s.Reload(); err == nil {
+		t.Error("expected error reloading invalid config")
+	}
+	if user := s.Lookup("web").User; user != "backup" {
+		t.Errorf("expected config to be kept on error, got user %s", user)
+	}
+}
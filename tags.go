@@ -0,0 +1,75 @@
+package sshconfig
+
+import (
+	"bytes"
+	"strings"
+)
+
+// annotationPrefix starts a comment holding tags of a block, like
+//
+//	# sshconfig: tag=prod owner=infra
+const annotationPrefix = "sshconfig:"
+
+// parseAnnotation returns the tags of an annotation comment, given as text
+// without the leading #. Tags are key=value arguments quoted like values of
+// directives, a key without = gets an empty value. It reports false if text
+// is no valid annotation.
+func parseAnnotation(text string) (map[string]string, bool) {
+	rest, ok := strings.CutPrefix(text, annotationPrefix)
+	if !ok {
+		return nil, false
+	}
+	args, err := splitArgs(rest)
+	if err != nil {
+		return nil, false
+	}
+
+	tags := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		if key == "" {
+			return nil, false
+		}
+		tags[key] = value
+	}
+	return tags, true
+}
+
+// formatAnnotation returns the comment line, without newline, holding tags
+// sorted by key.
+func formatAnnotation(tags map[string]string) string {
+	var b strings.Builder
+	b.WriteString("# " + annotationPrefix)
+	for _, key := range sortedKeys(tags) {
+		b.WriteString(" " + quoteArg(key+"="+tags[key]))
+	}
+	return b.String()
+}
+
+// addComment adds a comment of the block, given as text without the leading
+// #. The tags of annotations are added to Tags, later ones taking
+// precedence, other comments to Comments.
+func (h *SSHHost) addComment(text string) {
+	tags, ok := parseAnnotation(text)
+	if !ok {
+		h.Comments = append(h.Comments, text)
+		return
+	}
+	if h.Tags == nil {
+		h.Tags = make(map[string]string, len(tags))
+	}
+	for key, value := range tags {
+		h.Tags[key] = value
+	}
+}
+
+// writeComments writes the Comments of h and an annotation with its Tags
+// as comment lines.
+func writeComments(b *bytes.Buffer, h *SSHHost) {
+	for _, c := range h.Comments {
+		b.WriteString(strings.TrimSuffix("# "+c, " ") + "\n")
+	}
+	if len(h.Tags) > 0 {
+		b.WriteString(formatAnnotation(h.Tags) + "\n")
+	}
+}
@@ -0,0 +1,56 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	config := `# Production web server
+# sshconfig: tag=prod owner=infra
+Host web
+  HostName web.example.com
+  # sshconfig: team="web platform" tag=web
+
+# sshconfig:
+Host db
+  # sshconfig: =broken
+  User postgres
+`
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := map[string]string{"tag": "web", "owner": "infra", "team": "web platform"}
+	if !reflect.DeepEqual(hosts[0].Tags, expected) {
+		t.Errorf("unexpected Tags: %v", hosts[0].Tags)
+	}
+	if !reflect.DeepEqual(hosts[0].Comments, []string{"Production web server"}) {
+		t.Errorf("unexpected Comments: %q", hosts[0].Comments)
+	}
+	if len(hosts[1].Tags) != 0 || !reflect.DeepEqual(hosts[1].Comments, []string{"sshconfig: =broken"}) {
+		t.Errorf("unexpected Tags %v and Comments %q", hosts[1].Tags, hosts[1].Comments)
+	}
+
+	out, err := Marshal(hosts[:1])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedOut := `# Production web server
+# sshconfig: owner=infra tag=web "team=web platform"
+Host web
+  HostName web.example.com
+`
+	if string(out) != expectedOut {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedOut, out)
+	}
+
+	reparsed, err := parse(string(out), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse output: %s", err)
+	}
+	if !reflect.DeepEqual(reparsed[0].Tags, hosts[0].Tags) {
+		t.Errorf("Tags not kept: %v", reparsed[0].Tags)
+	}
+}
@@ -0,0 +1,188 @@
+package sshconfig
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Template renders Host blocks from a text/template, for tools generating
+// the config of a fleet. Besides the builtin functions templates can use
+// the ones of TemplateFuncs.
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate parses text as template called name.
+func NewTemplate(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Render executes the template with data and returns the result formatted
+// like Format does. An error is returned if the result is not a valid
+// config, which is reported at its line of the result with the name of the
+// template as file.
+func (t *Template) Render(data any) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	if _, err := parse(b.String(), t.tmpl.Name()); err != nil {
+		return nil, err
+	}
+	return Format(b.Bytes())
+}
+
+// TemplateFuncs returns the functions available to a Template:
+//
+//   - quote quotes a value as argument if needed, like "my key".
+//   - patterns joins a string or a list of Host patterns quoted as needed.
+//   - forward validates a LocalForward or RemoteForward given as string, or
+//     renders a Forward or DynamicForward, as written in a config.
+//   - jump validates and joins a string or a list of ProxyJump hops.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"quote": quoteArg,
+		"patterns": func(v any) (string, error) {
+			values, err := templateStrings(v)
+			if err != nil {
+				return "", err
+			}
+			for i, p := range values {
+				values[i] = quoteArg(p)
+			}
+			return strings.Join(values, " "), nil
+		},
+		"forward": func(v any) (string, error) {
+			switch f := v.(type) {
+			case Forward:
+				return f.String(), nil
+			case DynamicForward:
+				return f.String(), nil
+			case string:
+				forward, err := NewForward(f)
+				if err != nil {
+					return "", err
+				}
+				return forward.String(), nil
+			}
+			return "", fmt.Errorf("invalid forward of type %T", v)
+		},
+		"jump": func(v any) (string, error) {
+			values, err := templateStrings(v)
+			if err != nil {
+				return "", err
+			}
+			var specs []string
+			for _, value := range values {
+				hops, err := ParseProxyJump(value)
+				if err != nil {
+					return "", err
+				}
+				for _, hop := range hops {
+					specs = append(specs, hop.String())
+				}
+			}
+			return strings.Join(specs, ","), nil
+		},
+	}
+}
+
+// templateStrings returns the strings of a template argument which is a
+// string or a list of strings.
+func templateStrings(v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return append([]string(nil), v...), nil
+	}
+	return nil, fmt.Errorf("expected a string or a list of strings, got %T", v)
+}
+
+// managedMarkers returns the comment lines starting and ending the managed
+// block name.
+func managedMarkers(name string) (string, string) {
+	return "# BEGIN sshconfig " + name, "# END sshconfig " + name
+}
+
+// SetManagedBlock replaces the content between the marker comments
+//
+//	# BEGIN sshconfig <name>
+//	# END sshconfig <name>
+//
+// with content, like the Host blocks rendered by a Template, so a tool can
+// maintain its part of a config written by hand. The block is appended to
+// the config if it has no markers yet. Content must only have Host and
+// Match blocks, directives before the first one would belong to the block
+// before the managed one.
+func (e *Editor) SetManagedBlock(name string, content []byte) error {
+	hosts, err := parse(string(content), name)
+	if err != nil {
+		return err
+	}
+	if len(hosts) > 0 && hosts[0].IsGlobal() {
+		return fmt.Errorf("managed block %s has directives outside of a Host or Match block", name)
+	}
+
+	begin, end := managedMarkers(name)
+	text := string(content)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	text = e.newlines(begin + "\n" + text + end + "\n")
+
+	start, stop, err := e.findManagedBlock(name)
+	if err != nil {
+		return err
+	}
+	if start < 0 {
+		return e.appendBlock(text)
+	}
+	return e.reset(e.content[:start] + text + e.content[stop:])
+}
+
+// RemoveManagedBlock removes the managed block name including its markers,
+// see SetManagedBlock. It does nothing if there is no such block.
+func (e *Editor) RemoveManagedBlock(name string) error {
+	start, stop, err := e.findManagedBlock(name)
+	if err != nil || start < 0 {
+		return err
+	}
+
+	before, after := e.content[:start], e.content[stop:]
+	// drop the blank line separating a block appended by SetManagedBlock
+	if nl := e.newlines("\n"); after == "" && strings.HasSuffix(before, nl+nl) {
+		before = strings.TrimSuffix(before, nl)
+	}
+	return e.reset(before + after)
+}
+
+// findManagedBlock returns the offsets of the first line of the managed
+// block name and after its last line, or -1 if there is none.
+func (e *Editor) findManagedBlock(name string) (int, int, error) {
+	begin, end := managedMarkers(name)
+	start := -1
+	offset := 0
+	for _, line := range strings.SplitAfter(e.content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == begin && start < 0:
+			start = offset
+		case trimmed == end && start >= 0:
+			return start, offset + len(line), nil
+		case trimmed == end:
+			return -1, -1, fmt.Errorf("%s: end of managed block %s without begin", e.path, name)
+		}
+		offset += len(line)
+	}
+	if start >= 0 {
+		return -1, -1, fmt.Errorf("%s: managed block %s is not terminated", e.path, name)
+	}
+	return -1, -1, nil
+}
@@ -0,0 +1,102 @@
+package sshconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := NewTemplate("fleet", `{{range .}}
+Host {{patterns .Aliases}}
+  HostName {{.Addr}}
+  IdentityFile {{quote .Key}}
+{{- range .Forwards}}
+  LocalForward {{forward .}}
+{{- end}}
+{{- with .Jump}}
+  ProxyJump {{jump .}}
+{{- end}}
+{{end}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	type server struct {
+		Aliases  []string
+		Addr     string
+		Key      string
+		Forwards []string
+		Jump     []string
+	}
+	out, err := tmpl.Render([]server{
+		{Aliases: []string{"web", "web prod"}, Addr: "10.0.0.1", Key: "~/.ssh/fleet key", Forwards: []string{"8080  localhost:80"}},
+		{Aliases: []string{"db"}, Addr: "10.0.0.2", Key: "~/.ssh/fleet", Jump: []string{"ssh://admin@bastion:2222", "edge"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `Host web "web prod"
+  HostName 10.0.0.1
+  IdentityFile "~/.ssh/fleet key"
+  LocalForward 8080 localhost:80
+
+Host db
+  HostName 10.0.0.2
+  IdentityFile ~/.ssh/fleet
+  ProxyJump admin@bastion:2222,edge
+`
+	if string(out) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	tmpl, err = NewTemplate("ports", "Host {{.}}\n  Port {{.}}\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_, err = tmpl.Render("web")
+	if err == nil || err.Error() != `ports:2:8: strconv.Atoi: parsing "web": invalid syntax` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSetManagedBlock(t *testing.T) {
+	e, err := NewEditor([]byte("Host personal\n  User me\n"), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := e.SetManagedBlock("fleet", []byte("Host web\n  HostName 10.0.0.1\n")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "Host personal\n  User me\n\n# BEGIN sshconfig fleet\nHost web\n  HostName 10.0.0.1\n# END sshconfig fleet\n"
+	if string(e.Bytes()) != expected {
+		t.Errorf("unexpected content:\n%s", e.Bytes())
+	}
+
+	if err := e.SetManagedBlock("fleet", []byte("Host db\n  HostName 10.0.0.2")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected = strings.Replace(expected, "Host web\n  HostName 10.0.0.1", "Host db\n  HostName 10.0.0.2", 1)
+	if string(e.Bytes()) != expected {
+		t.Errorf("unexpected content:\n%s", e.Bytes())
+	}
+
+	if err := e.SetManagedBlock("fleet", []byte("User admin\n")); err == nil {
+		t.Error("expected error for directives outside of a block")
+	}
+
+	if err := e.RemoveManagedBlock("fleet"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(e.Bytes()) != "Host personal\n  User me\n" {
+		t.Errorf("unexpected content: %q", e.Bytes())
+	}
+
+	e, err = NewEditor([]byte("# BEGIN sshconfig fleet\nHost web\n"), "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := e.SetManagedBlock("fleet", nil); err == nil || err.Error() != "~/.ssh/config: managed block fleet is not terminated" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
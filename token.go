@@ -0,0 +1,95 @@
+package sshconfig
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind int
+
+const (
+	// TokenKeyword is the keyword starting a line, like HostName, Host or
+	// Match.
+	TokenKeyword TokenKind = iota
+	// TokenValue is the rest of the line following a keyword and its
+	// separator, as written.
+	TokenValue
+	// TokenComment is a comment line, starting with #.
+	TokenComment
+	// TokenError is a syntax error, its Text is the error message. It is
+	// the last token.
+	TokenError
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenKeyword:
+		return "keyword"
+	case TokenValue:
+		return "value"
+	case TokenComment:
+		return "comment"
+	case TokenError:
+		return "error"
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Token is a lexical token of a config.
+type Token struct {
+	Kind TokenKind
+	// Text is the token as written in the input, or the message of an
+	// error.
+	Text string
+	// Offset is the byte offset of the token in the input.
+	Offset int
+	// Line and Column give the position of the token, starting at 1.
+	// Columns count bytes.
+	Line   int
+	Column int
+	// Known reports whether a keyword is known to the parser, which
+	// includes Host, Match and Include but not keywords registered with
+	// RegisterKeyword.
+	Known bool
+}
+
+// Tokens returns an iterator over the tokens of a config, for tools like
+// syntax highlighters which need the tokens without the semantic parse:
+// values are not validated and Include directives are not followed. Blank
+// lines, whitespace and separators between tokens are skipped.
+func Tokens(input string) iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		l := lexWithComments(input)
+		defer l.drain()
+
+		for {
+			it := l.nextItem()
+			if it.typ == itemEOF {
+				return
+			}
+
+			t := Token{
+				Text:   it.val,
+				Offset: int(it.pos),
+				Line:   it.line,
+				Column: int(it.pos) - strings.LastIndex(input[:it.pos], "\n"),
+			}
+			switch it.typ {
+			case itemError:
+				t.Kind = TokenError
+			case itemComment:
+				t.Kind = TokenComment
+			case itemValue, itemHostValue:
+				t.Kind = TokenValue
+			default:
+				t.Kind = TokenKeyword
+				t.Known = it.typ != itemUnknown
+			}
+			if !yield(t) || t.Kind == TokenError {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	config := "# jump hosts\r\nHost bastion\r\n  HostName=bastion.example.com\r\n  UseKeychain yes # not a comment\r\n\r\n  # indented\r\n"
+
+	var got []string
+	for tok := range Tokens(config) {
+		got = append(got, fmt.Sprintf("%d:%d %s %q %t", tok.Line, tok.Column, tok.Kind, tok.Text, tok.Known))
+		if config[tok.Offset:tok.Offset+len(tok.Text)] != tok.Text {
+			t.Errorf("offset %d does not point to %q", tok.Offset, tok.Text)
+		}
+	}
+	expected := []string{
+		`1:1 comment "# jump hosts" false`,
+		`2:1 keyword "Host" true`,
+		`2:6 value "bastion" false`,
+		`3:3 keyword "HostName" true`,
+		`3:12 value "bastion.example.com" false`,
+		`4:3 keyword "UseKeychain" false`,
+		`4:15 value "yes # not a comment" false`,
+		`6:3 comment "# indented" false`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected tokens:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	var last Token
+	n := 0
+	for tok := range Tokens("Host web\n  -Port 22\n  User nobody\n") {
+		last = tok
+		n++
+	}
+	if n != 3 || last.Kind != TokenError || last.Line != 2 {
+		t.Errorf("expected error token on line 2 after 2 tokens, got %d tokens ending with %+v", n, last)
+	}
+
+	for tok := range Tokens("Host web\n  User nobody\n") {
+		if tok.Kind != TokenKeyword {
+			t.Errorf("expected to stop at the first token, got %+v", tok)
+		}
+		break
+	}
+}
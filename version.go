@@ -0,0 +1,161 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version rule IDs.
+const (
+	// RuleUnsupportedKeyword flags a keyword added after the target version.
+	RuleUnsupportedKeyword = "unsupported-keyword"
+	// RuleRemovedKeyword flags a keyword removed before the target version.
+	RuleRemovedKeyword = "removed-keyword"
+	// RuleUnsupportedValue flags a value, like a Match criterion, added after
+	// the target version.
+	RuleUnsupportedValue = "unsupported-value"
+)
+
+// Version is an OpenSSH release.
+type Version struct {
+	Major int
+	Minor int
+}
+
+// ParseVersion parses an OpenSSH version like 8.2, 8.2p1 or OpenSSH_8.2p1
+// as printed by `ssh -V`.
+func ParseVersion(s string) (Version, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(s), "OpenSSH_")
+	if i := strings.IndexAny(v, "p ,"); i >= 0 {
+		v = v[:i]
+	}
+	major, minor, ok := strings.Cut(v, ".")
+	if !ok {
+		return Version{}, fmt.Errorf("invalid OpenSSH version: %#v", s)
+	}
+	var version Version
+	var err error
+	if version.Major, err = strconv.Atoi(major); err != nil {
+		return Version{}, fmt.Errorf("invalid OpenSSH version: %#v", s)
+	}
+	if version.Minor, err = strconv.Atoi(minor); err != nil {
+		return Version{}, fmt.Errorf("invalid OpenSSH version: %#v", s)
+	}
+	return version, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Before reports whether v is older than other.
+func (v Version) Before(other Version) bool {
+	return v.Major < other.Major || (v.Major == other.Major && v.Minor < other.Minor)
+}
+
+// keywordVersions maps lowercased keywords to the OpenSSH version adding
+// them. Keywords older than OpenSSH 6.0 are not listed.
+var keywordVersions = map[string]Version{
+	"ignoreunknown":               {6, 3},
+	"canonicalizehostname":        {6, 5},
+	"canonicaldomains":            {6, 5},
+	"canonicalizemaxdots":         {6, 5},
+	"canonicalizefallbacklocal":   {6, 5},
+	"canonicalizepermittedcnames": {6, 5},
+	"proxyusefdpass":              {6, 5},
+	"streamlocalbindmask":         {6, 7},
+	"streamlocalbindunlink":       {6, 7},
+	"updatehostkeys":              {6, 8},
+	"fingerprinthash":             {6, 8},
+	"addkeystoagent":              {7, 2},
+	"certificatefile":             {7, 2},
+	"include":                     {7, 3},
+	"proxyjump":                   {7, 3},
+	"identityagent":               {7, 3},
+	"remotecommand":               {7, 6},
+	"setenv":                      {7, 8},
+	"casignaturealgorithms":       {7, 9},
+	"securitykeyprovider":         {8, 2},
+	"pubkeyacceptedalgorithms":    {8, 5},
+	"hostbasedacceptedalgorithms": {8, 5},
+	"knownhostscommand":           {8, 5},
+	"sessiontype":                 {8, 7},
+	"stdinnull":                   {8, 7},
+	"forkafterauthentication":     {8, 7},
+	"permitremoteopen":            {8, 7},
+	"requiredrsasize":             {9, 1},
+	"enableescapecommandline":     {9, 2},
+	"channeltimeout":              {9, 2},
+	"tag":                         {9, 4},
+	"obscurekeystroketiming":      {9, 7},
+}
+
+// removedKeywords maps lowercased keywords to the OpenSSH version removing
+// them. Protocol 1 support and its keywords were removed in 7.6.
+var removedKeywords = map[string]Version{
+	"protocol":                {7, 6},
+	"cipher":                  {7, 6},
+	"rsaauthentication":       {7, 6},
+	"rhostsrsaauthentication": {7, 6},
+	"compressionlevel":        {7, 6},
+}
+
+// matchVersions maps Match criteria to the OpenSSH version adding them.
+var matchVersions = map[string]Version{
+	"tagged":       {9, 4},
+	"localnetwork": {9, 4},
+}
+
+// valueVersions maps lowercased keywords and values to the OpenSSH version
+// adding the value.
+var valueVersions = map[string]map[string]Version{
+	"stricthostkeychecking": {"accept-new": {7, 6}},
+}
+
+// ValidateVersion checks that every keyword and value used by hosts is
+// supported by the given OpenSSH version. Keywords unknown to the parser
+// are only checked if they are known to be removed.
+func ValidateVersion(hosts []*SSHHost, v Version) []Finding {
+	var findings []Finding
+	add := func(rule string, file string, line int, format string, args ...any) {
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: SeverityError,
+			File:     file,
+			Line:     line,
+			Msg:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, h := range hosts {
+		for _, c := range h.Match {
+			if added, ok := matchVersions[c.Keyword]; ok && v.Before(added) {
+				add(RuleUnsupportedValue, h.SourceFile, h.SourceLine,
+					"Match %s requires OpenSSH %s", c.Keyword, added)
+			}
+		}
+
+		for _, d := range h.Directives {
+			keyword := strings.ToLower(d.Keyword)
+			if added, ok := keywordVersions[keyword]; ok && v.Before(added) {
+				add(RuleUnsupportedKeyword, d.File, d.Line, "%s requires OpenSSH %s", d.Keyword, added)
+				continue
+			}
+			if removed, ok := removedKeywords[keyword]; ok && !v.Before(removed) {
+				add(RuleRemovedKeyword, d.File, d.Line, "%s is removed in OpenSSH %s", d.Keyword, removed)
+				continue
+			}
+			if added, ok := valueVersions[keyword][strings.ToLower(d.Value)]; ok && v.Before(added) {
+				add(RuleUnsupportedValue, d.File, d.Line, "%s %s requires OpenSSH %s", d.Keyword, d.Value, added)
+			}
+		}
+	}
+	return findings
+}
+
+// ValidateVersion checks the hosts of the config against an OpenSSH
+// version, see ValidateVersion.
+func (c *Config) ValidateVersion(v Version) []Finding {
+	return ValidateVersion(c.hosts, v)
+}
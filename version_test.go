@@ -0,0 +1,67 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	for s, expected := range map[string]Version{
+		"8.2":                        {8, 2},
+		"8.2p1":                      {8, 2},
+		"OpenSSH_9.6p1 Ubuntu-3":     {9, 6},
+		"OpenSSH_7.4p1, OpenSSL 1.0": {7, 4},
+	} {
+		v, err := ParseVersion(s)
+		if err != nil {
+			t.Errorf("unable to parse %#v: %s", s, err.Error())
+			continue
+		}
+		if v != expected {
+			t.Errorf("unexpected version for %#v: %s", s, v)
+		}
+	}
+
+	for _, s := range []string{"", "8", "eight.two", "8.x"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("expected error for %#v", s)
+		}
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	config := `Host web
+  ProxyJump bastion
+  SetEnv FOO=bar
+  StrictHostKeyChecking accept-new
+  Protocol 2
+
+Match tagged prod
+  SessionType none
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	findings := NewConfig(hosts).ValidateVersion(Version{7, 4})
+	expected := []string{
+		"~/.ssh/config:3: error: SetEnv requires OpenSSH 7.8 (unsupported-keyword)",
+		"~/.ssh/config:4: error: StrictHostKeyChecking accept-new requires OpenSSH 7.6 (unsupported-value)",
+		"~/.ssh/config:7: error: Match tagged requires OpenSSH 9.4 (unsupported-value)",
+		"~/.ssh/config:8: error: SessionType requires OpenSSH 8.7 (unsupported-keyword)",
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %v", len(expected), len(findings), findings)
+	}
+	for i, f := range findings {
+		if f.String() != expected[i] {
+			t.Errorf("unexpected finding: %s, expected %s", f, expected[i])
+		}
+	}
+
+	findings = ValidateVersion(hosts, Version{9, 6})
+	if len(findings) != 1 || findings[0].String() != "~/.ssh/config:5: error: Protocol is removed in OpenSSH 7.6 (removed-keyword)" {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
@@ -0,0 +1,187 @@
+package sshconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Handler holds the callbacks invoked by Walk. Any callback may be nil, an
+// error returned from a callback stops the walk and is returned by Walk.
+type Handler struct {
+	// HostStart is called for every Host and Match line, the directive
+	// value holds the raw patterns or criteria.
+	HostStart func(d Directive) error
+	// HostEnd is called when the block started last ends.
+	HostEnd func() error
+	// Directive is called for every other directive with its raw value.
+	Directive func(d Directive) error
+	// Include is called for every Include directive before the included
+	// files are walked.
+	Include func(d Directive) error
+}
+
+// Walk reads the SSH config given by path and passes every directive to the
+// callbacks of h, following Include directives, without building hosts.
+// This is a lot cheaper than Parse when only a few keywords are of interest.
+//
+// When a file included from within a block starts blocks of its own, the
+// including block is started again before its next directive, so directives
+// are always reported within the block they belong to.
+func Walk(path string, h Handler, opts ...Option) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	w := &walker{h: h}
+	if err := w.walk(string(content), path, opts, nil); err != nil {
+		return err
+	}
+	return w.end()
+}
+
+// walker holds the state of a Walk
+type walker struct {
+	h Handler
+	// open is the block last started by HostStart
+	open *Directive
+	// resume is the block to start again before the next directive
+	resume *Directive
+}
+
+func (w *walker) start(d *Directive) error {
+	if err := w.end(); err != nil {
+		return err
+	}
+
+	w.open = d
+	w.resume = nil
+	if w.h.HostStart != nil {
+		return w.h.HostStart(*d)
+	}
+	return nil
+}
+
+func (w *walker) end() error {
+	if w.open == nil {
+		return nil
+	}
+
+	w.open = nil
+	if w.h.HostEnd != nil {
+		return w.h.HostEnd()
+	}
+	return nil
+}
+
+func (w *walker) directive(d Directive) error {
+	if w.resume != nil {
+		if err := w.start(w.resume); err != nil {
+			return err
+		}
+	}
+
+	if w.h.Directive != nil {
+		return w.h.Directive(d)
+	}
+	return nil
+}
+
+// walk passes the directives of input to the handler. The block the file is
+// included from is given as enclosing.
+func (w *walker) walk(input string, path string, opts []Option, enclosing *Directive) error {
+	o := newOptions(opts)
+	current := enclosing
+
+	lexer := lex(input)
+	defer lexer.drain()
+
+	for {
+		token := lexer.nextItem()
+
+		switch token.typ {
+		case itemHost, itemMatch:
+			value := lexer.nextItem()
+			if value.typ != itemValue && value.typ != itemHostValue {
+				return newParseError(input, path, value, token.val, valueError(token, value))
+			}
+
+			current = &Directive{Keyword: token.val, Value: value.val, File: path, Line: token.line}
+			if err := w.start(current); err != nil {
+				return err
+			}
+		case itemInclude:
+			value := lexer.nextItem()
+			if value.typ != itemValue {
+				return newParseError(input, path, value, token.val, valueError(token, value))
+			}
+
+			if w.h.Include != nil {
+				err := w.h.Include(Directive{Keyword: token.val, Value: value.val, File: path, Line: token.line})
+				if err != nil {
+					return err
+				}
+			}
+
+			if o.depth >= o.includeDepth {
+				return newParseError(input, path, value, token.val, fmt.Errorf("maximum include depth of %d exceeded", o.includeDepth))
+			}
+
+			includeOpts := append(opts[:len(opts):len(opts)], atDepth(o.depth+1))
+			if err := w.walkInclude(path, value.val, o, includeOpts, current); err != nil {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					err = newParseError(input, path, value, token.val, err)
+				}
+				return err
+			}
+		case itemError:
+			return newParseError(input, path, token, "", errors.New(token.val))
+		case itemEOF:
+			return nil
+		case itemValue, itemHostValue:
+			// continue onwards
+		default:
+			value := lexer.nextItem()
+			if value.typ != itemValue {
+				return newParseError(input, path, value, token.val, valueError(token, value))
+			}
+
+			if err := w.directive(Directive{Keyword: token.val, Value: value.val, File: path, Line: token.line}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// walkInclude walks all files matched by the include patterns. Every file
+// starts out in the enclosing block.
+func (w *walker) walkInclude(currentPath string, patterns string, o *options, opts []Option, enclosing *Directive) error {
+	for _, pattern := range strings.Fields(patterns) {
+		files, err := includeFiles(currentPath, pattern, o)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range files {
+			content, err := o.resolver.ReadFile(f)
+			if err != nil {
+				return err
+			}
+
+			if err := w.walk(string(content), f, opts, enclosing); err != nil {
+				return err
+			}
+
+			if w.open != enclosing {
+				if err := w.end(); err != nil {
+					return err
+				}
+				w.resume = enclosing
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package sshconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+
+	config := `Host web
+  User deploy
+  Include extra.conf
+  Port 2222
+
+Host *
+  IdentityFile ~/.ssh/id_ed25519
+`
+	extra := `HostName web.example.com
+
+Host db
+  User postgres
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(config), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "extra.conf"), []byte(extra), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	var events []string
+	h := Handler{
+		HostStart: func(d Directive) error {
+			events = append(events, "start "+d.Value)
+			return nil
+		},
+		HostEnd: func() error {
+			events = append(events, "end")
+			return nil
+		},
+		Directive: func(d Directive) error {
+			events = append(events, d.Keyword+" "+d.Value)
+			return nil
+		},
+		Include: func(d Directive) error {
+			events = append(events, "include "+d.Value)
+			return nil
+		},
+	}
+
+	if err := Walk(filepath.Join(dir, "config"), h); err != nil {
+		t.Fatalf("unable to walk config: %s", err.Error())
+	}
+
+	expected := []string{
+		"start web",
+		"User deploy",
+		"include extra.conf",
+		"HostName web.example.com",
+		"end",
+		"start db",
+		"User postgres",
+		"end",
+		"start web",
+		"Port 2222",
+		"end",
+		"start *",
+		"IdentityFile ~/.ssh/id_ed25519",
+		"end",
+	}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("unexpected events:\n%v\nexpected:\n%v", events, expected)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("Host web\n  User deploy\n  Port 2222\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %s", err.Error())
+	}
+
+	errStop := errors.New("stop")
+	var keywords []string
+	err := Walk(path, Handler{
+		Directive: func(d Directive) error {
+			keywords = append(keywords, d.Keyword)
+			return errStop
+		},
+	})
+	if err != errStop {
+		t.Errorf("expected stop error, got %v", err)
+	}
+	if len(keywords) != 1 {
+		t.Errorf("expected walk to stop after first directive, got %v", keywords)
+	}
+}
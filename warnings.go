@@ -0,0 +1,111 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warning describes a valid but suspicious directive found while parsing,
+// like a deprecated keyword or a keyword given twice in the same block.
+type Warning struct {
+	File      string
+	Line      int
+	Directive string
+	Msg       string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s:%d: %s", w.File, w.Line, w.Msg)
+}
+
+// WithWarnings registers fn to be called for every warning found while
+// parsing.
+func WithWarnings(fn func(Warning)) Option {
+	return func(o *options) {
+		o.warn = fn
+	}
+}
+
+// deprecatedKeywords maps keywords removed or renamed in OpenSSH to their
+// replacement, if any.
+var deprecatedKeywords = map[string]string{
+	"protocol":                        "",
+	"cipher":                          "Ciphers",
+	"rsaauthentication":               "",
+	"rhostsrsaauthentication":         "",
+	"compressionlevel":                "",
+	"useroaming":                      "",
+	"useprivilegedport":               "",
+	"fallbacktorsh":                   "",
+	"usersh":                          "",
+	"dsaauthentication":               "PubkeyAuthentication",
+	"keepalive":                       "TCPKeepAlive",
+	"challengeresponseauthentication": "KbdInteractiveAuthentication",
+	"pubkeyacceptedkeytypes":          "PubkeyAcceptedAlgorithms",
+	"hostbasedkeytypes":               "HostbasedAcceptedAlgorithms",
+	"smartcarddevice":                 "PKCS11Provider",
+}
+
+// multiValued are the keywords which may be given multiple times in a block
+var multiValued = map[itemType]bool{
+	itemIdentityFile:    true,
+	itemCertificateFile: true,
+	itemLocalForward:    true,
+	itemRemoteForward:   true,
+	itemDynamicForward:  true,
+	itemSendEnv:         true,
+	itemSetEnv:          true,
+}
+
+// ignoresUnknown reports whether the unknown keyword matches the patterns of
+// an IgnoreUnknown directive read before it.
+func (o *options) ignoresUnknown(keyword string) bool {
+	return matchHost(*o.ignoreUnknown, strings.ToLower(keyword))
+}
+
+// directiveWarning returns a warning message for the directive keyword about
+// to be added to host, or an empty string.
+func directiveWarning(host *SSHHost, typ itemType, keyword string) string {
+	if typ == itemUnknown {
+		if msg := deprecationWarning(keyword); msg != "" {
+			return msg
+		}
+		if _, _, ok := registeredKeyword(keyword); ok {
+			return ""
+		}
+		return fmt.Sprintf("unsupported keyword %s", keyword)
+	}
+
+	if d := duplicateOf(host, typ, keyword); d != nil {
+		return fmt.Sprintf("duplicate %s, previously set on line %d", keyword, d.Line)
+	}
+	return ""
+}
+
+// deprecationWarning returns a warning message if keyword is deprecated,
+// or an empty string.
+func deprecationWarning(keyword string) string {
+	replacement, ok := deprecatedKeywords[strings.ToLower(keyword)]
+	switch {
+	case !ok:
+		return ""
+	case replacement != "":
+		return fmt.Sprintf("%s is deprecated, use %s instead", keyword, replacement)
+	}
+	return fmt.Sprintf("%s is deprecated and ignored by OpenSSH", keyword)
+}
+
+// duplicateOf returns the directive of host which already set the single
+// valued keyword, or nil.
+func duplicateOf(host *SSHHost, typ itemType, keyword string) *Directive {
+	if multiValued[typ] || typ == itemUnknown {
+		return nil
+	}
+
+	for i, d := range host.Directives {
+		if strings.EqualFold(d.Keyword, keyword) {
+			return &host.Directives[i]
+		}
+	}
+	return nil
+}
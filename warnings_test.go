@@ -0,0 +1,77 @@
+package sshconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	config := `Host face
+  HostName facebook.com
+  Protocol 2
+  Cipher blowfish
+  User mark
+  IdentityFile ~/.ssh/a
+  IdentityFile ~/.ssh/b
+  User zuck
+  VisualHostKey yes`
+
+	var warnings []string
+	_, err := parse(config, "~/.ssh/config", WithWarnings(func(w Warning) {
+		warnings = append(warnings, w.String())
+	}))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []string{
+		"~/.ssh/config:3: Protocol is deprecated and ignored by OpenSSH",
+		"~/.ssh/config:4: Cipher is deprecated, use Ciphers instead",
+		"~/.ssh/config:8: duplicate User, previously set on line 5",
+		"~/.ssh/config:9: unsupported keyword VisualHostKey",
+	}
+	if !reflect.DeepEqual(expected, warnings) {
+		t.Errorf("unexpected warnings:\n%#v", warnings)
+	}
+}
+
+func TestIgnoreUnknown(t *testing.T) {
+	resolver := mapResolver{
+		"/team/ssh/vendor.conf": "Host vendor\n  UseKeychain yes\n  XAuthLocation /opt/X11/bin/xauth\n",
+	}
+
+	config := `VisualHostKey yes
+IgnoreUnknown UseKeychain,visual*
+
+Host web
+  VisualHostKey no
+  Include vendor.conf`
+
+	var warnings []string
+	hosts, err := ParseString(config, "/team/ssh/config", WithIncludeResolver(resolver), WithWarnings(func(w Warning) {
+		warnings = append(warnings, w.String())
+	}))
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	expected := []string{
+		"/team/ssh/config:1: unsupported keyword VisualHostKey",
+		"/team/ssh/vendor.conf:3: unsupported keyword XAuthLocation",
+	}
+	if !reflect.DeepEqual(expected, warnings) {
+		t.Errorf("unexpected warnings:\n%#v", warnings)
+	}
+
+	if !reflect.DeepEqual(hosts[0].IgnoreUnknown, []string{"UseKeychain", "visual*"}) {
+		t.Errorf("unexpected IgnoreUnknown: %v", hosts[0].IgnoreUnknown)
+	}
+	if v, _ := hosts[2].Get("UseKeychain"); v != "yes" {
+		t.Errorf("expected ignored keyword to be kept, got %#v", v)
+	}
+
+	_, err = ParseString(config, "/team/ssh/config", WithIncludeResolver(resolver), WithStrictMode())
+	if err == nil || err.Error() != "/team/ssh/config:1:1: unsupported keyword VisualHostKey" {
+		t.Errorf("unexpected strict error: %v", err)
+	}
+}
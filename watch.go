@@ -0,0 +1,249 @@
+package sshconfig
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay is how long the Watcher waits for further changes before
+// parsing, editors often write a file in several steps.
+var watchDelay = 100 * time.Millisecond
+
+// Watcher watches an SSH config including every file it includes and parses
+// it again on changes.
+type Watcher struct {
+	// Hosts receives the hosts of the config, first as parsed by Watch and
+	// then after every change. Hosts not received before the next change
+	// are dropped, only the latest state is delivered.
+	Hosts <-chan []*SSHHost
+	// Errors receives errors parsing the config after a change and errors
+	// watching the files. Like Hosts only the latest error is kept.
+	Errors <-chan error
+
+	path    string
+	opts    []Option
+	watcher *fsnotify.Watcher
+	hosts   chan []*SSHHost
+	errs    chan error
+	done    chan struct{}
+	close   sync.Once
+
+	// files and patterns are the files read and the include patterns
+	// globbed by the last parse, dirs the directories watched for them
+	files    map[string]bool
+	patterns []string
+	dirs     map[string]bool
+}
+
+// Watch parses the SSH config given by path and watches it and every
+// included file for changes, including files newly matching an Include
+// pattern. Files are read from the local filesystem, an include resolver set
+// in opts is not used. The Watcher must be closed when no longer needed.
+func Watch(path string, opts ...Option) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(chan []*SSHHost)
+	errs := make(chan error)
+	w := &Watcher{
+		Hosts:   hosts,
+		Errors:  errs,
+		path:    path,
+		opts:    opts,
+		watcher: fw,
+		hosts:   hosts,
+		errs:    errs,
+		done:    make(chan struct{}),
+		dirs:    map[string]bool{},
+	}
+
+	parsed, err := w.parse()
+	if err != nil {
+		fw.Close()
+		return nil, err
+	}
+
+	go w.run(parsed)
+	return w, nil
+}
+
+// Close stops watching, after which Hosts and Errors are closed.
+func (w *Watcher) Close() error {
+	var err error
+	w.close.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
+
+func (w *Watcher) run(hosts []*SSHHost) {
+	defer close(w.hosts)
+	defer close(w.errs)
+
+	var (
+		pendingHosts = true
+		pendingErr   error
+		delay        <-chan time.Time
+	)
+
+	for {
+		var hostsOut chan<- []*SSHHost
+		if pendingHosts {
+			hostsOut = w.hosts
+		}
+
+		var errsOut chan<- error
+		if pendingErr != nil {
+			errsOut = w.errs
+		}
+
+		select {
+		case <-w.done:
+			return
+		case hostsOut <- hosts:
+			pendingHosts = false
+		case errsOut <- pendingErr:
+			pendingErr = nil
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.relevant(event.Name) {
+				delay = time.After(watchDelay)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			pendingErr = err
+		case <-delay:
+			delay = nil
+			parsed, err := w.parse()
+			if err != nil {
+				pendingErr = err
+				continue
+			}
+			hosts, pendingHosts = parsed, true
+		}
+	}
+}
+
+// parse parses the config, recording the files it depends on and updating
+// the watched directories.
+func (w *Watcher) parse() ([]*SSHHost, error) {
+	r := &recordingResolver{IncludeResolver: osResolver{}}
+	opts := append(w.opts[:len(w.opts):len(w.opts)], WithIncludeResolver(r))
+	hosts, err := Parse(w.path, opts...)
+
+	w.files = map[string]bool{filepath.Clean(w.path): true}
+	for _, f := range r.files {
+		w.files[filepath.Clean(f)] = true
+	}
+	w.patterns = r.patterns
+
+	dirs := map[string]bool{}
+	for f := range w.files {
+		dirs[filepath.Dir(f)] = true
+	}
+	for _, p := range w.patterns {
+		dir := filepath.Dir(p)
+		for hasMeta(dir) {
+			dir = filepath.Dir(dir)
+		}
+		dirs[dir] = true
+	}
+	for _, dir := range r.dirs {
+		dirs[filepath.Clean(dir)] = true
+	}
+
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.watcher.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+	for dir := range dirs {
+		// directories which don't exist yet are picked up on the
+		// next change
+		if !w.dirs[dir] && w.watcher.Add(dir) == nil {
+			w.dirs[dir] = true
+		}
+	}
+
+	return hosts, err
+}
+
+// relevant reports whether a change of name affects the config.
+func (w *Watcher) relevant(name string) bool {
+	name = filepath.Clean(name)
+	if w.files[name] {
+		return true
+	}
+	for _, p := range w.patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// recordingResolver records the files read, the patterns globbed and the
+// included directories.
+type recordingResolver struct {
+	IncludeResolver
+
+	mu       sync.Mutex
+	files    []string
+	patterns []string
+	dirs     []string
+}
+
+func (r *recordingResolver) Glob(pattern string) ([]string, error) {
+	r.mu.Lock()
+	r.patterns = append(r.patterns, pattern)
+	r.mu.Unlock()
+	return r.IncludeResolver.Glob(pattern)
+}
+
+func (r *recordingResolver) ReadFile(name string) ([]byte, error) {
+	r.mu.Lock()
+	r.files = append(r.files, name)
+	r.mu.Unlock()
+	return r.IncludeResolver.ReadFile(name)
+}
+
+// Stat passes the call to the wrapped resolver so included directories are
+// detected as with Parse.
+func (r *recordingResolver) Stat(name string) (fs.FileInfo, error) {
+	sr, ok := r.IncludeResolver.(statResolver)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: errors.ErrUnsupported}
+	}
+	info, err := sr.Stat(name)
+	if err == nil && info.IsDir() {
+		r.mu.Lock()
+		r.dirs = append(r.dirs, name)
+		r.mu.Unlock()
+	}
+	return info, err
+}
+
+// hasMeta reports whether path contains any of the magic characters
+// recognized by filepath.Match.
+func hasMeta(path string) bool {
+	for _, c := range path {
+		switch c {
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
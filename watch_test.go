@@ -0,0 +1,100 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "conf.d"), 0700); err != nil {
+		t.Fatalf("unable to create dir: %s", err.Error())
+	}
+
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write file: %s", err.Error())
+		}
+	}
+	write("config", "Include conf.d/*.conf\n")
+	write("conf.d/a.conf", "Host a\n")
+
+	w, err := Watch(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("unable to watch config: %s", err.Error())
+	}
+	defer w.Close()
+
+	receive := func(n int) {
+		t.Helper()
+		select {
+		case hosts := <-w.Hosts:
+			if len(hosts) != n {
+				t.Errorf("expected %d hosts, got %d", n, len(hosts))
+			}
+		case err := <-w.Errors:
+			t.Fatalf("unexpected error: %s", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for hosts")
+		}
+	}
+
+	receive(1)
+
+	// a file newly matching the include pattern
+	write("conf.d/b.conf", "Host b\n")
+	receive(2)
+
+	write("conf.d/a.conf", "Host a\nHost c\n")
+	receive(3)
+
+	if err := w.Close(); err != nil {
+		t.Errorf("unable to close watcher: %s", err)
+	}
+	if _, ok := <-w.Hosts; ok {
+		t.Error("expected Hosts to be closed")
+	}
+}
+
+func TestWatchIncludeDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "conf.d"), 0700); err != nil {
+		t.Fatalf("unable to create dir: %s", err.Error())
+	}
+
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write file: %s", err.Error())
+		}
+	}
+	write("config", "Include conf.d\n")
+	write("conf.d/a.conf", "Host a\n")
+
+	w, err := Watch(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("unable to watch config: %s", err.Error())
+	}
+	defer w.Close()
+
+	receive := func(n int) {
+		t.Helper()
+		select {
+		case hosts := <-w.Hosts:
+			if len(hosts) != n {
+				t.Errorf("expected %d hosts, got %d", n, len(hosts))
+			}
+		case err := <-w.Errors:
+			t.Fatalf("unexpected error: %s", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for hosts")
+		}
+	}
+
+	receive(1)
+
+	// a file newly added to the included directory
+	write("conf.d/b", "Host b\n")
+	receive(2)
+}
@@ -0,0 +1,204 @@
+package sshconfig
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// WriteOption configures how Marshal renders hosts
+type WriteOption func(*writeOptions)
+
+// KeywordCase is the spelling of keywords written by Marshal.
+type KeywordCase int
+
+const (
+	// CanonicalCase writes keywords the way OpenSSH documents them, like
+	// HostName. Unknown keywords are written as they were read.
+	CanonicalCase KeywordCase = iota
+	// LowerCase writes every keyword in lower case, like hostname.
+	LowerCase
+)
+
+// HostOrder is the order in which Marshal writes hosts.
+type HostOrder int
+
+const (
+	// OriginalOrder writes hosts in the order given.
+	OriginalOrder HostOrder = iota
+	// SortedOrder writes hosts sorted by their first alias, Match blocks by
+	// their criteria. Global options stay in front. As ssh uses the first
+	// value obtained for a keyword, sorting may change the effective
+	// configuration of hosts matched by more than one block.
+	SortedOrder
+	// SourceOrder groups hosts by the file they were read from, in the
+	// order the files are first seen. Each group starts with a comment
+	// naming the file.
+	SourceOrder
+)
+
+type writeOptions struct {
+	indent      string
+	keywordCase KeywordCase
+	align       bool
+	blankLines  int
+	defaults    bool
+	order       HostOrder
+}
+
+func newWriteOptions(opts []WriteOption) *writeOptions {
+	o := &writeOptions{
+		indent:     "  ",
+		blankLines: 1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// keyword returns keyword in the configured case.
+func (o *writeOptions) keyword(keyword string) string {
+	if o.keywordCase == LowerCase {
+		return strings.ToLower(keyword)
+	}
+	return keyword
+}
+
+// WithIndent sets the indentation of the directives of a block. It defaults
+// to two spaces.
+func WithIndent(indent string) WriteOption {
+	return func(o *writeOptions) {
+		o.indent = indent
+	}
+}
+
+// WithKeywordCase sets the spelling of keywords. It defaults to
+// CanonicalCase.
+func WithKeywordCase(c KeywordCase) WriteOption {
+	return func(o *writeOptions) {
+		o.keywordCase = c
+	}
+}
+
+// WithAlignedValues pads the keywords of a block so their values start in
+// the same column.
+func WithAlignedValues() WriteOption {
+	return func(o *writeOptions) {
+		o.align = true
+	}
+}
+
+// WithBlankLines sets the number of blank lines between hosts. It defaults
+// to one.
+func WithBlankLines(n int) WriteOption {
+	return func(o *writeOptions) {
+		o.blankLines = max(n, 0)
+	}
+}
+
+// WithDefaultValues writes values which are the same as OpenSSH's default,
+// like Port 22, which are left out otherwise.
+func WithDefaultValues() WriteOption {
+	return func(o *writeOptions) {
+		o.defaults = true
+	}
+}
+
+// WithHostOrder sets the order in which hosts are written. It defaults to
+// OriginalOrder.
+func WithHostOrder(order HostOrder) WriteOption {
+	return func(o *writeOptions) {
+		o.order = order
+	}
+}
+
+// Marshal renders hosts as a config file, writing each as a Host or Match
+// block like MarshalText does. The Comments of a host and an annotation with
+// its Tags are written above its block, preceded by a comment naming its
+// Section where it changes.
+func Marshal(hosts []*SSHHost, opts ...WriteOption) ([]byte, error) {
+	o := newWriteOptions(opts)
+
+	var b bytes.Buffer
+	var file, section string
+	for i, h := range orderHosts(hosts, o.order) {
+		if i > 0 {
+			b.WriteString(strings.Repeat("\n", o.blankLines))
+		}
+		if o.order == SourceOrder && (i == 0 || h.SourceFile != file) {
+			file = h.SourceFile
+			if file != "" {
+				b.WriteString("# " + file + "\n")
+			}
+		}
+		if h.Section != section {
+			section = h.Section
+			if section != "" {
+				b.WriteString("# " + section + "\n\n")
+			}
+		}
+		writeComments(&b, h)
+		if err := writeHost(&b, h, o); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// orderHosts returns hosts in the given order.
+func orderHosts(hosts []*SSHHost, order HostOrder) []*SSHHost {
+	switch order {
+	case SortedOrder:
+		sorted := slices.Clone(hosts)
+		slices.SortStableFunc(sorted, func(a, b *SSHHost) int {
+			if a.IsGlobal() || b.IsGlobal() {
+				return compareBool(b.IsGlobal(), a.IsGlobal())
+			}
+			return strings.Compare(sortKey(a), sortKey(b))
+		})
+		return sorted
+	case SourceOrder:
+		var files []string
+		groups := map[string][]*SSHHost{}
+		for _, h := range hosts {
+			if _, ok := groups[h.SourceFile]; !ok {
+				files = append(files, h.SourceFile)
+			}
+			groups[h.SourceFile] = append(groups[h.SourceFile], h)
+		}
+		grouped := make([]*SSHHost, 0, len(hosts))
+		for _, file := range files {
+			grouped = append(grouped, groups[file]...)
+		}
+		return grouped
+	}
+	return hosts
+}
+
+// sortKey returns the string hosts are sorted by.
+func sortKey(h *SSHHost) string {
+	if h.Match != nil {
+		criteria := make([]string, len(h.Match))
+		for i, c := range h.Match {
+			criteria[i] = c.String()
+		}
+		// sort Match blocks after Host blocks
+		return "\xff" + strings.Join(criteria, " ")
+	}
+	if len(h.Host) == 0 {
+		return ""
+	}
+	return strings.ToLower(h.Host[0])
+}
+
+// compareBool orders false before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
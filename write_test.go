@@ -0,0 +1,162 @@
+package sshconfig
+
+import (
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	config := `Host web
+  HostName web.example.com
+  User deploy
+  UseKeychain yes
+
+Host db
+  User postgres
+  Port 5432
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     []WriteOption
+		lowered  bool
+		expected string
+	}{
+		{
+			name:     "defaults",
+			expected: config,
+		},
+		{
+			name: "styled",
+			opts: []WriteOption{
+				WithIndent("\t"),
+				WithKeywordCase(LowerCase),
+				WithAlignedValues(),
+				WithBlankLines(2),
+				WithDefaultValues(),
+			},
+			lowered: true,
+			expected: "host web\n" +
+				"\thostname    web.example.com\n" +
+				"\tuser        deploy\n" +
+				"\tport        22\n" +
+				"\tusekeychain yes\n" +
+				"\n\n" +
+				"host db\n" +
+				"\tuser postgres\n" +
+				"\tport 5432\n",
+		},
+		{
+			name:     "no blank lines",
+			opts:     []WriteOption{WithBlankLines(0), WithIndent("    ")},
+			expected: "Host web\n    HostName web.example.com\n    User deploy\n    UseKeychain yes\nHost db\n    User postgres\n    Port 5432\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := Marshal(hosts, tc.opts...)
+			if err != nil {
+				t.Fatalf("unable to marshal hosts: %s", err.Error())
+			}
+			if string(text) != tc.expected {
+				t.Errorf("unexpected text:\n%s\nexpected:\n%s", text, tc.expected)
+			}
+
+			parsed, err := parse(string(text), "~/.ssh/config")
+			if err != nil {
+				t.Fatalf("unable to parse rendered hosts: %s", err.Error())
+			}
+			// unknown keywords are kept in the case they are read in
+			if tc.lowered {
+				parsed[0].Unknowns = hosts[0].Unknowns
+			}
+			// WithDefaultValues writes the default port
+			parsed[0].PortSet = hosts[0].PortSet
+			compare(t, hosts, parsed)
+		})
+	}
+
+	if _, err := Marshal([]*SSHHost{{}}); err == nil {
+		t.Errorf("expected error for host without aliases")
+	}
+}
+
+func TestMarshalHostOrder(t *testing.T) {
+	config := `User admin
+
+Match user root
+  IdentityFile ~/.ssh/root
+
+Host web
+  User deploy
+
+Host Cache
+  User redis
+
+Host app
+  User app
+`
+
+	hosts, err := parse(config, "~/.ssh/config")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err.Error())
+	}
+	hosts[2].SourceFile = "~/.ssh/config.d/web"
+	hosts[4].SourceFile = "~/.ssh/config.d/web"
+
+	text, err := Marshal(hosts, WithHostOrder(SortedOrder))
+	if err != nil {
+		t.Fatalf("unable to marshal hosts: %s", err.Error())
+	}
+	expected := `Host *
+  User admin
+
+Host app
+  User app
+
+Host Cache
+  User redis
+
+Host web
+  User deploy
+
+Match user root
+  IdentityFile ~/.ssh/root
+`
+	if string(text) != expected {
+		t.Errorf("unexpected text:\n%s\nexpected:\n%s", text, expected)
+	}
+
+	text, err = Marshal(hosts, WithHostOrder(SourceOrder))
+	if err != nil {
+		t.Fatalf("unable to marshal hosts: %s", err.Error())
+	}
+	expected = `# ~/.ssh/config
+Host *
+  User admin
+
+Match user root
+  IdentityFile ~/.ssh/root
+
+Host Cache
+  User redis
+
+# ~/.ssh/config.d/web
+Host web
+  User deploy
+
+Host app
+  User app
+`
+	if string(text) != expected {
+		t.Errorf("unexpected text:\n%s\nexpected:\n%s", text, expected)
+	}
+
+	// the given order is left alone
+	if hosts[2].Host[0] != "web" {
+		t.Errorf("hosts were reordered in place")
+	}
+}